@@ -0,0 +1,83 @@
+package casbin
+
+import (
+	"database/sql"
+	"errors"
+	"exam/api-gateway/pkg/etc"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// APIKeyHeader carries the api key of a machine client instead of a bearer token
+	APIKeyHeader = "X-API-Key"
+	// APIKeyPrefix starts the "sub" of requests made with an api key
+	APIKeyPrefix = "apikey:"
+)
+
+// AuthenticateAPIKey enforces the request for every scope of the key until one allows it,
+// that scope becomes the "role" of the request and "apikey:<id>" its "sub"
+func (a *CasbinHandler) AuthenticateAPIKey(ctx *gin.Context, secret string) {
+	key, err := a.apiKeys.GetByHash(etc.HashToken(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		a.RequireAPIKey(ctx, "Valid API key is required")
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"Status":  "Internal server error",
+			"Message": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	switch {
+	case key.RevokedAt != nil:
+		a.RequireAPIKey(ctx, "API key is revoked")
+		return
+	case key.ExpiresAt.Before(now):
+		a.RequireAPIKey(ctx, "API key expired")
+		return
+	case key.KeyHash != etc.HashToken(secret) && (key.PreviousKeyExpiresAt == nil || key.PreviousKeyExpiresAt.Before(now)):
+		a.RequireAPIKey(ctx, "API key was rotated")
+		return
+	}
+
+	if err := a.apiKeys.Touch(key.Id); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"Status":  "Internal server error",
+			"Message": err.Error(),
+		})
+		return
+	}
+
+	for _, scope := range key.Scopes {
+		allowed, err := a.CheckPermission(scope, ctx.Request)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"Status":  "Internal server error",
+				"Message": err.Error(),
+			})
+			return
+		}
+		if allowed {
+			ctx.Set("role", scope)
+			ctx.Set("sub", APIKeyPrefix+key.Id)
+			ctx.Set("api_key", key)
+			ctx.Next()
+			return
+		}
+	}
+
+	a.RequirePermission(ctx)
+}
+
+func (a *CasbinHandler) RequireAPIKey(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"Status":  "unauthorized",
+		"Message": message,
+	})
+}
@@ -0,0 +1,110 @@
+package casbin
+
+import (
+	"database/sql"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/etc"
+	admin "exam/api-gateway/storage/postgresrepo"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// apiKeyStorage finds keys by their current or previous hash like the
+// api_keys table, only the methods the middleware calls are implemented
+type apiKeyStorage struct {
+	admin.APIKeyStorageI
+	keys    []*models.APIKey
+	touched []string
+}
+
+func (a *apiKeyStorage) GetByHash(hash string) (*models.APIKey, error) {
+	for _, key := range a.keys {
+		if key.KeyHash == hash || (key.PreviousKeyHash != "" && key.PreviousKeyHash == hash) {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (a *apiKeyStorage) Touch(id string) error {
+	a.touched = append(a.touched, id)
+	return nil
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	apiKeys := &apiKeyStorage{keys: []*models.APIKey{
+		{Id: "reader", KeyHash: etc.HashToken("reader-secret"), Scopes: []string{"user"}, ExpiresAt: later},
+		{Id: "multi", KeyHash: etc.HashToken("multi-secret"), Scopes: []string{"user", "admin"}, ExpiresAt: later},
+		{Id: "revoked", KeyHash: etc.HashToken("revoked-secret"), Scopes: []string{"admin"}, ExpiresAt: later, RevokedAt: &earlier},
+		{Id: "expired", KeyHash: etc.HashToken("expired-secret"), Scopes: []string{"admin"}, ExpiresAt: earlier},
+		{
+			Id:                   "rotated",
+			KeyHash:              etc.HashToken("rotated-new-secret"),
+			PreviousKeyHash:      etc.HashToken("rotated-old-secret"),
+			PreviousKeyExpiresAt: &later,
+			Scopes:               []string{"user"},
+			ExpiresAt:            later,
+		},
+		{
+			Id:                   "rotated-past-grace",
+			KeyHash:              etc.HashToken("past-grace-new-secret"),
+			PreviousKeyHash:      etc.HashToken("past-grace-old-secret"),
+			PreviousKeyExpiresAt: &earlier,
+			Scopes:               []string{"user"},
+			ExpiresAt:            later,
+		},
+	}}
+	router, keys := newTestAuth(t, memoryStorage{}, apiKeys)
+	access, _ := newTestTokens(t, keys, "user-1", "user", 15)
+
+	tests := []struct {
+		name        string
+		path        string
+		secret      string
+		token       string
+		wantCode    int
+		wantRole    string
+		wantTouched bool
+	}{
+		{name: "key in scope", path: "/v1/user/user-1", secret: "reader-secret", wantCode: http.StatusOK, wantRole: "user", wantTouched: true},
+		{name: "key out of scope", path: "/v1/users", secret: "reader-secret", wantCode: http.StatusForbidden, wantTouched: true},
+		{name: "second scope allows", path: "/v1/users", secret: "multi-secret", wantCode: http.StatusOK, wantRole: "admin", wantTouched: true},
+		{name: "first scope allows", path: "/v1/user/user-1", secret: "multi-secret", wantCode: http.StatusOK, wantRole: "user", wantTouched: true},
+		{name: "revoked key", path: "/v1/users", secret: "revoked-secret", wantCode: http.StatusUnauthorized},
+		{name: "expired key", path: "/v1/users", secret: "expired-secret", wantCode: http.StatusUnauthorized},
+		{name: "unknown key", path: "/v1/user/user-1", secret: "unknown-secret", wantCode: http.StatusUnauthorized},
+		{name: "rotated key", path: "/v1/user/user-1", secret: "rotated-new-secret", wantCode: http.StatusOK, wantRole: "user", wantTouched: true},
+		{name: "previous key within grace", path: "/v1/user/user-1", secret: "rotated-old-secret", wantCode: http.StatusOK, wantRole: "user", wantTouched: true},
+		{name: "previous key past grace", path: "/v1/user/user-1", secret: "past-grace-old-secret", wantCode: http.StatusUnauthorized},
+		{name: "key with access token", path: "/v1/user/user-1", secret: "reader-secret", token: access, wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKeys.touched = nil
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(APIKeyHeader, tt.secret)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body.String())
+			}
+			if tt.wantRole != "" && recorder.Body.String() != tt.wantRole {
+				t.Fatalf("role = %q, want %q", recorder.Body.String(), tt.wantRole)
+			}
+			if touched := len(apiKeys.touched) != 0; touched != tt.wantTouched {
+				t.Fatalf("touched = %v, want %v", touched, tt.wantTouched)
+			}
+		})
+	}
+}
@@ -1,91 +1,168 @@
 package casbin
 
 import (
+	"errors"
 	"exam/api-gateway/api/handlers/v1/tokens"
 	"exam/api-gateway/config"
-	"fmt"
-	"github.com/casbin/casbin/v2"
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
-	"github.com/spf13/cast"
+	admin "exam/api-gateway/storage/postgresrepo"
+	"exam/api-gateway/storage/repo"
 	"net/http"
 	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gomodule/redigo/redis"
 )
 
+// RoleUnauthorized is the casbin subject used for requests without a token
+const RoleUnauthorized = "unauthorized"
+
 type CasbinHandler struct {
 	cfg      config.Config
-	enforcer *casbin.Enforcer
+	enforcer *casbin.SyncedEnforcer
+	inMemory repo.InMemoryStorageI
+	keys     *tokens.Keys
+	apiKeys  admin.APIKeyStorageI
 }
 
-func NewAuth(casbin *casbin.Enforcer, cfg config.Config) gin.HandlerFunc {
+// NewAuth returns a middleware that authenticates the bearer token,
+// stores its "role", "sub" and claims in the gin context and enforces
+// the casbin policies for the requested path and method.
+// Requests with an X-API-Key header are authenticated by the key instead
+func NewAuth(casbin *casbin.SyncedEnforcer, cfg config.Config, inMemory repo.InMemoryStorageI, keys *tokens.Keys, apiKeys admin.APIKeyStorageI) gin.HandlerFunc {
 	casbHandler := &CasbinHandler{
 		cfg:      cfg,
 		enforcer: casbin,
+		inMemory: inMemory,
+		keys:     keys,
+		apiKeys:  apiKeys,
 	}
 
 	return func(ctx *gin.Context) {
-		allowed, err := casbHandler.CheckPermission(ctx.Request)
+		if secret := ctx.GetHeader(APIKeyHeader); secret != "" {
+			// a request is made either by a person or by a client, never both
+			if ctx.GetHeader("Authorization") != "" {
+				casbHandler.RequireAPIKey(ctx, "Send either an access token or an API key")
+				return
+			}
+			casbHandler.AuthenticateAPIKey(ctx, secret)
+			return
+		}
+
+		role, claims, err := casbHandler.GetRole(ctx.Request)
 		if err != nil {
-			v, _ := err.(jwt.ValidationError)
-			if v.Errors == jwt.ValidationErrorExpired {
+			if errors.Is(err, jwt.ErrTokenExpired) {
 				casbHandler.RequireRefresh(ctx)
 				return
-			} else {
-				casbHandler.RequirePermission(ctx)
+			}
+			casbHandler.RequireAuthentication(ctx)
+			return
+		}
+
+		ctx.Set("role", role)
+		if claims != nil {
+			revoked, err := casbHandler.IsRevoked(claims)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"Status":  "Internal server error",
+					"Message": err.Error(),
+				})
+				return
+			}
+			if revoked {
+				casbHandler.RequireAuthentication(ctx)
+				return
+			}
+
+			ctx.Set("sub", claims.Subject)
+			ctx.Set("claims", claims)
+		}
+
+		allowed, err := casbHandler.CheckPermission(role, ctx.Request)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"Status":  "Internal server error",
+				"Message": err.Error(),
+			})
+			return
+		}
+
+		if !allowed {
+			if role == RoleUnauthorized {
+				casbHandler.RequireAuthentication(ctx)
 				return
 			}
-		} else if !allowed {
 			casbHandler.RequirePermission(ctx)
 			return
 		}
+
+		ctx.Next()
 	}
 }
 
-func (a *CasbinHandler) GetRole(ctx *http.Request) (string, int) {
-	token := ctx.Header.Get("authorization")
+// GetRole returns the role and claims of the bearer token,
+// requests without a token get the "unauthorized" role and no claims,
+// refresh tokens are not accepted
+func (a *CasbinHandler) GetRole(r *http.Request) (string, *tokens.Claims, error) {
+	token := r.Header.Get("Authorization")
 	if token == "" {
-		return "unauthorized", http.StatusOK
+		return RoleUnauthorized, nil, nil
 	}
 
-	var cutToken string
+	cutToken := strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
 
-	if strings.Contains(token, "Bearer") {
-		cutToken = strings.TrimPrefix(token, "Bearer ")
-	} else {
-		cutToken = token
+	claims, err := tokens.ExtractClaim(cutToken, a.keys, tokens.TypeAccess)
+	if err != nil {
+		return "", nil, err
 	}
 
-	claims, err := tokens.ExtractClaim(cutToken, []byte(a.cfg.SignInKey))
-	if err != nil {
-		return "unauthorized, token is invalid", http.StatusBadRequest
+	if claims.Role == "" {
+		return "", nil, errors.New("token has no role")
 	}
 
-	return cast.ToString(claims["role"]), http.StatusOK
+	return claims.Role, claims, nil
 }
 
-func (a *CasbinHandler) CheckPermission(req *http.Request) (bool, error) {
-	role, status := a.GetRole(req)
-	if status != http.StatusOK {
-		return false, fmt.Errorf(role)
+// IsRevoked reports whether the token was logged out or issued
+// before all sessions of its subject were revoked
+func (a *CasbinHandler) IsRevoked(claims *tokens.Claims) (bool, error) {
+	if claims.ID != "" {
+		_, err := redis.String(a.inMemory.Get(tokens.DenylistKey(claims.ID)))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, redis.ErrNil) {
+			return false, err
+		}
 	}
 
-	object := req.URL.Path
-	action := req.Method
-
-	response, err := a.enforcer.Enforce(role, object, action)
+	notBefore, err := redis.Int64(a.inMemory.Get(tokens.NotBeforeKey(claims.Subject)))
+	if errors.Is(err, redis.ErrNil) {
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
 
-	if !response {
-		return false, nil
-	}
+	// iat has whole seconds, a token issued in the second of the revocation
+	// is kept so a login right after "revoke all sessions" is not refused
+	return claims.IssuedAt == nil || claims.IssuedAt.Unix() < notBefore, nil
+}
 
-	return true, nil
+func (a *CasbinHandler) CheckPermission(role string, r *http.Request) (bool, error) {
+	return a.enforcer.Enforce(role, r.URL.Path, r.Method)
+}
+
+func (a *CasbinHandler) RequireAuthentication(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"Status":  "unauthorized",
+		"Message": "Valid access token is required",
+	})
 }
 
 func (a *CasbinHandler) RequirePermission(c *gin.Context) {
-	c.AbortWithStatusJSON(http.StatusMethodNotAllowed, gin.H{
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 		"Status":  "Permission denied",
 		"Message": "This method is not allowed to you",
 	})
@@ -0,0 +1,219 @@
+package casbin
+
+import (
+	"exam/api-gateway/api/handlers/v1/tokens"
+	"exam/api-gateway/config"
+	"exam/api-gateway/pkg/logger"
+	admin "exam/api-gateway/storage/postgresrepo"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gomodule/redigo/redis"
+)
+
+// memoryStorage answers like redis, missing keys are redis.ErrNil
+type memoryStorage map[string]string
+
+func (m memoryStorage) Set(key, value string) error {
+	m[key] = value
+	return nil
+}
+
+func (m memoryStorage) SetWithTTL(key, value string, seconds int) error {
+	m[key] = value
+	return nil
+}
+
+func (m memoryStorage) Get(key string) (interface{}, error) {
+	value, ok := m[key]
+	if !ok {
+		return nil, redis.ErrNil
+	}
+	return []byte(value), nil
+}
+
+func (m memoryStorage) GetDel(key string) (interface{}, error) {
+	value, err := m.Get(key)
+	delete(m, key)
+	return value, err
+}
+
+func (m memoryStorage) Incr(key string, seconds int) (int64, error) {
+	count, _ := strconv.ParseInt(m[key], 10, 64)
+	m[key] = strconv.FormatInt(count+1, 10)
+	return count + 1, nil
+}
+
+func (m memoryStorage) Del(keys ...string) error {
+	for _, key := range keys {
+		delete(m, key)
+	}
+	return nil
+}
+
+func TestIsRevoked(t *testing.T) {
+	revokedAt := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name      string
+		id        string
+		issuedAt  *jwt.NumericDate
+		denied    bool
+		notBefore bool
+		revoked   bool
+	}{
+		{name: "no revocation", id: "a", issuedAt: jwt.NewNumericDate(revokedAt), revoked: false},
+		{name: "logged out", id: "a", issuedAt: jwt.NewNumericDate(revokedAt), denied: true, revoked: true},
+		{name: "issued before revoke all", id: "a", issuedAt: jwt.NewNumericDate(revokedAt.Add(-time.Second)), notBefore: true, revoked: true},
+		{name: "issued in the second of revoke all", id: "a", issuedAt: jwt.NewNumericDate(revokedAt), notBefore: true, revoked: false},
+		{name: "issued after revoke all", id: "a", issuedAt: jwt.NewNumericDate(revokedAt.Add(time.Second)), notBefore: true, revoked: false},
+		{name: "without iat", id: "a", notBefore: true, revoked: true},
+		{name: "without jti", issuedAt: jwt.NewNumericDate(revokedAt), denied: true, revoked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inMemory := memoryStorage{}
+			if tt.denied {
+				inMemory[tokens.DenylistKey("a")] = "1"
+			}
+			if tt.notBefore {
+				inMemory[tokens.NotBeforeKey("user-1")] = strconv.FormatInt(revokedAt.Unix(), 10)
+			}
+
+			handler := &CasbinHandler{inMemory: inMemory}
+			revoked, err := handler.IsRevoked(&tokens.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:       tt.id,
+					Subject:  "user-1",
+					IssuedAt: tt.issuedAt,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != tt.revoked {
+				t.Fatalf("IsRevoked() = %v, want %v", revoked, tt.revoked)
+			}
+		})
+	}
+}
+
+const testAuthPolicy = `p, unauthorized, /v1/user/login, POST
+p, user, /v1/user/{id}, GET
+p, admin, /v1/users, GET
+g, user, unauthorized
+g, admin, user
+`
+
+// newTestAuth returns a router with the auth middleware in front of
+// routes answering 200, and the keys its access tokens are signed with
+func newTestAuth(t *testing.T, inMemory memoryStorage, apiKeys admin.APIKeyStorageI) (*gin.Engine, *tokens.Keys) {
+	t.Helper()
+
+	policyFile := filepath.Join(t.TempDir(), "policy.csv")
+	if err := os.WriteFile(policyFile, []byte(testAuthPolicy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	enforcer, err := casbin.NewSyncedEnforcer("../../config/auth.conf", fileadapter.NewAdapter(policyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := tokens.LoadKeys(config.Config{
+		SignInKey:   "test-sign-in-key",
+		JWTIssuer:   "exam-api-gateway",
+		JWTAudience: "exam",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewAuth(enforcer, config.Config{}, inMemory, keys, apiKeys))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("role")) }
+	router.POST("/v1/user/login", ok)
+	router.GET("/v1/user/:id", ok)
+	router.GET("/v1/users", ok)
+
+	return router, keys
+}
+
+// newTestTokens signs an access and a refresh token of sub as role,
+// a negative timeout makes the access token expired
+func newTestTokens(t *testing.T, keys *tokens.Keys, sub, role string, timeout int) (string, string) {
+	t.Helper()
+
+	access, refresh, err := tokens.JWTHandler{
+		Sub:            sub,
+		Role:           role,
+		Keys:           keys,
+		Log:            logger.New("error", "test"),
+		Timeout:        timeout,
+		RefreshTimeout: 1,
+	}.GenerateAuthJWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return access, refresh
+}
+
+func TestNewAuth(t *testing.T) {
+	inMemory := memoryStorage{}
+	router, keys := newTestAuth(t, inMemory, nil)
+
+	userAccess, userRefresh := newTestTokens(t, keys, "user-1", "user", 15)
+	adminAccess, _ := newTestTokens(t, keys, "admin-1", "admin", 15)
+	expiredAccess, _ := newTestTokens(t, keys, "user-1", "user", -1)
+	revokedAccess, _ := newTestTokens(t, keys, "user-2", "user", 15)
+	inMemory[tokens.NotBeforeKey("user-2")] = strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		wantCode int
+		wantRole string
+	}{
+		{name: "public route without token", method: "POST", path: "/v1/user/login", wantCode: http.StatusOK, wantRole: "unauthorized"},
+		{name: "protected route without token", method: "GET", path: "/v1/user/user-1", wantCode: http.StatusUnauthorized},
+		{name: "user on user route", method: "GET", path: "/v1/user/user-1", token: userAccess, wantCode: http.StatusOK, wantRole: "user"},
+		{name: "user inherits public route", method: "POST", path: "/v1/user/login", token: userAccess, wantCode: http.StatusOK, wantRole: "user"},
+		{name: "user on admin route", method: "GET", path: "/v1/users", token: userAccess, wantCode: http.StatusForbidden},
+		{name: "admin on admin route", method: "GET", path: "/v1/users", token: adminAccess, wantCode: http.StatusOK, wantRole: "admin"},
+		{name: "admin inherits user route", method: "GET", path: "/v1/user/user-1", token: adminAccess, wantCode: http.StatusOK, wantRole: "admin"},
+		{name: "expired token", method: "GET", path: "/v1/user/user-1", token: expiredAccess, wantCode: http.StatusUnauthorized},
+		{name: "refresh token", method: "GET", path: "/v1/user/user-1", token: userRefresh, wantCode: http.StatusUnauthorized},
+		{name: "revoked token", method: "GET", path: "/v1/user/user-2", token: revokedAccess, wantCode: http.StatusUnauthorized},
+		{name: "garbage token", method: "GET", path: "/v1/user/user-1", token: "not-a-token", wantCode: http.StatusUnauthorized},
+		{name: "garbage token on public route", method: "POST", path: "/v1/user/login", token: "not-a-token", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body.String())
+			}
+			if tt.wantRole != "" && recorder.Body.String() != tt.wantRole {
+				t.Fatalf("role = %q, want %q", recorder.Body.String(), tt.wantRole)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package casbin
+
+import (
+	"exam/api-gateway/config"
+	"fmt"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+)
+
+// NewEnforcer reads the model from cfg.AuthConfigPath and loads the
+// policy from the casbin_rule table
+func NewEnforcer(cfg config.Config) (*casbin.SyncedEnforcer, error) {
+	psqlString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		cfg.PostgresHost,
+		cfg.PostgresPort,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDatabase)
+
+	adapter, err := gormadapter.NewAdapter("postgres", psqlString, true)
+	if err != nil {
+		return nil, err
+	}
+
+	enforcer, err := casbin.NewSyncedEnforcer(cfg.AuthConfigPath, adapter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := enforcer.LoadPolicy(); err != nil {
+		return nil, err
+	}
+
+	enforcer.GetRoleManager().AddMatchingFunc("keyMatch", util.KeyMatch)
+	enforcer.GetRoleManager().AddMatchingFunc("keyMatch3", util.KeyMatch3)
+
+	return enforcer, nil
+}
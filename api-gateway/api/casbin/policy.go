@@ -0,0 +1,266 @@
+package casbin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	PolicyFormatCSV  = "csv"
+	PolicyFormatJSON = "json"
+
+	// SubjectPrefix marks grouping rules that assign a role to a user id
+	// or an admin username, they are managed per subject and never exported
+	SubjectPrefix = "sub:"
+
+	// only the routes behind the casbin middleware are checked
+	protectedPrefix = "/v1/"
+
+	// the policy "p, <role>, mfa, required" makes 2FA mandatory for the role
+	// and every role inheriting it, it is managed with the rbac endpoints
+	MFAPolicyObject = "mfa"
+	MFAPolicyAction = "required"
+)
+
+// ExportPolicy returns the role policies and grouping rules loaded into the enforcer
+func ExportPolicy(e *casbin.SyncedEnforcer) []models.CasbinRule {
+	rules := []models.CasbinRule{}
+	for _, p := range e.GetPolicy() {
+		rules = append(rules, NewRule("p", p))
+	}
+	for _, g := range e.GetGroupingPolicy() {
+		if strings.HasPrefix(g[0], SubjectPrefix) {
+			continue
+		}
+		rules = append(rules, NewRule("g", g))
+	}
+
+	return rules
+}
+
+// NewRule builds a rule from the values of a casbin policy line
+func NewRule(ptype string, values []string) models.CasbinRule {
+	rule := models.CasbinRule{PType: ptype}
+	fields := []*string{&rule.V0, &rule.V1, &rule.V2}
+	for i := 0; i < len(values) && i < len(fields); i++ {
+		*fields[i] = values[i]
+	}
+
+	return rule
+}
+
+// WritePolicy writes the rules in the casbin csv format or as a json array
+func WritePolicy(w io.Writer, format string, rules []models.CasbinRule) error {
+	switch format {
+	case PolicyFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rules)
+	case PolicyFormatCSV:
+		for _, rule := range rules {
+			line := []string{rule.PType, rule.V0, rule.V1}
+			if rule.V2 != "" {
+				line = append(line, rule.V2)
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(line, ", ")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown policy format %q", format)
+}
+
+// ReadPolicy parses a file written by WritePolicy, lines of the csv
+// format starting with # are comments
+func ReadPolicy(r io.Reader, format string) ([]models.CasbinRule, error) {
+	switch format {
+	case PolicyFormatJSON:
+		rules := []models.CasbinRule{}
+		if err := json.NewDecoder(r).Decode(&rules); err != nil {
+			return nil, err
+		}
+		return rules, nil
+	case PolicyFormatCSV:
+		reader := csv.NewReader(r)
+		reader.Comment = '#'
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		rules := []models.CasbinRule{}
+		for {
+			line, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return rules, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range line {
+				line[i] = strings.TrimSpace(line[i])
+			}
+			if len(line) < 3 || len(line) > 4 {
+				row, _ := reader.FieldPos(0)
+				return nil, fmt.Errorf("line %d: a rule has 3 or 4 fields, got %d", row, len(line))
+			}
+			rules = append(rules, NewRule(line[0], line[1:]))
+		}
+	}
+
+	return nil, fmt.Errorf("unknown policy format %q", format)
+}
+
+// ValidatePolicy checks every rule against the defined roles and the
+// registered routes, the policy set is valid when nothing is invalid,
+// unmatched or dead. Routes only the superadmin can reach are reported
+// too, they are expected for the superadmin endpoints only
+func ValidatePolicy(rules []models.CasbinRule, roles []string, routes gin.RoutesInfo) *models.PolicyReport {
+	report := models.PolicyReport{
+		Rules:          len(rules),
+		Invalid:        []*models.PolicyProblem{},
+		Unmatched:      []*models.PolicyProblem{},
+		Dead:           []*models.PolicyProblem{},
+		SuperAdminOnly: []string{},
+	}
+	problem := func(list *[]*models.PolicyProblem, rule models.CasbinRule, reason string) {
+		*list = append(*list, &models.PolicyProblem{Rule: rule, Reason: reason})
+	}
+
+	defined := map[string]bool{}
+	for _, role := range roles {
+		defined[role] = true
+	}
+
+	protected := gin.RoutesInfo{}
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, protectedPrefix) {
+			protected = append(protected, route)
+		}
+	}
+	covered := make([]bool, len(protected))
+
+	seen := map[models.CasbinRule]bool{}
+	for _, rule := range rules {
+		if seen[rule] {
+			problem(&report.Invalid, rule, "duplicate rule")
+			continue
+		}
+		seen[rule] = true
+
+		switch rule.PType {
+		case "p":
+			if rule.V0 == "" || rule.V1 == "" || rule.V2 == "" {
+				problem(&report.Invalid, rule, "a policy needs a role, a path and a method")
+				continue
+			}
+			// the mfa requirement is not a route, it only needs its role
+			if rule.V1 == MFAPolicyObject {
+				if rule.V2 != MFAPolicyAction {
+					problem(&report.Invalid, rule, "the action of an mfa policy must be "+MFAPolicyAction)
+					continue
+				}
+				if !defined[rule.V0] {
+					problem(&report.Dead, rule, "role "+rule.V0+" is not defined")
+				}
+				continue
+			}
+			if !strings.HasPrefix(rule.V1, "/") {
+				problem(&report.Invalid, rule, "the path must start with /")
+				continue
+			}
+			if strings.Contains(rule.V1, "/:") {
+				problem(&report.Invalid, rule, "use {param} for path parameters, :param is matched literally")
+				continue
+			}
+			if strings.Count(rule.V1, "{") != strings.Count(rule.V1, "}") {
+				problem(&report.Invalid, rule, "unbalanced braces in the path")
+				continue
+			}
+			if _, err := regexp.Compile(rule.V2); err != nil {
+				problem(&report.Invalid, rule, "the method is not a valid pattern")
+				continue
+			}
+			if !defined[rule.V0] {
+				problem(&report.Dead, rule, "role "+rule.V0+" is not defined")
+				continue
+			}
+
+			matched := false
+			for i, route := range protected {
+				if policyMatches(rule, route) {
+					matched = true
+					if rule.V0 != "superadmin" {
+						covered[i] = true
+					}
+				}
+			}
+			if !matched {
+				problem(&report.Unmatched, rule, "no registered route is allowed by the policy")
+			}
+		case "g":
+			if rule.V0 == "" || rule.V1 == "" {
+				problem(&report.Invalid, rule, "a grouping rule needs a role and a parent")
+				continue
+			}
+			if rule.V2 != "" {
+				problem(&report.Invalid, rule, "a grouping rule takes only a role and a parent")
+				continue
+			}
+			if strings.HasPrefix(rule.V0, SubjectPrefix) {
+				problem(&report.Invalid, rule, "role assignments of subjects are managed with /v1/rbac/subjects")
+				continue
+			}
+			for _, role := range []string{rule.V0, rule.V1} {
+				if !defined[role] {
+					problem(&report.Dead, rule, "role "+role+" is not defined")
+					break
+				}
+			}
+		default:
+			problem(&report.Invalid, rule, "unknown rule type "+rule.PType)
+		}
+	}
+
+	for i, route := range protected {
+		if !covered[i] {
+			report.SuperAdminOnly = append(report.SuperAdminOnly, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(report.SuperAdminOnly)
+
+	report.Valid = len(report.Invalid) == 0 && len(report.Unmatched) == 0 && len(report.Dead) == 0
+
+	return &report
+}
+
+// policyMatches tells whether a request to the route passes the matchers
+// of auth.conf for the policy, a sample path stands for the route
+// with every parameter filled in
+func policyMatches(rule models.CasbinRule, route gin.RouteInfo) bool {
+	segments := strings.Split(route.Path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "sample"
+		}
+	}
+	path := strings.Join(segments, "/")
+
+	if !util.KeyMatch(path, rule.V1) && !util.KeyMatch3(path, rule.V1) {
+		return false
+	}
+
+	return route.Method == rule.V2 || util.RegexMatch(route.Method, rule.V2)
+}
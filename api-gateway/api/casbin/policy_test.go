@@ -0,0 +1,152 @@
+package casbin
+
+import (
+	"bytes"
+	"exam/api-gateway/api/handlers/models"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	"github.com/gin-gonic/gin"
+)
+
+var testRoutes = gin.RoutesInfo{
+	{Method: "GET", Path: "/v1/user/:id"},
+	{Method: "POST", Path: "/v1/user/login"},
+	{Method: "DELETE", Path: "/v1/user/delete/:id"},
+	{Method: "GET", Path: "/v1/rbac/roles"},
+	{Method: "GET", Path: "/.well-known/jwks.json"},
+}
+
+var testRoles = []string{"unauthorized", "user", "admin", "superadmin"}
+
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      models.CasbinRule
+		invalid   bool
+		unmatched bool
+		dead      bool
+	}{
+		{name: "route", rule: models.CasbinRule{PType: "p", V0: "user", V1: "/v1/user/{id}", V2: "GET"}},
+		{name: "key match", rule: models.CasbinRule{PType: "p", V0: "admin", V1: "/v1/user/*", V2: "DELETE"}},
+		{name: "method pattern", rule: models.CasbinRule{PType: "p", V0: "admin", V1: "/v1/user/*", V2: "(GET)|(DELETE)"}},
+		{name: "mfa requirement", rule: models.CasbinRule{PType: "p", V0: "admin", V1: MFAPolicyObject, V2: MFAPolicyAction}},
+		{name: "mfa of undefined role", rule: models.CasbinRule{PType: "p", V0: "auditor", V1: MFAPolicyObject, V2: MFAPolicyAction}, dead: true},
+		{name: "mfa with another action", rule: models.CasbinRule{PType: "p", V0: "admin", V1: MFAPolicyObject, V2: "GET"}, invalid: true},
+		{name: "relative path", rule: models.CasbinRule{PType: "p", V0: "user", V1: "v1/user/{id}", V2: "GET"}, invalid: true},
+		{name: "gin parameter", rule: models.CasbinRule{PType: "p", V0: "user", V1: "/v1/user/:id", V2: "GET"}, invalid: true},
+		{name: "unbalanced braces", rule: models.CasbinRule{PType: "p", V0: "user", V1: "/v1/user/{id", V2: "GET"}, invalid: true},
+		{name: "bad method pattern", rule: models.CasbinRule{PType: "p", V0: "user", V1: "/v1/user/{id}", V2: "(GET"}, invalid: true},
+		{name: "missing method", rule: models.CasbinRule{PType: "p", V0: "user", V1: "/v1/user/{id}"}, invalid: true},
+		{name: "no route", rule: models.CasbinRule{PType: "p", V0: "user", V1: "/v1/missing", V2: "GET"}, unmatched: true},
+		{name: "wrong method", rule: models.CasbinRule{PType: "p", V0: "user", V1: "/v1/user/login", V2: "GET"}, unmatched: true},
+		{name: "undefined role", rule: models.CasbinRule{PType: "p", V0: "auditor", V1: "/v1/user/{id}", V2: "GET"}, dead: true},
+		{name: "inheritance", rule: models.CasbinRule{PType: "g", V0: "admin", V1: "user"}},
+		{name: "inheritance of undefined role", rule: models.CasbinRule{PType: "g", V0: "auditor", V1: "user"}, dead: true},
+		{name: "inheritance with action", rule: models.CasbinRule{PType: "g", V0: "admin", V1: "user", V2: "GET"}, invalid: true},
+		{name: "subject assignment", rule: models.CasbinRule{PType: "g", V0: SubjectPrefix + "user-1", V1: "admin"}, invalid: true},
+		{name: "unknown type", rule: models.CasbinRule{PType: "x", V0: "admin", V1: "user"}, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ValidatePolicy([]models.CasbinRule{tt.rule}, testRoles, testRoutes)
+
+			if got := len(report.Invalid) > 0; got != tt.invalid {
+				t.Errorf("invalid = %v, want %v: %+v", got, tt.invalid, report.Invalid)
+			}
+			if got := len(report.Unmatched) > 0; got != tt.unmatched {
+				t.Errorf("unmatched = %v, want %v: %+v", got, tt.unmatched, report.Unmatched)
+			}
+			if got := len(report.Dead) > 0; got != tt.dead {
+				t.Errorf("dead = %v, want %v: %+v", got, tt.dead, report.Dead)
+			}
+			if report.Valid != (!tt.invalid && !tt.unmatched && !tt.dead) {
+				t.Errorf("valid = %v", report.Valid)
+			}
+		})
+	}
+}
+
+func TestValidatePolicyDuplicates(t *testing.T) {
+	rule := models.CasbinRule{PType: "p", V0: "user", V1: "/v1/user/{id}", V2: "GET"}
+	report := ValidatePolicy([]models.CasbinRule{rule, rule}, testRoles, testRoutes)
+
+	if len(report.Invalid) != 1 || report.Invalid[0].Reason != "duplicate rule" {
+		t.Fatalf("duplicate is not reported: %+v", report.Invalid)
+	}
+}
+
+func TestValidatePolicySuperAdminOnly(t *testing.T) {
+	report := ValidatePolicy([]models.CasbinRule{
+		{PType: "p", V0: "user", V1: "/v1/user/{id}", V2: "GET"},
+		{PType: "p", V0: "unauthorized", V1: "/v1/user/login", V2: "POST"},
+		{PType: "p", V0: "superadmin", V1: "/v1/rbac/roles", V2: "GET"},
+	}, testRoles, testRoutes)
+
+	want := []string{"DELETE /v1/user/delete/:id", "GET /v1/rbac/roles"}
+	if len(report.SuperAdminOnly) != len(want) {
+		t.Fatalf("SuperAdminOnly = %v, want %v", report.SuperAdminOnly, want)
+	}
+	for i := range want {
+		if report.SuperAdminOnly[i] != want[i] {
+			t.Fatalf("SuperAdminOnly = %v, want %v", report.SuperAdminOnly, want)
+		}
+	}
+}
+
+// a policy exported from a live enforcer, mfa requirement included,
+// has to pass the validation and read back the same in both formats
+func TestExportImportPolicy(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.csv")
+	policy := `p, user, /v1/user/{id}, GET
+p, unauthorized, /v1/user/login, POST
+p, admin, /v1/user/delete/{id}, DELETE
+p, admin, mfa, required
+g, user, unauthorized
+g, admin, user
+g, sub:user-1, admin
+`
+	if err := os.WriteFile(policyFile, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	enforcer, err := casbin.NewSyncedEnforcer("../../config/auth.conf", fileadapter.NewAdapter(policyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exported := ExportPolicy(enforcer)
+	if len(exported) != 6 {
+		t.Fatalf("exported %d rules, want 6 without the subject assignment: %+v", len(exported), exported)
+	}
+
+	for _, format := range []string{PolicyFormatCSV, PolicyFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var file bytes.Buffer
+			if err := WritePolicy(&file, format, exported); err != nil {
+				t.Fatal(err)
+			}
+
+			imported, err := ReadPolicy(&file, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(imported) != len(exported) {
+				t.Fatalf("read %d rules, want %d", len(imported), len(exported))
+			}
+			for i := range exported {
+				if imported[i] != exported[i] {
+					t.Fatalf("rule %d read as %+v, want %+v", i, imported[i], exported[i])
+				}
+			}
+
+			if report := ValidatePolicy(imported, testRoles, testRoutes); !report.Valid {
+				t.Fatalf("exported policy is not valid: %+v %+v %+v", report.Invalid, report.Unmatched, report.Dead)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package casbin
+
+import (
+	"encoding/json"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/config"
+	"exam/api-gateway/pkg/logger"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// postgres drops notifications with a longer payload
+	maxNotifyPayload = 8000
+
+	listenerPingInterval = time.Minute
+)
+
+// Watcher keeps the enforcers of every gateway in sync through postgres
+// LISTEN/NOTIFY, each change of the policy is announced as a PolicyUpdate
+// and applied incrementally by the other gateways
+type Watcher struct {
+	listener *pq.Listener
+	origin   string
+	log      logger.Logger
+	// notify announces the payload to the gateways, itself included
+	notify func(payload string) error
+
+	mu       sync.Mutex
+	callback func(string)
+}
+
+func NewWatcher(cfg config.Config, db *sqlx.DB, log logger.Logger) (*Watcher, error) {
+	psqlString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		cfg.PostgresHost,
+		cfg.PostgresPort,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDatabase)
+
+	w := &Watcher{
+		origin: uuid.NewString(),
+		log:    log,
+		notify: func(payload string) error {
+			_, err := db.Exec(`SELECT pg_notify($1, $2)`, models.PolicyChannel, payload)
+			return err
+		},
+	}
+	w.listener = pq.NewListener(psqlString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Error("casbin policy listener", logger.Error(err))
+		}
+	})
+	if err := w.listener.Listen(models.PolicyChannel); err != nil {
+		w.listener.Close()
+		return nil, err
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+// WatchPolicy makes the enforcer announce its changes through the
+// watcher and apply the changes announced by the other gateways
+func WatchPolicy(e *casbin.SyncedEnforcer, w *Watcher) error {
+	if err := e.SetWatcher(w); err != nil {
+		return err
+	}
+
+	return w.SetUpdateCallback(func(message string) {
+		if err := applyPolicyUpdate(e, message); err != nil {
+			w.log.Error("cannot apply casbin policy update, reloading it", logger.Error(err))
+			if err := e.LoadPolicy(); err != nil {
+				w.log.Error("cannot reload casbin policy", logger.Error(err))
+			}
+		}
+	})
+}
+
+func applyPolicyUpdate(e *casbin.SyncedEnforcer, message string) error {
+	var update models.PolicyUpdate
+	if err := json.Unmarshal([]byte(message), &update); err != nil {
+		return err
+	}
+
+	var err error
+	switch update.Op {
+	case models.PolicyUpdateAdd:
+		_, err = e.SelfAddPolicies(update.Sec, update.PType, update.Rules)
+	case models.PolicyUpdateRemove:
+		_, err = e.SelfRemovePolicies(update.Sec, update.PType, update.Rules)
+	case models.PolicyUpdateRemoveFiltered:
+		_, err = e.SelfRemoveFilteredPolicy(update.Sec, update.PType, update.FieldIndex, update.FieldValues...)
+	default:
+		err = e.LoadPolicy()
+	}
+
+	return err
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case notification, ok := <-w.listener.Notify:
+			if !ok {
+				return
+			}
+			w.receive(notification)
+		case <-time.After(listenerPingInterval):
+			go w.listener.Ping()
+		}
+	}
+}
+
+// receive passes the update of another gateway to the callback
+func (w *Watcher) receive(notification *pq.Notification) {
+	// nil comes after a reconnect, the updates sent meanwhile are lost
+	message := `{"op":"` + models.PolicyUpdateReload + `"}`
+	if notification != nil {
+		var update models.PolicyUpdate
+		if err := json.Unmarshal([]byte(notification.Extra), &update); err == nil && update.Origin == w.origin {
+			return
+		}
+		message = notification.Extra
+	}
+
+	w.mu.Lock()
+	callback := w.callback
+	w.mu.Unlock()
+	if callback != nil {
+		callback(message)
+	}
+}
+
+func (w *Watcher) publish(update models.PolicyUpdate) error {
+	update.Origin = w.origin
+
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		payload, _ = json.Marshal(models.PolicyUpdate{
+			Origin: w.origin,
+			Op:     models.PolicyUpdateReload,
+		})
+	}
+
+	return w.notify(string(payload))
+}
+
+func (w *Watcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+
+	return nil
+}
+
+func (w *Watcher) Update() error {
+	return w.publish(models.PolicyUpdate{Op: models.PolicyUpdateReload})
+}
+
+func (w *Watcher) Close() {
+	w.listener.Close()
+}
+
+func (w *Watcher) UpdateForAddPolicy(sec, ptype string, params ...string) error {
+	return w.UpdateForAddPolicies(sec, ptype, params)
+}
+
+func (w *Watcher) UpdateForRemovePolicy(sec, ptype string, params ...string) error {
+	return w.UpdateForRemovePolicies(sec, ptype, params)
+}
+
+func (w *Watcher) UpdateForRemoveFilteredPolicy(sec, ptype string, fieldIndex int, fieldValues ...string) error {
+	return w.publish(models.PolicyUpdate{
+		Op:          models.PolicyUpdateRemoveFiltered,
+		Sec:         sec,
+		PType:       ptype,
+		FieldIndex:  fieldIndex,
+		FieldValues: fieldValues,
+	})
+}
+
+func (w *Watcher) UpdateForSavePolicy(model model.Model) error {
+	return w.Update()
+}
+
+func (w *Watcher) UpdateForAddPolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(models.PolicyUpdate{
+		Op:    models.PolicyUpdateAdd,
+		Sec:   sec,
+		PType: ptype,
+		Rules: rules,
+	})
+}
+
+func (w *Watcher) UpdateForRemovePolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(models.PolicyUpdate{
+		Op:    models.PolicyUpdateRemove,
+		Sec:   sec,
+		PType: ptype,
+		Rules: rules,
+	})
+}
@@ -0,0 +1,203 @@
+package casbin
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/logger"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	"github.com/lib/pq"
+)
+
+const testWatcherPolicy = `p, user, /v1/user/{id}, GET
+g, admin, user
+g, sub:user-1, admin
+`
+
+// notifyChannel delivers every payload to each watcher on it
+// like the policy channel of postgres
+type notifyChannel struct {
+	mu       sync.Mutex
+	watchers []*Watcher
+	payloads []string
+}
+
+func (n *notifyChannel) notify(payload string) error {
+	n.mu.Lock()
+	n.payloads = append(n.payloads, payload)
+	watchers := n.watchers
+	n.mu.Unlock()
+
+	for _, w := range watchers {
+		w.receive(&pq.Notification{Channel: models.PolicyChannel, Extra: payload})
+	}
+	return nil
+}
+
+// newWatchedEnforcers returns gateways whose enforcers load the same policy
+// file and are kept in sync through the channel. The file adapter saves
+// nothing, so a gateway sees a change only when the watcher applies it
+func newWatchedEnforcers(t *testing.T, gateways int) ([]*casbin.SyncedEnforcer, *notifyChannel, string) {
+	t.Helper()
+
+	policyFile := filepath.Join(t.TempDir(), "policy.csv")
+	if err := os.WriteFile(policyFile, []byte(testWatcherPolicy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	channel := &notifyChannel{}
+	var enforcers []*casbin.SyncedEnforcer
+	for i := 0; i < gateways; i++ {
+		enforcer, err := casbin.NewSyncedEnforcer("../../config/auth.conf", fileadapter.NewAdapter(policyFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &Watcher{origin: string(rune('a' + i)), log: logger.New("error", "test"), notify: channel.notify}
+		if err := WatchPolicy(enforcer, w); err != nil {
+			t.Fatal(err)
+		}
+		channel.watchers = append(channel.watchers, w)
+		enforcers = append(enforcers, enforcer)
+	}
+	return enforcers, channel, policyFile
+}
+
+func TestWatcherPropagatesUpdates(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(e *casbin.SyncedEnforcer) (bool, error)
+		// request is enforced on the other gateway
+		request []interface{}
+		want    bool
+		wantOp  string
+	}{
+		{
+			name:    "added policy",
+			change:  func(e *casbin.SyncedEnforcer) (bool, error) { return e.AddPolicy("user", "/v1/products", "GET") },
+			request: []interface{}{"user", "/v1/products", "GET"},
+			want:    true,
+			wantOp:  models.PolicyUpdateAdd,
+		},
+		{
+			name:    "removed policy",
+			change:  func(e *casbin.SyncedEnforcer) (bool, error) { return e.RemovePolicy("user", "/v1/user/{id}", "GET") },
+			request: []interface{}{"admin", "/v1/user/42", "GET"},
+			want:    false,
+			wantOp:  models.PolicyUpdateRemove,
+		},
+		{
+			name:    "added inheritance",
+			change:  func(e *casbin.SyncedEnforcer) (bool, error) { return e.AddGroupingPolicy("auditor", "admin") },
+			request: []interface{}{"auditor", "/v1/user/42", "GET"},
+			want:    true,
+			wantOp:  models.PolicyUpdateAdd,
+		},
+		{
+			name:    "removed inheritance",
+			change:  func(e *casbin.SyncedEnforcer) (bool, error) { return e.RemoveGroupingPolicy("admin", "user") },
+			request: []interface{}{"admin", "/v1/user/42", "GET"},
+			want:    false,
+			wantOp:  models.PolicyUpdateRemove,
+		},
+		{
+			name: "unassigned subject",
+			change: func(e *casbin.SyncedEnforcer) (bool, error) {
+				return e.RemoveFilteredGroupingPolicy(0, SubjectPrefix+"user-1")
+			},
+			request: []interface{}{SubjectPrefix + "user-1", "/v1/user/42", "GET"},
+			want:    false,
+			wantOp:  models.PolicyUpdateRemoveFiltered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enforcers, channel, _ := newWatchedEnforcers(t, 2)
+
+			if changed, err := tt.change(enforcers[0]); err != nil || !changed {
+				t.Fatalf("change = %v, %v", changed, err)
+			}
+
+			if len(channel.payloads) != 1 || !strings.Contains(channel.payloads[0], `"op":"`+tt.wantOp+`"`) {
+				t.Fatalf("announced %v, want one %s", channel.payloads, tt.wantOp)
+			}
+			for i, enforcer := range enforcers {
+				if allowed, err := enforcer.Enforce(tt.request...); err != nil || allowed != tt.want {
+					t.Fatalf("gateway %d allowed %v, want %v: %v", i, allowed, tt.want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestWatcherReloads(t *testing.T) {
+	tests := []struct {
+		name string
+		// announce makes the second gateway reload the policy
+		announce func(watchers []*Watcher) error
+	}{
+		{name: "after a reconnect", announce: func(watchers []*Watcher) error { watchers[1].receive(nil); return nil }},
+		{
+			name: "on a broken update",
+			announce: func(watchers []*Watcher) error {
+				watchers[1].receive(&pq.Notification{Channel: models.PolicyChannel, Extra: `{"op":"add","ptype":"p","rules":"user"}`})
+				return nil
+			},
+		},
+		{name: "when asked to", announce: func(watchers []*Watcher) error { return watchers[0].Update() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enforcers, channel, policyFile := newWatchedEnforcers(t, 2)
+			if err := os.WriteFile(policyFile, []byte(testWatcherPolicy+"p, user, /v1/products, GET\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.announce(channel.watchers); err != nil {
+				t.Fatal(err)
+			}
+
+			if allowed, _ := enforcers[1].Enforce("user", "/v1/products", "GET"); !allowed {
+				t.Fatal("the other gateway did not reload the policy")
+			}
+		})
+	}
+}
+
+func TestWatcherSkipsOwnUpdates(t *testing.T) {
+	enforcers, channel, _ := newWatchedEnforcers(t, 1)
+	var calls int
+	channel.watchers[0].SetUpdateCallback(func(string) { calls++ })
+
+	if _, err := enforcers[0].AddPolicy("user", "/v1/products", "GET"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(channel.payloads) != 1 || calls != 0 {
+		t.Fatalf("announced %d updates and applied %d of its own", len(channel.payloads), calls)
+	}
+}
+
+func TestWatcherReloadsLargeUpdates(t *testing.T) {
+	enforcers, channel, _ := newWatchedEnforcers(t, 2)
+
+	// about 35 bytes a rule, more than postgres takes
+	var rules [][]string
+	for i := 0; i < maxNotifyPayload/20; i++ {
+		rules = append(rules, []string{"user", "/v1/products/" + strconv.Itoa(i), "GET"})
+	}
+	if _, err := enforcers[0].AddPolicies(rules); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(channel.payloads) != 1 || len(channel.payloads[0]) > maxNotifyPayload || !strings.Contains(channel.payloads[0], `"op":"`+models.PolicyUpdateReload+`"`) {
+		t.Fatalf("announced %d bytes: %.80s", len(channel.payloads[0]), channel.payloads[0])
+	}
+}
@@ -1,5 +1,13 @@
 package models
 
+import (
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/go-ozzo/ozzo-validation/v3/is"
+)
+
 type AdminReq struct {
 	Id       string `json:"id"`
 	FullName string `json:"full_name"`
@@ -21,13 +29,72 @@ type AdminResp struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// Admin is an admin account as superadmins see it, the password hash never leaves the gateway
+type Admin struct {
+	Id                 string     `json:"id"`
+	FullName           string     `json:"full_name"`
+	Age                int64      `json:"age"`
+	Email              string     `json:"email"`
+	UserName           string     `json:"username"`
+	Password           string     `json:"-"`
+	Role               string     `json:"role"`
+	Disabled           bool       `json:"disabled"`
+	MustChangePassword bool       `json:"must_change_password"`
+	LastLoginAt        *time.Time `json:"last_login_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          *time.Time `json:"updated_at"`
+}
+
+type AdminList struct {
+	Count  int64    `json:"count"`
+	Admins []*Admin `json:"admins"`
+}
+
+type AdminUpdateReq struct {
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+func (a *AdminUpdateReq) Validate() error {
+	return validation.ValidateStruct(
+		a,
+		validation.Field(&a.FullName, validation.Required, validation.Length(1, 200)),
+		validation.Field(&a.Email, validation.Required, is.Email),
+		validation.Field(&a.Role, validation.Required),
+	)
+}
+
+// AdminPasswordReset is returned once, the admin has to change
+// the temporary password at the next login
+type AdminPasswordReset struct {
+	Message           string `json:"message"`
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+type AdminChangePasswordReq struct {
+	Username        string `json:"username"`
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (a *AdminChangePasswordReq) Validate() error {
+	return validation.ValidateStruct(
+		a,
+		validation.Field(&a.Username, validation.Required),
+		validation.Field(&a.CurrentPassword, validation.Required),
+		validation.Field(&a.NewPassword, validation.Required, validation.Length(5, 15), validation.Match(regexp.MustCompile("[a-z]|[A-Z][0-9]"))),
+	)
+}
+
 type AdminLoginReq struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
 
 type AdminLoginResp struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type SuperAdminMessage struct {
@@ -56,3 +123,124 @@ type ListRolePolicyResp struct {
 type AddPolicyRequest struct {
 	Policy Policy
 }
+
+// Role is a role that casbin rules and subjects can refer to,
+// built in roles are used by the gateway itself and cannot be deleted
+type Role struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	BuiltIn     bool       `json:"built_in"`
+	Parents     []string   `json:"parents"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+type RoleList struct {
+	Roles []*Role `json:"roles"`
+}
+
+type RoleReq struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (r *RoleReq) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Name, validation.Required, validation.Match(regexp.MustCompile("^[a-z][a-z0-9_-]{1,49}$"))),
+		validation.Field(&r.Description, validation.Length(0, 500)),
+	)
+}
+
+// RoleInheritance is a grouping rule "g, role, parent",
+// the role gets every permission of the parent
+type RoleInheritance struct {
+	Role   string `json:"role"`
+	Parent string `json:"parent"`
+}
+
+type RoleInheritanceList struct {
+	Rules []*RoleInheritance `json:"rules"`
+}
+
+// SubjectRole is the role assigned to the "sub" of a user or an admin,
+// it is the role claim of the tokens they get at the next login
+type SubjectRole struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+}
+
+type SubjectRoleReq struct {
+	Role string `json:"role"`
+}
+
+// CasbinRule is one line of the policy set, "p, role, /v1/path, GET" or "g, role, parent"
+type CasbinRule struct {
+	PType string `json:"ptype"`
+	V0    string `json:"v0"`
+	V1    string `json:"v1"`
+	V2    string `json:"v2,omitempty"`
+}
+
+type PolicyProblem struct {
+	Rule   CasbinRule `json:"rule"`
+	Reason string     `json:"reason"`
+}
+
+// PolicyReport tells how a policy set fits the routes of the gateway,
+// Unmatched rules allow no registered route and Dead rules belong to undefined roles
+type PolicyReport struct {
+	Valid          bool             `json:"valid"`
+	Rules          int              `json:"rules"`
+	Invalid        []*PolicyProblem `json:"invalid"`
+	Unmatched      []*PolicyProblem `json:"unmatched"`
+	Dead           []*PolicyProblem `json:"dead"`
+	SuperAdminOnly []string         `json:"superadmin_only"`
+}
+
+// AuthCheckReq asks whether a request would pass the casbin middleware,
+// the role assigned to Subject is used when there is one, Role otherwise
+type AuthCheckReq struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+	Path    string `json:"path"`
+	Method  string `json:"method"`
+}
+
+// AuthCheckMatch is a line of the matcher in auth.conf that allows the
+// request and the policy it matched, superadmins are allowed without one
+type AuthCheckMatch struct {
+	Matcher string      `json:"matcher"`
+	Rule    *CasbinRule `json:"rule,omitempty"`
+}
+
+type AuthCheckResp struct {
+	Allowed    bool              `json:"allowed"`
+	Role       string            `json:"role"`
+	RoleSource string            `json:"role_source"`
+	RoleChain  []string          `json:"role_chain"`
+	Rule       *CasbinRule       `json:"rule,omitempty"`
+	Matches    []*AuthCheckMatch `json:"matches"`
+}
+
+// PolicyChannel is the postgres channel changes of the casbin policy are announced on
+const PolicyChannel = "casbin_policy"
+
+const (
+	PolicyUpdateAdd            = "add"
+	PolicyUpdateRemove         = "remove"
+	PolicyUpdateRemoveFiltered = "remove_filtered"
+	PolicyUpdateReload         = "reload"
+)
+
+// PolicyUpdate is a change of the casbin policy made by one gateway,
+// the others apply it to their enforcer, Origin tells the gateway it came from
+type PolicyUpdate struct {
+	Origin      string     `json:"origin,omitempty"`
+	Op          string     `json:"op"`
+	Sec         string     `json:"sec,omitempty"`
+	PType       string     `json:"ptype,omitempty"`
+	Rules       [][]string `json:"rules,omitempty"`
+	FieldIndex  int        `json:"field_index,omitempty"`
+	FieldValues []string   `json:"field_values,omitempty"`
+}
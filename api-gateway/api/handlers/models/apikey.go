@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+)
+
+// APIKey lets a machine client call the gateway with the X-API-Key header
+// instead of an access token, the request is allowed when one of the
+// scopes, casbin roles, allows it. Only the hash of the key is stored
+type APIKey struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Prefix  string `json:"prefix"`
+	KeyHash string `json:"-"`
+	// the key replaced by the last rotation works until PreviousKeyExpiresAt
+	PreviousKeyHash      string     `json:"-"`
+	PreviousKeyExpiresAt *time.Time `json:"previous_key_expires_at"`
+	Scopes               []string   `json:"scopes"`
+	CreatedBy            string     `json:"created_by"`
+	ExpiresAt            time.Time  `json:"expires_at"`
+	LastUsedAt           *time.Time `json:"last_used_at"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            *time.Time `json:"updated_at"`
+	RevokedAt            *time.Time `json:"revoked_at"`
+}
+
+type APIKeyList struct {
+	APIKeys []*APIKey `json:"api_keys"`
+}
+
+type APIKeyReq struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+func (a *APIKeyReq) Validate() error {
+	return validation.ValidateStruct(
+		a,
+		validation.Field(&a.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&a.Scopes, validation.Required, validation.Length(1, 10)),
+		validation.Field(&a.ExpiresInDays, validation.Required, validation.Min(1), validation.Max(365)),
+	)
+}
+
+// APIKeyRotateReq keeps the expiry of the key when ExpiresInDays is 0,
+// the old key keeps working for GracePeriod minutes so clients can switch
+type APIKeyRotateReq struct {
+	ExpiresInDays int `json:"expires_in_days"`
+	GracePeriod   int `json:"grace_period"`
+}
+
+func (a *APIKeyRotateReq) Validate() error {
+	return validation.ValidateStruct(
+		a,
+		validation.Field(&a.ExpiresInDays, validation.Min(0), validation.Max(365)),
+		validation.Field(&a.GracePeriod, validation.Min(0), validation.Max(1440)),
+	)
+}
+
+// APIKeySecret is returned when a key is created or rotated,
+// the key cannot be shown again
+type APIKeySecret struct {
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"api_key"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// MFA is the TOTP enrolment of a user or an admin,
+// Subject is the "sub" claim of their tokens
+type MFA struct {
+	Subject       string     `json:"subject"`
+	Secret        string     `json:"-"`
+	Enabled       bool       `json:"enabled"`
+	RecoveryCodes []string   `json:"-"`
+	LastUsedStep  int64      `json:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	EnabledAt     *time.Time `json:"enabled_at"`
+}
+
+type MFARequiredResp struct {
+	MFARequired   bool   `json:"mfa_required"`
+	SetupRequired bool   `json:"setup_required"`
+	MFAToken      string `json:"mfa_token"`
+}
+
+// MFATokenReq carries the token returned by login
+// while the second factor is not verified yet
+type MFATokenReq struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type MFASetupResp struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFAActivateReq struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type MFAActivateResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	AccessToken   string   `json:"access_token,omitempty"`
+	RefreshToken  string   `json:"refresh_token,omitempty"`
+}
+
+// MFAVerifyReq takes either a code of the authenticator app or a recovery code
+type MFAVerifyReq struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type MFAVerifyResp struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type MFADisableReq struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
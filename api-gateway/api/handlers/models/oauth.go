@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// OIDCState is a login started with an identity provider, it is kept until
+// the provider redirects back and can be used only once
+type OIDCState struct {
+	Provider     string    `json:"provider"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type OIDCProviderList struct {
+	Providers []string `json:"providers"`
+}
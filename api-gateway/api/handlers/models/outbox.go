@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+// OutboxEmail is an email waiting in the outbox table to be sent
+type OutboxEmail struct {
+	Id            string     `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Text          string     `json:"-"`
+	HTML          string     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
@@ -20,9 +20,8 @@ type PurchasedProductsList struct {
 }
 
 type BuyProductRequest struct {
-	UserId    string `json:"user_id"`
-	ProductId int32  `json:"product_id"`
-	Amount    int32  `json:"amount"`
+	ProductId int32 `json:"product_id"`
+	Amount    int32 `json:"amount"`
 }
 
 type BuyProductResponse struct {
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Session struct {
+	Id               string    `json:"id"`
+	UserId           string    `json:"user_id"`
+	Role             string    `json:"role"`
+	DeviceId         string    `json:"device_id"`
+	UserAgent        string    `json:"user_agent"`
+	RefreshTokenHash string    `json:"-"`
+	CreatedAt        time.Time `json:"created_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	Revoked          bool      `json:"revoked"`
+}
+
+type RevokeSessionsResp struct {
+	UserId          string `json:"user_id"`
+	RevokedSessions int64  `json:"revoked_sessions"`
+}
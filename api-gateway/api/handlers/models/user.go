@@ -2,6 +2,7 @@ package models
 
 import (
 	"regexp"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v3"
 	"github.com/go-ozzo/ozzo-validation/v3/is"
@@ -14,16 +15,31 @@ type UserRequest struct {
 	Age       int64  `json:"age"`
 	Email     string `json:"email"`
 	Password  string `json:"password"`
+	Locale    string `json:"locale"`
 }
 
+// RegisterUserModel is a registration waiting for its email to be verified,
+// only hashes of the password, the code and the magic link are kept
 type RegisterUserModel struct {
-	Id        string `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Age       int64  `json:"age"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	Code      string `json:"code"`
+	Id            string    `json:"id"`
+	FirstName     string    `json:"first_name"`
+	LastName      string    `json:"last_name"`
+	Age           int64     `json:"age"`
+	Email         string    `json:"email"`
+	PasswordHash  string    `json:"password_hash"`
+	Locale        string    `json:"locale"`
+	CodeHash      string    `json:"code_hash"`
+	Attempts      int       `json:"attempts"`
+	CodeExpiresAt time.Time `json:"code_expires_at"`
+	LinkId        string    `json:"link_id"`
+	Resends       int       `json:"resends"`
+	LastSentAt    time.Time `json:"last_sent_at"`
+	Verified      bool      `json:"verified"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type ResendVerificationReq struct {
+	Email string `json:"email"`
 }
 
 type VerifyUserResponse struct {
@@ -57,14 +73,17 @@ type ResponseError struct {
 	Message string `json:"message"`
 }
 
+// UserModel is the user as the gateway answers with it, the password hash
+// stays in the user service, the tokens are there only when a login issued them
 type UserModel struct {
-	Id          string `json:"id"`
-	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
-	Age         int64  `json:"age"`
-	Email       string `json:"email"`
-	Password    string `json:"password"`
-	AccessToken string `json:"access_token"`
+	Id           string `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Age          int64  `json:"age"`
+	Email        string `json:"email"`
+	Locale       string `json:"locale"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
 type ListUsers struct {
@@ -82,9 +101,10 @@ type AccessTokenUpdateReq struct {
 }
 
 type AccessTokenUpdateResp struct {
-	Status      string `json:"status"`
-	UserID      string `json:"user_id"`
-	AccessToken string `json:"access_token"`
+	Status       string `json:"status"`
+	UserID       string `json:"user_id"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type Status struct {
@@ -92,8 +112,73 @@ type Status struct {
 }
 
 type UserWithProducts struct {
-	User     UserRequest `json:"user"`
-	Products []*Product  `json:"products"`
+	User     UserModel  `json:"user"`
+	Products []*Product `json:"products"`
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordReq struct {
+	Email       string `json:"email"`
+	Code        string `json:"code"`
+	NewPassword string `json:"new_password"`
+}
+
+func (r *ResetPasswordReq) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Email, validation.Required, is.Email),
+		validation.Field(&r.Code, validation.Required),
+		validation.Field(&r.NewPassword, validation.Required, validation.Length(5, 15), validation.Match(regexp.MustCompile("[a-z]|[A-Z][0-9]"))),
+	)
+}
+
+// PasswordResetModel is kept in redis for a while after a reset was requested,
+// also for unknown emails, only the latest emailed code is valid until CodeExpiresAt
+type PasswordResetModel struct {
+	CodeHash      string    `json:"code_hash"`
+	CodeExpiresAt time.Time `json:"code_expires_at"`
+	Resends       int       `json:"resends"`
+	LastSentAt    time.Time `json:"last_sent_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (r *ChangePasswordReq) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.CurrentPassword, validation.Required),
+		validation.Field(&r.NewPassword, validation.Required, validation.Length(5, 15), validation.Match(regexp.MustCompile("[a-z]|[A-Z][0-9]"))),
+	)
+}
+
+type ChangeEmailReq struct {
+	NewEmail string `json:"new_email"`
+}
+
+func (r *ChangeEmailReq) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.NewEmail, validation.Required, is.Email),
+	)
+}
+
+type VerifyEmailChangeReq struct {
+	Code string `json:"code"`
+}
+
+// EmailChangeModel is kept in redis until the new email is verified
+type EmailChangeModel struct {
+	NewEmail  string    `json:"new_email"`
+	CodeHash  string    `json:"code_hash"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type AmountUpdateResp struct {
@@ -1,12 +1,14 @@
 package v1
 
 import (
+	"database/sql"
+	"errors"
 	"exam/api-gateway/api/handlers/models"
-	"exam/api-gateway/api/handlers/v1/tokens"
 	"exam/api-gateway/pkg/etc"
 	"exam/api-gateway/pkg/logger"
-	"fmt"
+	"exam/api-gateway/pkg/utils"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -21,12 +23,10 @@ import (
 // @Description Create a new admin if you are a superadmin
 // @Accept json
 // @Product json
-// @Param super-username query string true "super-username"
-// @Param super-password query string true "super-password"
 // @Param admin body models.AdminReq true "admin"
 // @Success 201 {object} models.SuperAdminMessage
 // @Failure 400 string error models.Error
-// @Failure 400 string error models.Error
+// @Failure 403 string error models.Error
 func (h *handlerV1) CreateAdmin(c *gin.Context) {
 	var (
 		jspbMarshal protojson.MarshalOptions
@@ -34,74 +34,67 @@ func (h *handlerV1) CreateAdmin(c *gin.Context) {
 	)
 	jspbMarshal.UseProtoNames = true
 
-	superAdminUsername := c.Query("super-username")
-	superAdminPassword := c.Query("super-password")
-
-	if superAdminUsername == "a" && superAdminPassword == "b" {
-		err := c.BindJSON(&body)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			h.log.Error("failed to bind json", logger.Error(err))
-			return
-		}
-
-		body.Id = uuid.NewString()
-
-		body.Password, err = etc.HashPassword(body.Password)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
-			h.log.Error("cannot hash the password", logger.Error(err))
-			return
-		}
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
 
-		h.jwtHandler = tokens.JWTHandler{
-			Sub:       body.Id,
-			Role:      "admin",
-			SignInKey: h.cfg.SignInKey,
-			Log:       h.log,
-			Timeout:   h.cfg.AccessTokenTimeout,
-		}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
 
-		_, refresh, err := h.jwtHandler.GenerateAuthJWT()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": err.Error(),
-			})
-			h.log.Error("cannot create new access and refresh token", logger.Error(err))
-			return
-		}
+	body.Id = uuid.NewString()
+	if body.Role == "" {
+		body.Role = RoleAdmin
+	}
+	if !isAdminRole(body.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "role must be admin or superadmin",
+		})
+		return
+	}
 
-		adminResp := models.AdminResp{
-			Id:           body.Id,
-			FullName:     body.FullName,
-			Age:          body.Age,
-			UserName:     body.UserName,
-			Email:        body.Email,
-			Password:     body.Password,
-			RefreshToken: refresh,
-		}
+	body.Password, err = etc.HashPassword(body.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot hash the password", logger.Error(err))
+		return
+	}
 
-		err = h.postgres.Create(&adminResp)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
-			h.log.Error("cannot create admin", logger.Error(err))
-			return
-		}
+	adminResp := models.AdminResp{
+		Id:       body.Id,
+		FullName: body.FullName,
+		Age:      body.Age,
+		UserName: body.UserName,
+		Email:    body.Email,
+		Password: body.Password,
+		Role:     body.Role,
+	}
 
-		c.JSON(http.StatusCreated, models.SuperAdminMessage{
-			Message: "admin successfully created",
-		})
-	} else {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"message": "you cannot create admin, provide username and password",
+	err = h.postgres.Create(&adminResp)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
 		})
+		h.log.Error("cannot create admin", logger.Error(err))
+		return
 	}
+
+	h.log.Info("admin created",
+		logger.String("superadmin", superAdmin),
+		logger.String("admin", adminResp.UserName),
+		logger.String("role", adminResp.Role))
+
+	c.JSON(http.StatusCreated, models.SuperAdminMessage{
+		Message: "admin successfully created",
+	})
 }
 
 // Delete Admin
@@ -112,12 +105,10 @@ func (h *handlerV1) CreateAdmin(c *gin.Context) {
 // @Description delete admin if you are a superadmin
 // @Accept json
 // @Product json
-// @Param super-username query string true "super-username"
-// @Param super-password query string true "super-password"
 // @Param admin body models.DeleteAdmin true "admin"
 // @Success 201 {object} models.SuperAdminMessage
 // @Failure 400 string error models.Error
-// @Failure 400 string error models.Error
+// @Failure 403 string error models.Error
 func (h *handlerV1) DeleteAdmin(c *gin.Context) {
 	var (
 		jspbMarshal protojson.MarshalOptions
@@ -125,69 +116,77 @@ func (h *handlerV1) DeleteAdmin(c *gin.Context) {
 	)
 	jspbMarshal.UseProtoNames = true
 
-	superAdminUsername := c.Query("super-username")
-	superAdminPassword := c.Query("super-password")
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
 
-	if superAdminUsername == "a" && superAdminPassword == "b" {
-		err := c.BindJSON(&body)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			h.log.Error("failed to bind json", logger.Error(err))
-			return
-		}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
 
-		_, password, status, err := h.postgres.Get(body.Username)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
-			h.log.Error("cannot get admin", logger.Error(err))
-			return
-		}
+	admin, err := h.postgres.GetByUsername(body.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "this admin does not exist",
+		})
+		h.log.Error("admin does not exist", logger.String("username", body.Username))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot get admin", logger.Error(err))
+		return
+	}
 
-		if !status {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "this admin does not exist",
-			})
-			h.log.Error("admin does not exist", logger.Error(err))
-			return
-		}
+	if !etc.CompareHashPassword(admin.Password, body.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "incorrect password",
+		})
+		h.log.Error("incorrect password", logger.String("username", body.Username))
+		return
+	}
 
-		if !etc.CompareHashPassword(password, body.Password) {
+	resp := h.postgres.Delete(body.Username, body.Password)
+	if resp != nil {
+		if resp.Error() == "no rows were deleted" {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "incorrect password",
-			})
-			h.log.Error("incorrect password", logger.Error(err))
-			return
-		}
-
-		resp := h.postgres.Delete(body.Username, body.Password)
-		if resp != nil {
-			if resp.Error() == "no rows were deleted" {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"message": "this admin does not exists",
-				})
-				return
-			}
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"message": resp.Error(),
+				"message": "this admin does not exists",
 			})
 			return
 		}
-
-		c.JSON(http.StatusOK, models.SuperAdminMessage{
-			Message: "admin successfully deleted",
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"message": resp.Error(),
 		})
-	} else {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"error":   "permission denied",
-			"message": "provide correct username and password for superadmin",
+		return
+	}
+
+	// a deleted admin keeps neither the tokens nor the role assigned to the id
+	if _, err := h.RevokeAllSessions(admin.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
 		})
+		h.log.Error("cannot revoke sessions of deleted admin", logger.Error(err))
 		return
 	}
+	if _, err := h.casbin.RemoveFilteredGroupingPolicy(0, subjectKey(admin.Id)); err != nil {
+		h.log.Error("cannot remove role assignment of deleted admin", logger.Error(err))
+	}
+
+	h.log.Info("admin deleted",
+		logger.String("superadmin", superAdmin),
+		logger.String("admin", body.Username))
 
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "admin successfully deleted",
+	})
 }
 
 // Delete Admin
@@ -198,8 +197,10 @@ func (h *handlerV1) DeleteAdmin(c *gin.Context) {
 // @Product json
 // @Param User body models.AdminLoginReq true "Login"
 // @Success 201 {object} models.AdminLoginResp
+// @Success 200 {object} models.MFARequiredResp
 // @Failure 400 string error models.Error
 // @Failure 400 string error models.Error
+// @Failure 429 string Error models.ResponseError
 // @Router /v1/auth/login [post]
 func (h *handlerV1) LoginAdmin(c *gin.Context) {
 	var (
@@ -218,7 +219,20 @@ func (h *handlerV1) LoginAdmin(c *gin.Context) {
 		return
 	}
 
-	role, password, status, err := h.postgres.Get(body.Username)
+	accountKey := attemptKey("admin", body.Username)
+	if !h.CheckLockout(c, accountKey) {
+		return
+	}
+
+	admin, err := h.postgres.GetByUsername(body.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		h.LoginFailed(c, accountKey)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "this admin does not exist",
+		})
+		h.log.Error("admin does not exist", logger.String("username", body.Username))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -226,54 +240,566 @@ func (h *handlerV1) LoginAdmin(c *gin.Context) {
 		h.log.Error("cannot get admin", logger.Error(err))
 		return
 	}
-
-	if !status {
+	if !etc.CompareHashPassword(admin.Password, body.Password) {
+		h.LoginFailed(c, accountKey)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "this admin does not exist",
+			"message": "incorrect password",
 		})
-		h.log.Error("admin does not exist", logger.Error(err))
+		h.log.Error("incorrect password", logger.Error(err))
 		return
 	}
 
-	if !etc.CompareHashPassword(password, body.Password) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "incorrect password",
+	if !isAdminRole(admin.Role) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "this account has no admin role",
 		})
-		h.log.Error("incorrect password", logger.Error(err))
+		h.log.Error("admin has unknown role", logger.String("role", admin.Role))
 		return
 	}
 
-	if role == "admin" {
-		h.jwtHandler = tokens.JWTHandler{
-			Sub:       body.Username,
-			Role:      "admin",
-			SignInKey: h.cfg.SignInKey,
-			Log:       h.log,
-			Timeout:   h.cfg.AccessTokenTimeout,
-		}
-	} else if role == "superadmin" {
-		fmt.Println(role)
-		h.jwtHandler = tokens.JWTHandler{
-			Sub:       body.Username,
-			Role:      "superadmin",
-			SignInKey: h.cfg.SignInKey,
-			Log:       h.log,
-			Timeout:   h.cfg.AccessTokenTimeout,
-		}
+	if admin.Disabled {
+		c.JSON(http.StatusForbidden, models.ResponseError{
+			Code:    ErrorCodeAccountDisabled,
+			Message: "this admin account is disabled",
+		})
+		h.log.Warn("disabled admin tried to log in", logger.String("username", body.Username))
+		return
 	}
 
-	access, _, err := h.jwtHandler.GenerateAuthJWT()
+	if admin.MustChangePassword {
+		c.JSON(http.StatusForbidden, models.ResponseError{
+			Code:    ErrorCodePasswordChangeRequired,
+			Message: "the password was reset, change it with /v1/auth/password/change",
+		})
+		return
+	}
+
+	// last_login_at is when the password was last accepted, the second factor may still follow
+	if err := h.postgres.UpdateLastLogin(admin.UserName); err != nil {
+		h.log.Error("cannot update last login of admin", logger.String("admin", admin.UserName), logger.Error(err))
+	}
+
+	// the id is the subject of the admin, role assignments, mfa and sessions use it
+	role := h.subjectRole(admin.Id, admin.Role)
+
+	if h.RequireMFA(c, accountKey, admin.Id, role) {
+		return
+	}
+	h.LoginSucceeded(accountKey)
+
+	access, refresh, err := h.NewSession(c, admin.Id, role)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
-		h.log.Error("cannot create access token", logger.Error(err))
+		h.log.Error("cannot create access and refresh token", logger.Error(err))
 		return
 	}
 
+	h.log.Info("admin logged in",
+		logger.String("username", body.Username),
+		logger.String("role", role))
+
 	response := models.AdminLoginResp{
-		AccessToken: access,
+		AccessToken:  access,
+		RefreshToken: refresh,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// Logout Admin
+// @Router /v1/auth/logout [post]
+// @Security BearerAuth
+// @Summary logout
+// @Tags Auth
+// @Description revoke the access token and close the session of this device
+// @Accept json
+// @Product json
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 401 string error models.Error
+// @Failure 500 string error models.Error
+func (h *handlerV1) LogoutAdmin(c *gin.Context) {
+	h.CloseSession(c)
+}
+
+const adminTemporaryPasswordLength = 12
+
+// List Admins
+// @Router /v1/auth/admins [get]
+// @Security BearerAuth
+// @Summary list admins
+// @Tags Auth
+// @Description List admins and superadmins page by page if you are a superadmin
+// @Produce json
+// @Param page query int false "page"
+// @Param limit query int false "limit"
+// @Success 200 {object} models.AdminList
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+func (h *handlerV1) ListAdmins(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	params, errStr := utils.ParseQueryParams(c.Request.URL.Query())
+	if len(errStr) > 0 || params.Page < 1 || params.Limit < 1 || params.Limit > 100 {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidParams,
+			Message: "page must be positive and limit between 1 and 100",
+		})
+		return
+	}
+
+	admins, err := h.postgres.List(int(params.Page), int(params.Limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot list admins", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, admins)
+}
+
+// Get Admin
+// @Router /v1/auth/admins/{id} [get]
+// @Security BearerAuth
+// @Summary get admin
+// @Tags Auth
+// @Description Get an admin by id if you are a superadmin
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.Admin
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) GetAdmin(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	admin, ok := h.getAdmin(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, admin)
+}
+
+// Update Admin
+// @Router /v1/auth/admins/{id} [put]
+// @Security BearerAuth
+// @Summary update admin
+// @Tags Auth
+// @Description Update the name, email and role of an admin if you are a superadmin, a new role replaces the role assigned to the admin and closes every session of the admin
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param admin body models.AdminUpdateReq true "admin"
+// @Success 200 {object} models.Admin
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) UpdateAdmin(c *gin.Context) {
+	var body models.AdminUpdateReq
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+	body.Email = strings.ToLower(strings.TrimSpace(body.Email))
+
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+	if !isAdminRole(body.Role) {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "role must be admin or superadmin",
+		})
+		return
+	}
+
+	admin, ok := h.getAdmin(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// a superadmin demoting themselves could leave nobody to manage admins
+	if admin.Id == superAdmin && body.Role != RoleSuperAdmin {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "you cannot take the superadmin role from yourself",
+		})
+		return
+	}
+
+	// tokens carry the role assigned to the admin when there is one,
+	// so it is the assignment the new role has to replace, once the
+	// admin is saved so a failed update leaves the assignment alone
+	tokenRole := h.subjectRole(admin.Id, admin.Role)
+	assigned := h.subjectRole(admin.Id, "")
+
+	updated, err := h.postgres.Update(admin.Id, &body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot update admin", logger.Error(err))
+		return
+	}
+
+	if assigned != "" && assigned != body.Role {
+		if err := h.replaceSubjectRole(admin.Id, body.Role); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			h.log.Error("cannot assign role", logger.Error(err))
+
+			// the previous assignment may be gone, the old tokens must not outlive it
+			if _, err := h.RevokeAllSessions(admin.Id); err != nil {
+				h.log.Error("cannot revoke sessions after failed role assignment", logger.Error(err))
+			}
+			return
+		}
+	}
+
+	// tokens carry the role, the old ones must not outlive it
+	if updated.Role != admin.Role || h.subjectRole(updated.Id, updated.Role) != tokenRole {
+		if _, err := h.RevokeAllSessions(updated.Id); err != nil {
+			h.log.Error("cannot revoke sessions after role change", logger.Error(err))
+		}
+	}
+
+	h.log.Info("admin updated",
+		logger.String("superadmin", superAdmin),
+		logger.String("admin", updated.UserName),
+		logger.String("role", updated.Role))
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// Disable Admin
+// @Router /v1/auth/admins/{id}/disable [post]
+// @Security BearerAuth
+// @Summary disable admin
+// @Tags Auth
+// @Description Disable an admin without deleting them if you are a superadmin, every session of the admin is closed
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) DisableAdmin(c *gin.Context) {
+	h.setAdminDisabled(c, true)
+}
+
+// Enable Admin
+// @Router /v1/auth/admins/{id}/enable [post]
+// @Security BearerAuth
+// @Summary enable admin
+// @Tags Auth
+// @Description Let a disabled admin log in again if you are a superadmin
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) EnableAdmin(c *gin.Context) {
+	h.setAdminDisabled(c, false)
+}
+
+func (h *handlerV1) setAdminDisabled(c *gin.Context, disabled bool) {
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	admin, ok := h.getAdmin(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if disabled && admin.Id == superAdmin {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "you cannot disable yourself",
+		})
+		return
+	}
+
+	if err := h.postgres.SetDisabled(admin.Id, disabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot disable admin", logger.Error(err))
+		return
+	}
+
+	message := "admin successfully enabled"
+	if disabled {
+		message = "admin successfully disabled"
+		if _, err := h.RevokeAllSessions(admin.Id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			h.log.Error("cannot revoke sessions of disabled admin", logger.Error(err))
+			return
+		}
+	}
+
+	h.log.Info(message,
+		logger.String("superadmin", superAdmin),
+		logger.String("admin", admin.UserName))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: message,
+	})
+}
+
+// Reset Admin Password
+// @Router /v1/auth/admins/{id}/password/reset [post]
+// @Security BearerAuth
+// @Summary reset admin password
+// @Tags Auth
+// @Description Replace the password of an admin with a temporary one if you are a superadmin, every session of the admin is closed and the admin has to change the password before logging in
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.AdminPasswordReset
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) ResetAdminPassword(c *gin.Context) {
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	admin, ok := h.getAdmin(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	temporary, err := utils.GenerateSecurePassword(adminTemporaryPasswordLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot generate temporary password", logger.Error(err))
+		return
+	}
+
+	password, err := etc.HashPassword(temporary)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot hash the password", logger.Error(err))
+		return
+	}
+
+	if err := h.postgres.SetPassword(admin.Id, password, true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot reset admin password", logger.Error(err))
+		return
+	}
+
+	if _, err := h.RevokeAllSessions(admin.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot revoke sessions after admin password reset", logger.Error(err))
+		return
+	}
+
+	h.log.Info("admin password reset",
+		logger.String("superadmin", superAdmin),
+		logger.String("admin", admin.UserName))
+
+	c.JSON(http.StatusOK, models.AdminPasswordReset{
+		Message:           "password was reset, the admin has to change it at the next login",
+		TemporaryPassword: temporary,
+	})
+}
+
+// Change Admin Password
+// @Router /v1/auth/password/change [post]
+// @Summary change admin password
+// @Tags Auth
+// @Description Change the password of an admin, required after a superadmin reset it, every session of the admin is closed
+// @Accept json
+// @Produce json
+// @Param password body models.AdminChangePasswordReq true "password"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 429 string error models.ResponseError
+func (h *handlerV1) ChangeAdminPassword(c *gin.Context) {
+	var body models.AdminChangePasswordReq
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// the current password is checked here like at login, so it shares the lockout
+	accountKey := attemptKey("admin", body.Username)
+	if !h.CheckLockout(c, accountKey) {
+		return
+	}
+
+	admin, err := h.postgres.GetByUsername(body.Username)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot get admin", logger.Error(err))
+		return
+	}
+	if err != nil || !etc.CompareHashPassword(admin.Password, body.CurrentPassword) {
+		h.LoginFailed(c, accountKey)
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorInvalidCredentials,
+			Message: "username or current password is incorrect",
+		})
+		return
+	}
+	h.LoginSucceeded(accountKey)
+
+	if admin.Disabled {
+		c.JSON(http.StatusForbidden, models.ResponseError{
+			Code:    ErrorCodeAccountDisabled,
+			Message: "this admin account is disabled",
+		})
+		return
+	}
+
+	if body.NewPassword == body.CurrentPassword {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "the new password must differ from the current one",
+		})
+		return
+	}
+
+	password, err := etc.HashPassword(body.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot hash the password", logger.Error(err))
+		return
+	}
+
+	if err := h.postgres.SetPassword(admin.Id, password, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot change admin password", logger.Error(err))
+		return
+	}
+
+	if _, err := h.RevokeAllSessions(admin.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot revoke sessions after admin password change", logger.Error(err))
+		return
+	}
+
+	h.log.Info("admin password changed", logger.String("admin", admin.UserName))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "password was changed, login again",
+	})
+}
+
+// getAdmin answers 404 and returns false when there is no admin with the id
+func (h *handlerV1) getAdmin(c *gin.Context, id string) (*models.Admin, bool) {
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "admin not found",
+		})
+		return nil, false
+	}
+
+	admin, err := h.postgres.GetById(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "admin not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot get admin", logger.Error(err))
+		return nil, false
+	}
+
+	return admin, true
+}
+
+// activeAdmin answers 401 and returns false when the subject is an admin
+// that was disabled or deleted after the session was opened
+func (h *handlerV1) activeAdmin(c *gin.Context, sub, role string) bool {
+	// the admins are keyed by uuid, other subjects cannot be admins
+	if _, err := uuid.Parse(sub); err != nil {
+		return true
+	}
+
+	admin, err := h.postgres.GetById(sub)
+	if errors.Is(err, sql.ErrNoRows) && !isAdminRole(role) {
+		return true
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get admin", logger.Error(err))
+		return false
+	}
+	if err != nil || admin.Disabled {
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "the admin account is disabled or deleted",
+		})
+		return false
+	}
+
+	return true
+}
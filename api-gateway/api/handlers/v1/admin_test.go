@@ -0,0 +1,600 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/api/handlers/v1/tokens"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// adminStorage keeps the admins of the tests in memory by id,
+// updates fail with updateErr when it is set
+type adminStorage struct {
+	mu        sync.Mutex
+	admins    map[string]*models.Admin
+	created   []*models.AdminResp
+	updateErr error
+}
+
+func (a *adminStorage) Create(admin *models.AdminResp) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.created = append(a.created, admin)
+	a.admins[admin.Id] = &models.Admin{
+		Id:       admin.Id,
+		FullName: admin.FullName,
+		Age:      admin.Age,
+		Email:    admin.Email,
+		UserName: admin.UserName,
+		Password: admin.Password,
+		Role:     admin.Role,
+	}
+	return nil
+}
+
+func (a *adminStorage) Delete(userName, password string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for id, admin := range a.admins {
+		if admin.UserName == userName {
+			delete(a.admins, id)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (a *adminStorage) Get(userName string) (string, string, bool, error) {
+	admin, err := a.GetByUsername(userName)
+	if err != nil {
+		return "", "", false, nil
+	}
+	return admin.Role, admin.Password, true, nil
+}
+
+func (a *adminStorage) GetById(id string) (*models.Admin, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	admin, ok := a.admins[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *admin
+	return &copied, nil
+}
+
+func (a *adminStorage) GetByUsername(userName string) (*models.Admin, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, admin := range a.admins {
+		if admin.UserName == userName {
+			copied := *admin
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (a *adminStorage) List(page, limit int) (*models.AdminList, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	list := &models.AdminList{Count: int64(len(a.admins)), Admins: []*models.Admin{}}
+	for _, admin := range a.admins {
+		copied := *admin
+		list.Admins = append(list.Admins, &copied)
+	}
+	return list, nil
+}
+
+func (a *adminStorage) Update(id string, update *models.AdminUpdateReq) (*models.Admin, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.updateErr != nil {
+		return nil, a.updateErr
+	}
+	admin, ok := a.admins[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	admin.FullName, admin.Email, admin.Role = update.FullName, update.Email, update.Role
+	copied := *admin
+	return &copied, nil
+}
+
+func (a *adminStorage) SetDisabled(id string, disabled bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	admin, ok := a.admins[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	admin.Disabled = disabled
+	return nil
+}
+
+func (a *adminStorage) SetPassword(id, password string, mustChange bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	admin, ok := a.admins[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	admin.Password, admin.MustChangePassword = password, mustChange
+	return nil
+}
+
+func (a *adminStorage) UpdateLastLogin(userName string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for _, admin := range a.admins {
+		if admin.UserName == userName {
+			admin.LastLoginAt = &now
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+const (
+	testAdminId      = "6f1c2f0e-3d4b-4a8e-9c61-1b7f0f6d2a10"
+	testSuperAdminId = "0b6c9c44-52f7-4c1f-a3b5-8d2e6a1f9e37"
+	testAdminPass    = "admin-password"
+)
+
+// newAdminTestHandler has an admin and a superadmin, the policy may assign roles to their ids
+func newAdminTestHandler(t *testing.T, policy string) (*handlerV1, *adminStorage, *sessionStorage) {
+	t.Helper()
+
+	h, _, sessions := newSessionTestHandler(t)
+	h.casbin, _ = newTestEnforcer(t, policy)
+	h.mfa = &mfaStorage{enrolments: map[string]*models.MFA{}, usedSteps: map[string]int64{}, usedCodes: map[string]bool{}}
+
+	// etc.HashPassword is too slow for tests, bcrypt accepts any cost
+	password, err := bcrypt.GenerateFromPassword([]byte(testAdminPass), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admins := &adminStorage{admins: map[string]*models.Admin{
+		testAdminId:      {Id: testAdminId, UserName: "admin", Email: "admin@example.com", Password: string(password), Role: RoleAdmin},
+		testSuperAdminId: {Id: testSuperAdminId, UserName: "root", Email: "root@example.com", Password: string(password), Role: RoleSuperAdmin},
+	}}
+	h.postgres = admins
+	return h, admins, sessions
+}
+
+// newAdminContext is a request of the superadmin with the id as the subject
+func newAdminContext(method, target string, body interface{}, params ...gin.Param) (*gin.Context, *httptest.ResponseRecorder) {
+	data, _ := json.Marshal(body)
+
+	c, recorder := newTestContext("10.0.4.1")
+	c.Request = httptest.NewRequest(method, target, bytes.NewReader(data))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("role", RoleSuperAdmin)
+	c.Set("sub", testSuperAdminId)
+	return c, recorder
+}
+
+func TestLoginAdminSubject(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		policy   string
+		sub      string
+		role     string
+	}{
+		{name: "admin", username: "admin", sub: testAdminId, role: RoleAdmin},
+		{name: "superadmin", username: "root", sub: testSuperAdminId, role: RoleSuperAdmin},
+		{
+			name:     "role assigned to the id",
+			username: "admin",
+			policy:   "g, sub:" + testAdminId + ", " + RoleSuperAdmin + "\n",
+			sub:      testAdminId,
+			role:     RoleSuperAdmin,
+		},
+		{
+			name:     "role assigned to the username",
+			username: "admin",
+			policy:   "g, sub:admin, " + RoleSuperAdmin + "\n",
+			sub:      testAdminId,
+			role:     RoleAdmin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newAdminTestHandler(t, tt.policy)
+
+			c, recorder := newAdminContext(http.MethodPost, "/v1/auth/login", models.AdminLoginReq{Username: tt.username, Password: testAdminPass})
+			h.LoginAdmin(c)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("login answered %d: %s", recorder.Code, recorder.Body)
+			}
+
+			var resp models.AdminLoginResp
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			claims, err := tokens.ExtractClaim(resp.AccessToken, h.jwtKeys, tokens.TypeAccess)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != tt.sub || claims.Role != tt.role {
+				t.Fatalf("token of %q as %q, want %q as %q", claims.Subject, claims.Role, tt.sub, tt.role)
+			}
+		})
+	}
+}
+
+func TestAdminSelfChecksUseId(t *testing.T) {
+	h, admins, _ := newAdminTestHandler(t, "")
+	self := gin.Param{Key: "id", Value: testSuperAdminId}
+
+	c, recorder := newAdminContext(http.MethodPut, "/v1/auth/admins/"+testSuperAdminId,
+		models.AdminUpdateReq{FullName: "Root", Email: "root@example.com", Role: RoleAdmin}, self)
+	h.UpdateAdmin(c)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("demoting yourself answered %d", recorder.Code)
+	}
+
+	c, recorder = newAdminContext(http.MethodPost, "/v1/auth/admins/"+testSuperAdminId+"/disable", nil, self)
+	h.DisableAdmin(c)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("disabling yourself answered %d", recorder.Code)
+	}
+	if admins.admins[testSuperAdminId].Disabled || admins.admins[testSuperAdminId].Role != RoleSuperAdmin {
+		t.Fatal("the superadmin changed their own account")
+	}
+}
+
+func TestDisableAdminRevokesSessionsOfId(t *testing.T) {
+	h, _, sessions := newAdminTestHandler(t, "")
+
+	c, _ := newTestContext("10.0.4.1")
+	_, refreshToken, err := h.NewSession(c, testAdminId, RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, recorder := newAdminContext(http.MethodPost, "/v1/auth/admins/"+testAdminId+"/disable", nil, gin.Param{Key: "id", Value: testAdminId})
+	h.DisableAdmin(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("disable answered %d: %s", recorder.Code, recorder.Body)
+	}
+
+	for _, session := range sessions.sessions {
+		if session.UserId == testAdminId && !session.Revoked {
+			t.Fatal("session of the disabled admin is open")
+		}
+	}
+	if status, _ := refresh(h, refreshToken); status != http.StatusUnauthorized {
+		t.Fatalf("refresh of the disabled admin answered %d", status)
+	}
+}
+
+func TestDeleteAdminClosesSessions(t *testing.T) {
+	h, admins, _ := newAdminTestHandler(t, "g, sub:"+testAdminId+", "+RoleSuperAdmin+"\n")
+
+	c, _ := newTestContext("10.0.4.1")
+	access, refreshToken, err := h.NewSession(c, testAdminId, RoleSuperAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, recorder := newAdminContext(http.MethodDelete, "/v1/auth/delete", models.DeleteAdmin{Username: "admin", Password: testAdminPass})
+	h.DeleteAdmin(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("delete answered %d: %s", recorder.Code, recorder.Body)
+	}
+
+	if _, ok := admins.admins[testAdminId]; ok {
+		t.Fatal("admin is not deleted")
+	}
+	if status, _ := refresh(h, refreshToken); status != http.StatusUnauthorized {
+		t.Fatalf("refresh of the deleted admin answered %d", status)
+	}
+	if notBefore, err := h.inMemoryStorage.Get(tokens.NotBeforeKey(testAdminId)); err != nil || notBefore == nil {
+		t.Fatalf("access token %q of the deleted admin is not denied", access)
+	}
+	if role := h.subjectRole(testAdminId, ""); role != "" {
+		t.Fatalf("deleted admin is still assigned %q", role)
+	}
+}
+
+func TestUpdateAdminRoleAssignment(t *testing.T) {
+	tests := []struct {
+		name      string
+		updateErr error
+		status    int
+		assigned  string
+	}{
+		{name: "updated", status: http.StatusOK, assigned: RoleAdmin},
+		{name: "update failed", updateErr: errors.New("connection refused"), status: http.StatusInternalServerError, assigned: RoleSuperAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, admins, _ := newAdminTestHandler(t, "g, sub:"+testAdminId+", "+RoleSuperAdmin+"\n")
+			admins.updateErr = tt.updateErr
+
+			c, recorder := newAdminContext(http.MethodPut, "/v1/auth/admins/"+testAdminId,
+				models.AdminUpdateReq{FullName: "Admin", Email: "admin@example.com", Role: RoleAdmin},
+				gin.Param{Key: "id", Value: testAdminId})
+			h.UpdateAdmin(c)
+			if recorder.Code != tt.status {
+				t.Fatalf("update answered %d, want %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+			if role := h.subjectRole(testAdminId, ""); role != tt.assigned {
+				t.Fatalf("assigned role %q, want %q", role, tt.assigned)
+			}
+		})
+	}
+}
+
+func TestRefreshOfInactiveAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(admins *adminStorage)
+		status int
+	}{
+		{name: "active", change: func(*adminStorage) {}, status: http.StatusOK},
+		{name: "disabled", change: func(admins *adminStorage) { admins.admins[testAdminId].Disabled = true }, status: http.StatusUnauthorized},
+		{name: "deleted", change: func(admins *adminStorage) { delete(admins.admins, testAdminId) }, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, admins, _ := newAdminTestHandler(t, "")
+
+			// the session is left open as if its revocation had failed
+			c, _ := newTestContext("10.0.4.1")
+			_, refreshToken, err := h.NewSession(c, testAdminId, RoleAdmin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.change(admins)
+
+			if status, _ := refresh(h, refreshToken); status != tt.status {
+				t.Fatalf("refresh answered %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestCreateAdminStoresNoToken(t *testing.T) {
+	h, admins, sessions := newAdminTestHandler(t, "")
+
+	c, recorder := newAdminContext(http.MethodPost, "/v1/auth/create", models.AdminReq{
+		FullName: "New Admin",
+		Email:    "new-admin@example.com",
+		UserName: "new-admin",
+		Password: "new-admin-password",
+	})
+	h.CreateAdmin(c)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("create answered %d: %s", recorder.Code, recorder.Body)
+	}
+
+	if len(admins.created) != 1 || admins.created[0].RefreshToken != "" {
+		t.Fatalf("created %+v", admins.created)
+	}
+	if len(sessions.sessions) != 0 {
+		t.Fatal("a session is opened for the new admin")
+	}
+}
+
+// loginAdmin logs in as the admin and returns the status
+func loginAdmin(h *handlerV1, username, password string) int {
+	c, recorder := newRequestContext(http.MethodPost, "/v1/auth/login", models.AdminLoginReq{Username: username, Password: password}, "", "")
+	h.LoginAdmin(c)
+	return recorder.Code
+}
+
+func TestListAdmins(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantCount int64
+	}{
+		{name: "first page", query: "?page=1&limit=10", wantCode: http.StatusOK, wantCount: 2},
+		{name: "page zero", query: "?page=0&limit=10", wantCode: http.StatusBadRequest},
+		{name: "limit too big", query: "?page=1&limit=101", wantCode: http.StatusBadRequest},
+		{name: "limit not a number", query: "?page=1&limit=ten", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newAdminTestHandler(t, "")
+
+			c, recorder := newAdminContext(http.MethodGet, "/v1/auth/admins"+tt.query, nil)
+			h.ListAdmins(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var list models.AdminList
+			if err := json.Unmarshal(recorder.Body.Bytes(), &list); err != nil {
+				t.Fatal(err)
+			}
+			if list.Count != tt.wantCount || len(list.Admins) != int(tt.wantCount) {
+				t.Fatalf("listed %d of %d admins", len(list.Admins), list.Count)
+			}
+			if strings.Contains(recorder.Body.String(), "$2a$") {
+				t.Fatal("the list has password hashes")
+			}
+		})
+	}
+}
+
+func TestGetAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		wantCode int
+	}{
+		{name: "admin", id: testAdminId, wantCode: http.StatusOK},
+		{name: "unknown id", id: "1d2c3b4a-5e6f-4a7b-8c9d-0e1f2a3b4c5d", wantCode: http.StatusNotFound},
+		{name: "not an id", id: "admin", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newAdminTestHandler(t, "")
+
+			c, recorder := newAdminContext(http.MethodGet, "/v1/auth/admins/"+tt.id, nil, gin.Param{Key: "id", Value: tt.id})
+			h.GetAdmin(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var admin models.Admin
+			if err := json.Unmarshal(recorder.Body.Bytes(), &admin); err != nil {
+				t.Fatal(err)
+			}
+			if admin.Id != tt.id || admin.UserName != "admin" || strings.Contains(recorder.Body.String(), "$2a$") {
+				t.Fatalf("admin = %s", recorder.Body)
+			}
+		})
+	}
+}
+
+func TestLoginOfDisabledAdmin(t *testing.T) {
+	h, admins, _ := newAdminTestHandler(t, "")
+	id := gin.Param{Key: "id", Value: testAdminId}
+
+	c, recorder := newAdminContext(http.MethodPost, "/v1/auth/admins/"+testAdminId+"/disable", nil, id)
+	h.DisableAdmin(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("disable answered %d", recorder.Code)
+	}
+	if code := loginAdmin(h, "admin", testAdminPass); code != http.StatusForbidden {
+		t.Fatalf("login of disabled admin answered %d", code)
+	}
+	if admins.admins[testAdminId].LastLoginAt != nil {
+		t.Fatal("the refused login was recorded")
+	}
+
+	c, recorder = newAdminContext(http.MethodPost, "/v1/auth/admins/"+testAdminId+"/enable", nil, id)
+	h.EnableAdmin(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("enable answered %d", recorder.Code)
+	}
+	if code := loginAdmin(h, "admin", testAdminPass); code != http.StatusOK {
+		t.Fatalf("login of enabled admin answered %d", code)
+	}
+	if admins.admins[testAdminId].LastLoginAt == nil {
+		t.Fatal("last_login_at is not set")
+	}
+}
+
+func TestResetAdminPassword(t *testing.T) {
+	h, _, sessions := newAdminTestHandler(t, "")
+	if code := loginAdmin(h, "admin", testAdminPass); code != http.StatusOK {
+		t.Fatalf("login answered %d", code)
+	}
+
+	c, recorder := newAdminContext(http.MethodPost, "/v1/auth/admins/"+testAdminId+"/password/reset", nil, gin.Param{Key: "id", Value: testAdminId})
+	h.ResetAdminPassword(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("reset answered %d: %s", recorder.Code, recorder.Body)
+	}
+	var reset models.AdminPasswordReset
+	if err := json.Unmarshal(recorder.Body.Bytes(), &reset); err != nil {
+		t.Fatal(err)
+	}
+	for _, session := range sessions.sessions {
+		if session.UserId == testAdminId && !session.Revoked {
+			t.Fatal("a session of the admin is still open")
+		}
+	}
+
+	// the old password is gone and the temporary one only lets the admin change it
+	if code := loginAdmin(h, "admin", testAdminPass); code != http.StatusBadRequest {
+		t.Fatalf("login with the old password answered %d", code)
+	}
+	if code := loginAdmin(h, "admin", reset.TemporaryPassword); code != http.StatusForbidden {
+		t.Fatalf("login with the temporary password answered %d", code)
+	}
+
+	c, recorder = newRequestContext(http.MethodPost, "/v1/auth/password/change", models.AdminChangePasswordReq{
+		Username:        "admin",
+		CurrentPassword: reset.TemporaryPassword,
+		NewPassword:     "newpass1",
+	}, "", "")
+	h.ChangeAdminPassword(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("change answered %d: %s", recorder.Code, recorder.Body)
+	}
+	if code := loginAdmin(h, "admin", "newpass1"); code != http.StatusOK {
+		t.Fatalf("login with the new password answered %d", code)
+	}
+}
+
+func TestCreateAdminRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		wantCode int
+		wantRole string
+	}{
+		{name: "superadmin", role: RoleSuperAdmin, wantCode: http.StatusCreated, wantRole: RoleSuperAdmin},
+		{name: "not an admin role", role: RoleUser, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, admins, _ := newAdminTestHandler(t, "")
+
+			c, recorder := newAdminContext(http.MethodPost, "/v1/auth/create", models.AdminReq{
+				FullName: "New Admin",
+				Email:    "new-admin@example.com",
+				UserName: "new-admin",
+				Password: "new-admin-password",
+				Role:     tt.role,
+			})
+			h.CreateAdmin(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+
+			created, err := admins.GetByUsername("new-admin")
+			if tt.wantRole == "" {
+				if err == nil {
+					t.Fatalf("created %+v", created)
+				}
+				return
+			}
+			if err != nil || created.Role != tt.wantRole {
+				t.Fatalf("created %+v, %v", created, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/etc"
+	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefixLength is how much of a key is kept to tell the keys apart in lists
+const apiKeyPrefixLength = 12
+
+// List API keys
+// @Router /v1/auth/api-keys [get]
+// @Security BearerAuth
+// @Summary list api keys
+// @Tags Auth
+// @Description List the api keys of machine clients if you are a superadmin, revoked and expired ones included
+// @Produce json
+// @Success 200 {object} models.APIKeyList
+// @Failure 403 string error models.ResponseError
+func (h *handlerV1) ListAPIKeys(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	keys, err := h.apiKeys.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot list api keys", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIKeyList{APIKeys: keys})
+}
+
+// Create API key
+// @Router /v1/auth/api-keys [post]
+// @Security BearerAuth
+// @Summary create api key
+// @Tags Auth
+// @Description Issue a named api key for a machine client if you are a superadmin, the client sends it in the X-API-Key header.
+// @Description Scopes are the roles whose policies the key may use, superadmin cannot be one. The key is shown only once
+// @Accept json
+// @Produce json
+// @Param api_key body models.APIKeyReq true "api key"
+// @Success 201 {object} models.APIKeySecret
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) CreateAPIKey(c *gin.Context) {
+	var body models.APIKeyReq
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	scopes, ok := h.apiKeyScopes(c, body.Scopes)
+	if !ok {
+		return
+	}
+
+	secret, err := utils.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot generate api key", logger.Error(err))
+		return
+	}
+
+	key := models.APIKey{
+		Id:        uuid.NewString(),
+		Name:      body.Name,
+		Prefix:    secret[:apiKeyPrefixLength],
+		KeyHash:   etc.HashToken(secret),
+		Scopes:    scopes,
+		CreatedBy: superAdmin,
+		ExpiresAt: time.Now().AddDate(0, 0, body.ExpiresInDays),
+	}
+	if err := h.apiKeys.Create(&key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot create api key", logger.Error(err))
+		return
+	}
+
+	h.log.Info("api key created",
+		logger.String("superadmin", superAdmin),
+		logger.String("api_key", key.Id),
+		logger.Any("scopes", key.Scopes))
+
+	c.JSON(http.StatusCreated, models.APIKeySecret{
+		Key:    secret,
+		APIKey: &key,
+	})
+}
+
+// Get API key
+// @Router /v1/auth/api-keys/{id} [get]
+// @Security BearerAuth
+// @Summary get api key
+// @Tags Auth
+// @Description Get an api key by id if you are a superadmin, the key itself is never shown again
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.APIKey
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) GetAPIKey(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	key, ok := h.getAPIKey(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// Revoke API key
+// @Router /v1/auth/api-keys/{id}/revoke [post]
+// @Security BearerAuth
+// @Summary revoke api key
+// @Tags Auth
+// @Description Revoke an api key if you are a superadmin, the clients using it are refused at once
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) RevokeAPIKey(c *gin.Context) {
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	key, ok := h.getAPIKey(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := h.apiKeys.Revoke(key.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "api key is already revoked",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot revoke api key", logger.Error(err))
+		return
+	}
+
+	h.log.Info("api key revoked",
+		logger.String("superadmin", superAdmin),
+		logger.String("api_key", key.Id))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "api key successfully revoked",
+	})
+}
+
+// Rotate API key
+// @Router /v1/auth/api-keys/{id}/rotate [post]
+// @Security BearerAuth
+// @Summary rotate api key
+// @Tags Auth
+// @Description Replace an api key with a new one keeping its name and scopes if you are a superadmin.
+// @Description The old key keeps working for grace_period minutes, the expiry is kept unless expires_in_days is given
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param rotation body models.APIKeyRotateReq true "rotation"
+// @Success 200 {object} models.APIKeySecret
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) RotateAPIKey(c *gin.Context) {
+	var body models.APIKeyRotateReq
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	key, ok := h.getAPIKey(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	expiresAt := key.ExpiresAt
+	if body.ExpiresInDays > 0 {
+		expiresAt = now.AddDate(0, 0, body.ExpiresInDays)
+	}
+	if key.RevokedAt != nil || !expiresAt.After(now) {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "revoked keys cannot be rotated, expired ones need expires_in_days",
+		})
+		return
+	}
+
+	secret, err := utils.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot generate api key", logger.Error(err))
+		return
+	}
+
+	rotated, err := h.apiKeys.Rotate(key.Id, etc.HashToken(secret), secret[:apiKeyPrefixLength],
+		expiresAt, now.Add(time.Minute*time.Duration(body.GracePeriod)))
+	if errors.Is(err, sql.ErrNoRows) {
+		// revoked in the meantime
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "revoked keys cannot be rotated",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot rotate api key", logger.Error(err))
+		return
+	}
+
+	h.log.Info("api key rotated",
+		logger.String("superadmin", superAdmin),
+		logger.String("api_key", key.Id),
+		logger.Int("grace_period", body.GracePeriod))
+
+	c.JSON(http.StatusOK, models.APIKeySecret{
+		Key:    secret,
+		APIKey: rotated,
+	})
+}
+
+// apiKeyScopes checks that every scope is a defined role a client may act as,
+// superadmins are exempt from the policies so a key cannot be one
+func (h *handlerV1) apiKeyScopes(c *gin.Context, requested []string) ([]string, bool) {
+	scopes := []string{}
+	seen := map[string]bool{}
+	for _, scope := range requested {
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+
+		if scope == RoleSuperAdmin || scope == casb.RoleUnauthorized {
+			c.JSON(http.StatusBadRequest, models.ResponseError{
+				Code:    ErrorBadRequest,
+				Message: "an api key cannot have the " + scope + " scope",
+			})
+			return nil, false
+		}
+		if _, ok := h.getRole(c, scope); !ok {
+			return nil, false
+		}
+		scopes = append(scopes, scope)
+	}
+
+	return scopes, true
+}
+
+func (h *handlerV1) getAPIKey(c *gin.Context, id string) (*models.APIKey, bool) {
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "api key not found",
+		})
+		return nil, false
+	}
+
+	key, err := h.apiKeys.Get(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "api key not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot get api key", logger.Error(err))
+		return nil, false
+	}
+
+	return key, true
+}
@@ -0,0 +1,227 @@
+package v1
+
+import (
+	"database/sql"
+	"encoding/json"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/etc"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testAPIKeyId = "3a9f6e2d-8c1b-4d7a-b5e4-2f0c9d8e7a61"
+
+// apiKeyStorage keeps api keys in memory like the api_keys table
+type apiKeyStorage struct {
+	keys []*models.APIKey
+}
+
+func (a *apiKeyStorage) Create(key *models.APIKey) error {
+	created := *key
+	a.keys = append(a.keys, &created)
+	return nil
+}
+
+func (a *apiKeyStorage) Get(id string) (*models.APIKey, error) {
+	for _, key := range a.keys {
+		if key.Id == id {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (a *apiKeyStorage) GetByHash(hash string) (*models.APIKey, error) {
+	for _, key := range a.keys {
+		if key.KeyHash == hash || (key.PreviousKeyHash != "" && key.PreviousKeyHash == hash) {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (a *apiKeyStorage) List() ([]*models.APIKey, error) { return a.keys, nil }
+
+func (a *apiKeyStorage) Rotate(id, hash, prefix string, expiresAt, previousExpiresAt time.Time) (*models.APIKey, error) {
+	for _, key := range a.keys {
+		if key.Id == id && key.RevokedAt == nil {
+			key.PreviousKeyHash, key.PreviousKeyExpiresAt = key.KeyHash, &previousExpiresAt
+			key.KeyHash, key.Prefix, key.ExpiresAt = hash, prefix, expiresAt
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (a *apiKeyStorage) Revoke(id string) error {
+	for _, key := range a.keys {
+		if key.Id == id && key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (a *apiKeyStorage) Touch(id string) error { return nil }
+
+func (a *apiKeyStorage) CountByScope(scope string) (int64, error) {
+	var count int64
+	for _, key := range a.keys {
+		for _, keyScope := range key.Scopes {
+			if keyScope == scope && key.RevokedAt == nil {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		scopes     []string
+		wantCode   int
+		wantScopes []string
+	}{
+		{name: "defined roles", scopes: []string{RoleUser, "auditor", RoleUser}, wantCode: http.StatusCreated, wantScopes: []string{RoleUser, "auditor"}},
+		{name: "superadmin scope", scopes: []string{RoleUser, RoleSuperAdmin}, wantCode: http.StatusBadRequest},
+		{name: "unauthorized scope", scopes: []string{casb.RoleUnauthorized}, wantCode: http.StatusBadRequest},
+		{name: "undefined role", scopes: []string{"support"}, wantCode: http.StatusNotFound},
+		{name: "no scope", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy)
+			apiKeys := h.apiKeys.(*apiKeyStorage)
+
+			c, recorder := newAdminContext(http.MethodPost, "/v1/rbac/api-keys", models.APIKeyReq{Name: "exporter", Scopes: tt.scopes, ExpiresInDays: 30})
+			h.CreateAPIKey(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusCreated {
+				if len(apiKeys.keys) != 0 {
+					t.Fatal("a rejected key was stored")
+				}
+				return
+			}
+
+			var created models.APIKeySecret
+			if err := json.Unmarshal(recorder.Body.Bytes(), &created); err != nil {
+				t.Fatal(err)
+			}
+			// only the hash of the secret is kept
+			stored, err := apiKeys.GetByHash(etc.HashToken(created.Key))
+			if err != nil || stored.KeyHash == created.Key || stored.CreatedBy != testSuperAdminId {
+				t.Fatalf("stored %+v, %v", stored, err)
+			}
+			if !reflect.DeepEqual(stored.Scopes, tt.wantScopes) {
+				t.Fatalf("scopes = %v, want %v", stored.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		revoked  bool
+		wantCode int
+	}{
+		{name: "active key", id: testAPIKeyId, wantCode: http.StatusOK},
+		{name: "revoked key", id: testAPIKeyId, revoked: true, wantCode: http.StatusBadRequest},
+		{name: "unknown key", id: "9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b", wantCode: http.StatusNotFound},
+		{name: "not an id", id: "key", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy)
+			key := &models.APIKey{Id: testAPIKeyId, KeyHash: etc.HashToken("secret"), Scopes: []string{RoleUser}, ExpiresAt: time.Now().Add(time.Hour)}
+			if tt.revoked {
+				key.RevokedAt = &time.Time{}
+			}
+			h.apiKeys = &apiKeyStorage{keys: []*models.APIKey{key}}
+
+			c, recorder := newAdminContext(http.MethodPost, "/v1/rbac/api-keys/"+tt.id+"/revoke", nil, gin.Param{Key: "id", Value: tt.id})
+			h.RevokeAPIKey(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if revoked := key.RevokedAt != nil; revoked != (tt.revoked || tt.wantCode == http.StatusOK) {
+				t.Fatalf("revoked = %v", revoked)
+			}
+		})
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		revoked       bool
+		expiresAt     time.Duration
+		request       models.APIKeyRotateReq
+		wantCode      int
+		wantOldWorks  bool
+		wantExpiresIn time.Duration
+	}{
+		{name: "with grace period", expiresAt: time.Hour, request: models.APIKeyRotateReq{GracePeriod: 10}, wantCode: http.StatusOK, wantOldWorks: true, wantExpiresIn: time.Hour},
+		{name: "without grace period", expiresAt: time.Hour, wantCode: http.StatusOK, wantExpiresIn: time.Hour},
+		{name: "expired key with new expiry", expiresAt: -time.Hour, request: models.APIKeyRotateReq{ExpiresInDays: 1}, wantCode: http.StatusOK, wantExpiresIn: time.Hour * 24},
+		{name: "expired key", expiresAt: -time.Hour, wantCode: http.StatusBadRequest},
+		{name: "revoked key", revoked: true, expiresAt: time.Hour, request: models.APIKeyRotateReq{GracePeriod: 10}, wantCode: http.StatusBadRequest},
+		{name: "grace period too long", expiresAt: time.Hour, request: models.APIKeyRotateReq{GracePeriod: 1441}, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy)
+			key := &models.APIKey{Id: testAPIKeyId, KeyHash: etc.HashToken("old-secret"), Scopes: []string{RoleUser}, ExpiresAt: time.Now().Add(tt.expiresAt)}
+			if tt.revoked {
+				key.RevokedAt = &time.Time{}
+			}
+			apiKeys := &apiKeyStorage{keys: []*models.APIKey{key}}
+			h.apiKeys = apiKeys
+
+			c, recorder := newAdminContext(http.MethodPost, "/v1/rbac/api-keys/"+testAPIKeyId+"/rotate", tt.request, gin.Param{Key: "id", Value: testAPIKeyId})
+			h.RotateAPIKey(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				if key.KeyHash != etc.HashToken("old-secret") {
+					t.Fatal("a rejected rotation replaced the key")
+				}
+				return
+			}
+
+			var rotated models.APIKeySecret
+			if err := json.Unmarshal(recorder.Body.Bytes(), &rotated); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := apiKeys.GetByHash(etc.HashToken(rotated.Key)); err != nil {
+				t.Fatal("the new key is not stored")
+			}
+			if wait := time.Until(key.ExpiresAt); wait < tt.wantExpiresIn-time.Minute || wait > tt.wantExpiresIn+time.Minute {
+				t.Fatalf("the key expires in %s, want %s", wait, tt.wantExpiresIn)
+			}
+			// the middleware accepts the previous key until the grace period ends
+			oldWorks := key.PreviousKeyHash == etc.HashToken("old-secret") && key.PreviousKeyExpiresAt.After(time.Now())
+			if oldWorks != tt.wantOldWorks {
+				t.Fatalf("old key works = %v, want %v", oldWorks, tt.wantOldWorks)
+			}
+		})
+	}
+}
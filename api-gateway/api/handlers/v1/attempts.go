@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/logger"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// failures allowed before an account is locked, an ip address
+	// is shared by many users so it gets more of them
+	accountMaxFailures = 5
+	ipMaxFailures      = 20
+
+	// the first lockout is short, every next one is twice as long
+	lockoutBase = time.Minute
+	lockoutMax  = time.Hour * 24
+
+	// failures and lockouts are forgotten after a quiet day
+	attemptsWindow = time.Hour * 24
+)
+
+// attemptKey is the in-memory storage key counting failures
+// of an account in a login scope ("user", "admin", "verify")
+func attemptKey(scope, account string) string {
+	return "login_attempts:" + scope + ":" + account
+}
+
+func ipAttemptKey(ip string) string {
+	return "login_attempts:ip:" + ip
+}
+
+// lockoutsKey counts how many times the key was locked, lockedKey holds
+// the unix time the current lockout ends at until it ends
+func lockoutsKey(key string) string {
+	return key + ":lockouts"
+}
+
+func lockedKey(key string) string {
+	return key + ":locked_until"
+}
+
+// CheckLockout answers 429 and returns false while the account
+// or the ip address of the request is locked
+func (h *handlerV1) CheckLockout(c *gin.Context, accountKey string) bool {
+	for _, key := range []string{accountKey, ipAttemptKey(c.ClientIP())} {
+		lockedUntil, err := redis.Int64(h.inMemoryStorage.Get(lockedKey(key)))
+		if errors.Is(err, redis.ErrNil) {
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ResponseError{
+				Code:    ErrorCodeInternalServerError,
+				Message: err.Error(),
+			})
+			h.log.Error("cannot get login lockout", logger.Error(err))
+			return false
+		}
+
+		if wait := time.Until(time.Unix(lockedUntil, 0)); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, models.ResponseError{
+				Code:    ErrorCodeTooManyAttempts,
+				Message: "too many failed attempts, try again later",
+			})
+			return false
+		}
+	}
+
+	return true
+}
+
+// LoginFailed counts a failure for the account and the ip address
+// and locks them once they run out of attempts
+func (h *handlerV1) LoginFailed(c *gin.Context, accountKey string) {
+	h.countFailure(accountKey, accountMaxFailures, c.ClientIP())
+	h.countFailure(ipAttemptKey(c.ClientIP()), ipMaxFailures, c.ClientIP())
+}
+
+// LoginSucceeded forgets the failures and lockouts of the account,
+// those of the ip address are kept
+func (h *handlerV1) LoginSucceeded(accountKey string) {
+	if err := h.inMemoryStorage.Del(accountKey, lockoutsKey(accountKey)); err != nil {
+		h.log.Error("cannot reset login attempts", logger.Error(err))
+	}
+}
+
+// countFailure increments the failures atomically, of parallel failures
+// only the one reaching a multiple of maxFailures locks the key
+func (h *handlerV1) countFailure(key string, maxFailures int, ip string) {
+	window := int(attemptsWindow.Seconds())
+
+	failures, err := h.inMemoryStorage.Incr(key, window)
+	if err != nil {
+		h.log.Error("cannot count login attempt", logger.Error(err))
+		return
+	}
+	if failures%int64(maxFailures) != 0 {
+		return
+	}
+
+	lockouts, err := h.inMemoryStorage.Incr(lockoutsKey(key), window)
+	if err != nil {
+		h.log.Error("cannot count login lockout", logger.Error(err))
+		return
+	}
+	lockout := lockoutDuration(lockouts)
+	lockedUntil := time.Now().Add(lockout)
+
+	err = h.inMemoryStorage.SetWithTTL(lockedKey(key), strconv.FormatInt(lockedUntil.Unix(), 10), int(lockout.Seconds())+1)
+	if err != nil {
+		h.log.Error("cannot lock login", logger.Error(err))
+		return
+	}
+
+	h.log.Warn("login locked after failed attempts",
+		logger.String("key", key),
+		logger.String("ip", ip),
+		logger.Int("lockouts", int(lockouts)),
+		logger.String("locked_until", lockedUntil.Format(time.RFC3339)))
+}
+
+// lockoutDuration is lockoutBase doubled for every earlier lockout, at most lockoutMax
+func lockoutDuration(lockouts int64) time.Duration {
+	if lockouts > 16 {
+		return lockoutMax
+	}
+
+	lockout := lockoutBase << (lockouts - 1)
+	if lockout > lockoutMax {
+		return lockoutMax
+	}
+	return lockout
+}
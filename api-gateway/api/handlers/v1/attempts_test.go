@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		lockouts int64
+		want     time.Duration
+	}{
+		{lockouts: 1, want: time.Minute},
+		{lockouts: 2, want: time.Minute * 2},
+		{lockouts: 5, want: time.Minute * 16},
+		{lockouts: 11, want: time.Minute * 1024},
+		{lockouts: 12, want: time.Hour * 24},
+		{lockouts: 64, want: time.Hour * 24},
+	}
+
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.lockouts); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", tt.lockouts, got, tt.want)
+		}
+	}
+}
+
+func TestLoginFailedLocksAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		locked   bool
+	}{
+		{name: "no failures", failures: 0, locked: false},
+		{name: "attempts left", failures: accountMaxFailures - 1, locked: false},
+		{name: "out of attempts", failures: accountMaxFailures, locked: true},
+		{name: "failing on", failures: accountMaxFailures + 1, locked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandler(t)
+			accountKey := attemptKey("user", "user@exam.local")
+
+			for i := 0; i < tt.failures; i++ {
+				c, _ := newTestContext("10.0.0.1")
+				h.LoginFailed(c, accountKey)
+			}
+
+			c, recorder := newTestContext("10.0.0.1")
+			allowed := h.CheckLockout(c, accountKey)
+			if allowed == tt.locked {
+				t.Fatalf("CheckLockout() = %v after %d failures", allowed, tt.failures)
+			}
+			if tt.locked && (recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") == "") {
+				t.Fatalf("locked account answered %d with Retry-After %q", recorder.Code, recorder.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestLoginFailedLocksIP(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	// every failure is for another account, only the ip runs out of attempts
+	for i := 0; i < ipMaxFailures; i++ {
+		c, _ := newTestContext("10.0.0.2")
+		h.LoginFailed(c, attemptKey("user", string(rune('a'+i))))
+	}
+
+	c, _ := newTestContext("10.0.0.2")
+	if h.CheckLockout(c, attemptKey("user", "fresh")) {
+		t.Fatal("ip is not locked")
+	}
+
+	c, _ = newTestContext("10.0.0.3")
+	if !h.CheckLockout(c, attemptKey("user", "fresh")) {
+		t.Fatal("another ip is locked")
+	}
+}
+
+func TestLoginFailedParallel(t *testing.T) {
+	h, storage := newTestHandler(t)
+	accountKey := attemptKey("admin", "admin")
+
+	// parallel failures must not overwrite each other's count
+	var wg sync.WaitGroup
+	for i := 0; i < accountMaxFailures*3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, _ := newTestContext("10.0.0.4")
+			h.LoginFailed(c, accountKey)
+		}()
+	}
+	wg.Wait()
+
+	lockouts, _ := storage.Incr(lockoutsKey(accountKey), 60)
+	if lockouts != 4 {
+		t.Fatalf("account was locked %d times, want 3", lockouts-1)
+	}
+}
+
+func TestLoginSucceededResetsAccount(t *testing.T) {
+	h, storage := newTestHandler(t)
+	accountKey := attemptKey("user", "user@exam.local")
+
+	for i := 0; i < accountMaxFailures-1; i++ {
+		c, _ := newTestContext("10.0.0.5")
+		h.LoginFailed(c, accountKey)
+	}
+	h.LoginSucceeded(accountKey)
+
+	if _, ok := storage.values[accountKey]; ok {
+		t.Fatal("failures are kept after a successful login")
+	}
+	if _, ok := storage.values[ipAttemptKey("10.0.0.5")]; !ok {
+		t.Fatal("failures of the ip are forgotten after a successful login")
+	}
+
+	c, _ := newTestContext("10.0.0.5")
+	h.LoginFailed(c, accountKey)
+	c, _ = newTestContext("10.0.0.5")
+	if !h.CheckLockout(c, accountKey) {
+		t.Fatal("account is locked by failures made before the successful login")
+	}
+}
@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"encoding/json"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	pbu "exam/api-gateway/genproto/user-service"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testDeletedId = "8c4e2a6f-1b3d-4f5e-9a7c-6d0b2e4f8a19"
+
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		wantCode int
+	}{
+		{name: "active user", id: testUserId, wantCode: http.StatusOK},
+		{name: "deleted user", id: testDeletedId, wantCode: http.StatusNotFound},
+		{name: "unknown user", id: "0f1e2d3c-4b5a-4968-8776-a5b4c3d2e1f0", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, services, _ := newUserTestHandler(t)
+			services.users.add(&pbu.User{Id: testDeletedId, Email: "deleted@example.com", DeletedAt: "2026-01-01T00:00:00Z"})
+			sessions := h.sessions.(*sessionStorage)
+			sessions.sessions["session"] = &models.Session{Id: "session", UserId: tt.id}
+
+			c, recorder := newRequestContext(http.MethodDelete, "/v1/user/delete/"+tt.id, nil, testAdminId, RoleAdmin, gin.Param{Key: "id", Value: tt.id})
+			h.DeleteUser(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			// the user is kept to be restored, but cannot be used anymore
+			user, ok := services.users.get(tt.id)
+			if !ok || user.DeletedAt == "" {
+				t.Fatalf("user = %v", user)
+			}
+			if !sessions.sessions["session"].Revoked {
+				t.Fatal("the session of the deleted user is open")
+			}
+		})
+	}
+}
+
+func TestGetAllUsersIncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantCount int64
+	}{
+		{name: "without deleted", wantCode: http.StatusOK, wantCount: 2},
+		{name: "deleted excluded", query: "?include_deleted=false", wantCode: http.StatusOK, wantCount: 2},
+		{name: "deleted included", query: "?include_deleted=true", wantCode: http.StatusOK, wantCount: 3},
+		{name: "not a bool", query: "?include_deleted=maybe", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, services, _ := newUserTestHandler(t)
+			services.users.add(&pbu.User{Id: testDeletedId, Email: "deleted@example.com", DeletedAt: "2026-01-01T00:00:00Z"})
+
+			c, recorder := newRequestContext(http.MethodGet, "/v1/users/1/10"+tt.query, nil, testAdminId, RoleAdmin,
+				gin.Param{Key: "page", Value: "1"}, gin.Param{Key: "limit", Value: "10"})
+			h.GetAllUsers(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var list models.ListUsers
+			if err := json.Unmarshal(recorder.Body.Bytes(), &list); err != nil {
+				t.Fatal(err)
+			}
+			if list.Count != tt.wantCount || len(list.Users) != int(tt.wantCount) {
+				t.Fatalf("listed %d of %d users, want %d", len(list.Users), list.Count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		// email of the deleted user
+		email       string
+		wantCode    int
+		wantRestore bool
+	}{
+		{name: "deleted user", id: testDeletedId, email: "deleted@example.com", wantCode: http.StatusOK, wantRestore: true},
+		{name: "email taken meanwhile", id: testDeletedId, email: "other@example.com", wantCode: http.StatusConflict},
+		{name: "active user", id: testUserId, email: "deleted@example.com", wantCode: http.StatusNotFound},
+		{name: "user that does not exist", id: "0f1e2d3c-4b5a-4968-8776-a5b4c3d2e1f0", email: "deleted@example.com", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, services, _ := newUserTestHandler(t)
+			services.users.add(&pbu.User{Id: testDeletedId, Email: tt.email, DeletedAt: "2026-01-01T00:00:00Z"})
+
+			c, recorder := newRequestContext(http.MethodPost, "/v1/user/restore/"+tt.id, nil, testAdminId, RoleAdmin, gin.Param{Key: "id", Value: tt.id})
+			h.RestoreUser(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+
+			user, _ := services.users.get(testDeletedId)
+			if restored := user.DeletedAt == ""; restored != tt.wantRestore {
+				t.Fatalf("restored = %v, want %v", restored, tt.wantRestore)
+			}
+		})
+	}
+}
+
+// TestDeletedUserEndpointsPolicy checks the shipped policy, the
+// handlers leave it to the middleware who may call them
+func TestDeletedUserEndpointsPolicy(t *testing.T) {
+	policy, err := os.ReadFile("../../../config/auth.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enforcer, _ := newTestEnforcer(t, string(policy))
+
+	requests := []struct{ path, method string }{
+		{path: "/v1/user/delete/" + testUserId, method: http.MethodDelete},
+		{path: "/v1/user/restore/" + testUserId, method: http.MethodPost},
+		{path: "/v1/users/1/10", method: http.MethodGet},
+	}
+	roles := []struct {
+		role    string
+		allowed bool
+	}{
+		{role: casb.RoleUnauthorized},
+		{role: RoleUser},
+		{role: RoleAdmin, allowed: true},
+		{role: RoleSuperAdmin, allowed: true},
+	}
+
+	for _, request := range requests {
+		for _, role := range roles {
+			allowed, err := enforcer.Enforce(role.role, request.path, request.method)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed != role.allowed {
+				t.Errorf("%s %s %s allowed = %v, want %v", role.role, request.method, request.path, allowed, role.allowed)
+			}
+		}
+	}
+}
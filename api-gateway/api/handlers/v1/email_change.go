@@ -0,0 +1,267 @@
+package v1
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/email"
+	pb "exam/api-gateway/genproto/user-service"
+	"exam/api-gateway/pkg/etc"
+	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/pkg/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	emailChangeTimeout     = time.Minute * 15
+	emailChangeCodeLength  = 6
+	emailChangeMaxAttempts = 5
+)
+
+func emailChangeKey(userId string) string {
+	return "email_change:" + userId
+}
+
+// Change email
+// @Router /v1/user/email/change [post]
+// @Security BearerAuth
+// @Summary change email
+// @Tags User
+// @Description Send a verification code to the new email, it replaces the current one after /v1/user/email/verify
+// @Accept json
+// @Produce json
+// @Param email body models.ChangeEmailReq true "email"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string Error models.ResponseError
+// @Failure 401 string Error models.ResponseError
+// @Failure 409 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) ChangeEmail(c *gin.Context) {
+	var body models.ChangeEmailReq
+
+	userId, ok := h.GetUserId(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+	body.NewEmail = strings.ToLower(strings.TrimSpace(body.NewEmail))
+
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	if !h.isEmailFree(ctx, c, body.NewEmail) {
+		return
+	}
+
+	code, err := utils.GenerateSecureCode(emailChangeCodeLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot generate email change code", logger.Error(err))
+		return
+	}
+
+	change := models.EmailChangeModel{
+		NewEmail:  body.NewEmail,
+		CodeHash:  etc.HashToken(code),
+		ExpiresAt: time.Now().Add(emailChangeTimeout),
+	}
+	if err := h.saveUntil(emailChangeKey(userId), change, change.ExpiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot set with ttl", logger.Error(err))
+		return
+	}
+
+	// the code goes to the new email, in the language of the user
+	user, err := h.serviceManager.UserService().GetUserById(ctx, &pb.GetUserId{UserId: userId})
+	if err != nil {
+		h.log.Error("cannot get user", logger.Error(err))
+		user = &pb.User{}
+	}
+
+	err = email.SendVerificationCode(h.mailer, email.Params{
+		To:       change.NewEmail,
+		Locale:   user.Locale,
+		Purpose:  email.PurposeEmailChange,
+		Code:     code,
+		UserName: user.FirstName,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot send a code to an email", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "a verification code was sent to the new email, please check it.",
+	})
+}
+
+// Verify email change
+// @Router /v1/user/email/verify [post]
+// @Security BearerAuth
+// @Summary verify email change
+// @Tags User
+// @Description Replace the email of the user with the new one the code was sent to
+// @Accept json
+// @Produce json
+// @Param code body models.VerifyEmailChangeReq true "code"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string Error models.ResponseError
+// @Failure 401 string Error models.ResponseError
+// @Failure 409 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) VerifyEmailChange(c *gin.Context) {
+	var body models.VerifyEmailChangeReq
+
+	userId, ok := h.GetUserId(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	changeJson, err := redis.Bytes(h.inMemoryStorage.Get(emailChangeKey(userId)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "code is expired, try again.",
+		})
+		return
+	}
+
+	var change models.EmailChangeModel
+	if err := json.Unmarshal(changeJson, &change); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot unmarshal email change from redis", logger.Error(err))
+		return
+	}
+
+	if change.Attempts >= emailChangeMaxAttempts || time.Now().After(change.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "code is expired, try again.",
+		})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(etc.HashToken(body.Code)), []byte(change.CodeHash)) != 1 {
+		change.Attempts++
+		if err := h.saveUntil(emailChangeKey(userId), change, change.ExpiresAt); err != nil {
+			h.log.Error("cannot count email change attempt", logger.Error(err))
+		}
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "code is incorrect, try again.",
+		})
+		return
+	}
+
+	// the email is changed by the gateway once the code proved the new address
+	ctx, cancel := context.WithTimeout(h.gatewayContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	// the email could have been taken while the code was on its way
+	if !h.isEmailFree(ctx, c, change.NewEmail) {
+		return
+	}
+
+	status, err := h.serviceManager.UserService().UpdateEmail(ctx, &pb.UpdateEmailReq{
+		UserId: userId,
+		Email:  change.NewEmail,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot update email", logger.Error(err))
+		return
+	}
+	if !status.Success {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "user not found",
+		})
+		return
+	}
+
+	// the code is one-time, burn it
+	change.Attempts = emailChangeMaxAttempts
+	if err := h.saveUntil(emailChangeKey(userId), change, change.ExpiresAt); err != nil {
+		h.log.Error("cannot burn email change code", logger.Error(err))
+	}
+
+	h.log.Info("email changed", logger.String("user_id", userId))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "email was changed",
+	})
+}
+
+// isEmailFree writes a conflict response if the email is already used by someone
+func (h *handlerV1) isEmailFree(ctx context.Context, c *gin.Context, email string) bool {
+	exists, err := h.serviceManager.UserService().CheckField(ctx, &pb.CheckFieldRequest{
+		Field: "email",
+		Data:  email,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to check email uniqueness", logger.Error(err))
+		return false
+	}
+	if exists.Status {
+		c.JSON(http.StatusConflict, models.ResponseError{
+			Code:    ErrorCodeAlreadyExists,
+			Message: "This email is already in use. Try another email.",
+		})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	pbu "exam/api-gateway/genproto/user-service"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+var mailedCodePattern = regexp.MustCompile(`: (\d+)\n`)
+
+// mailedCode returns the code of the last message sent to the address
+func mailedCode(t *testing.T, mails *mailbox, address string) string {
+	t.Helper()
+
+	messages := mails.to(address)
+	if len(messages) == 0 {
+		t.Fatalf("nothing was sent to %s", address)
+	}
+	match := mailedCodePattern.FindStringSubmatch(messages[len(messages)-1].Text)
+	if match == nil {
+		t.Fatalf("no code in %q", messages[len(messages)-1].Text)
+	}
+	return match[1]
+}
+
+func TestChangeEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		sub      string
+		newEmail string
+		wantCode int
+		wantSent bool
+	}{
+		{name: "free email", sub: testUserId, newEmail: " New@Example.com ", wantCode: http.StatusOK, wantSent: true},
+		{name: "taken email", sub: testUserId, newEmail: "other@example.com", wantCode: http.StatusConflict},
+		{name: "invalid email", sub: testUserId, newEmail: "not-an-email", wantCode: http.StatusBadRequest},
+		{name: "anonymous", newEmail: "new@example.com", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, services, mails := newUserTestHandler(t)
+
+			c, recorder := newRequestContext(http.MethodPost, "/v1/user/email/change", models.ChangeEmailReq{NewEmail: tt.newEmail}, tt.sub, RoleUser)
+			h.ChangeEmail(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if sent := len(mails.to("new@example.com")) != 0; sent != tt.wantSent {
+				t.Fatalf("sent = %v, want %v", sent, tt.wantSent)
+			}
+			// the email is not changed before it is verified
+			if user, _ := services.users.get(testUserId); user.Email != testUserEmail {
+				t.Fatalf("email = %q before verification", user.Email)
+			}
+		})
+	}
+}
+
+func TestVerifyEmailChange(t *testing.T) {
+	const newEmail = "new@example.com"
+
+	tests := []struct {
+		name string
+		// before runs between the request of the change and its verification
+		before    func(services *serviceManager)
+		failures  int
+		wrongCode bool
+		replay    bool
+		wantCode  int
+		wantEmail string
+	}{
+		{name: "mailed code", wantCode: http.StatusOK, wantEmail: newEmail},
+		{name: "wrong code", wrongCode: true, wantCode: http.StatusBadRequest, wantEmail: testUserEmail},
+		{name: "attempts left", failures: emailChangeMaxAttempts - 1, wantCode: http.StatusOK, wantEmail: newEmail},
+		{name: "out of attempts", failures: emailChangeMaxAttempts, wantCode: http.StatusBadRequest, wantEmail: testUserEmail},
+		{name: "code used twice", replay: true, wantCode: http.StatusBadRequest, wantEmail: newEmail},
+		{
+			name: "email taken meanwhile",
+			before: func(services *serviceManager) {
+				services.users.add(&pbu.User{Id: "5e8d2c71-0b4a-4f3e-9d6c-2a1b0f9e8d73", Email: newEmail})
+			},
+			wantCode:  http.StatusConflict,
+			wantEmail: testUserEmail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, services, mails := newUserTestHandler(t)
+			verify := func(code string) int {
+				c, recorder := newRequestContext(http.MethodPost, "/v1/user/email/verify", models.VerifyEmailChangeReq{Code: code}, testUserId, RoleUser)
+				h.VerifyEmailChange(c)
+				return recorder.Code
+			}
+
+			c, recorder := newRequestContext(http.MethodPost, "/v1/user/email/change", models.ChangeEmailReq{NewEmail: newEmail}, testUserId, RoleUser)
+			h.ChangeEmail(c)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("change answered %d: %s", recorder.Code, recorder.Body)
+			}
+			code := mailedCode(t, mails, newEmail)
+			if tt.before != nil {
+				tt.before(services)
+			}
+
+			wrong := "000000"
+			if code == wrong {
+				wrong = "111111"
+			}
+			for i := 0; i < tt.failures; i++ {
+				if got := verify(wrong); got != http.StatusBadRequest {
+					t.Fatalf("failure %d answered %d", i+1, got)
+				}
+			}
+			if tt.wrongCode {
+				code = wrong
+			}
+			if tt.replay {
+				if got := verify(code); got != http.StatusOK {
+					t.Fatalf("first verification answered %d", got)
+				}
+			}
+
+			if got := verify(code); got != tt.wantCode {
+				t.Fatalf("code = %d, want %d", got, tt.wantCode)
+			}
+			if user, _ := services.users.get(testUserId); user.Email != tt.wantEmail {
+				t.Fatalf("email = %q, want %q", user.Email, tt.wantEmail)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package v1
+
+import (
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/logger"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RoleSourceRequest    = "request"
+	RoleSourceAssignment = "assignment"
+)
+
+// casbin keeps the matcher with r.sub escaped as r_sub
+var escapedAssertion = regexp.MustCompile(`\b([rp])_(\w+)`)
+
+// Explain authorization decision
+// @Router /v1/rbac/check [post]
+// @Security BearerAuth
+// @Summary explain authorization decision
+// @Tags Role-management
+// @Description Tell whether the role, or the role assigned to the subject, may call the path with the method,
+// @Description which policy allowed it, which lines of the matcher in auth.conf matched and which roles were consulted
+// @Accept json
+// @Produce json
+// @Param request body models.AuthCheckReq true "request"
+// @Success 200 {object} models.AuthCheckResp
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) CheckAuthorization(c *gin.Context) {
+	var body models.AuthCheckReq
+
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	// the middleware enforces the path without the query
+	path, err := url.Parse(body.Path)
+	if err != nil || !strings.HasPrefix(path.Path, "/") || body.Method == "" {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "an absolute path and a method are required",
+		})
+		return
+	}
+	method := strings.ToUpper(body.Method)
+
+	response := models.AuthCheckResp{
+		Role:       body.Role,
+		RoleSource: RoleSourceRequest,
+		Matches:    []*models.AuthCheckMatch{},
+	}
+	if body.Subject != "" {
+		if role := h.subjectRole(body.Subject, ""); role != "" {
+			response.Role = role
+			response.RoleSource = RoleSourceAssignment
+		}
+	}
+	if response.Role == "" {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "no role is assigned to the subject, give the role of its account",
+		})
+		return
+	}
+	if _, ok := h.getRole(c, response.Role); !ok {
+		return
+	}
+
+	response.RoleChain, err = h.casbin.GetImplicitRolesForUser(response.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot get implicit roles", logger.Error(err))
+		return
+	}
+	response.RoleChain = append([]string{response.Role}, response.RoleChain...)
+
+	allowed, explain, err := h.casbin.EnforceEx(response.Role, path.Path, method)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot enforce", logger.Error(err))
+		return
+	}
+	response.Allowed = allowed
+
+	// every line of the matcher is enforced on its own to tell which ones allow the request
+	for _, matcher := range strings.Split(h.casbin.GetModel()["m"]["m"].Value, "||") {
+		matcher = strings.TrimSpace(matcher)
+		allowed, rule, err := h.casbin.EnforceExWithMatcher(matcher, response.Role, path.Path, method)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			h.log.Error("cannot enforce with matcher", logger.Error(err))
+			return
+		}
+		if !allowed {
+			continue
+		}
+
+		match := models.AuthCheckMatch{
+			Matcher: escapedAssertion.ReplaceAllString(matcher, "$1.$2"),
+		}
+		// a line that does not look at the policy, like the superadmin one,
+		// is satisfied by whatever policy casbin tried first
+		if len(rule) > 0 && strings.Contains(matcher, "p_") {
+			explained := casb.NewRule("p", rule)
+			match.Rule = &explained
+		}
+		response.Matches = append(response.Matches, &match)
+
+		if match.Rule != nil && (response.Rule == nil || strings.Join(rule, ",") == strings.Join(explain, ",")) {
+			response.Rule = match.Rule
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
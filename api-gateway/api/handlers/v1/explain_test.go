@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"encoding/json"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCheckAuthorization(t *testing.T) {
+	userRule := &models.CasbinRule{PType: "p", V0: RoleUser, V1: "/v1/user/{id}", V2: "GET"}
+
+	tests := []struct {
+		name           string
+		request        models.AuthCheckReq
+		wantCode       int
+		wantAllowed    bool
+		wantRole       string
+		wantRoleSource string
+		wantChain      []string
+		wantRule       *models.CasbinRule
+	}{
+		{
+			name:           "allowed by the policy of the role",
+			request:        models.AuthCheckReq{Role: RoleUser, Path: "/v1/user/42", Method: "get"},
+			wantCode:       http.StatusOK,
+			wantAllowed:    true,
+			wantRole:       RoleUser,
+			wantRoleSource: RoleSourceRequest,
+			wantChain:      []string{RoleUser, casb.RoleUnauthorized},
+			wantRule:       userRule,
+		},
+		{
+			name:           "allowed by an inherited policy",
+			request:        models.AuthCheckReq{Role: RoleAdmin, Path: "/v1/user/42?fields=email", Method: "GET"},
+			wantCode:       http.StatusOK,
+			wantAllowed:    true,
+			wantRole:       RoleAdmin,
+			wantRoleSource: RoleSourceRequest,
+			wantChain:      []string{RoleAdmin, RoleUser, casb.RoleUnauthorized},
+			wantRule:       userRule,
+		},
+		{
+			name:           "denied",
+			request:        models.AuthCheckReq{Role: RoleUser, Path: "/v1/users", Method: "GET"},
+			wantCode:       http.StatusOK,
+			wantRole:       RoleUser,
+			wantRoleSource: RoleSourceRequest,
+			wantChain:      []string{RoleUser, casb.RoleUnauthorized},
+		},
+		{
+			name:           "role assigned to the subject",
+			request:        models.AuthCheckReq{Subject: testUserId, Role: RoleUser, Path: "/v1/users", Method: "GET"},
+			wantCode:       http.StatusOK,
+			wantAllowed:    true,
+			wantRole:       RoleAdmin,
+			wantRoleSource: RoleSourceAssignment,
+			wantChain:      []string{RoleAdmin, RoleUser, casb.RoleUnauthorized},
+			wantRule:       &models.CasbinRule{PType: "p", V0: RoleAdmin, V1: "/v1/users", V2: "GET"},
+		},
+		{
+			name:           "superadmin without a policy",
+			request:        models.AuthCheckReq{Role: RoleSuperAdmin, Path: "/v1/rbac/roles", Method: "POST"},
+			wantCode:       http.StatusOK,
+			wantAllowed:    true,
+			wantRole:       RoleSuperAdmin,
+			wantRoleSource: RoleSourceRequest,
+			wantChain:      []string{RoleSuperAdmin, RoleAdmin, RoleUser, casb.RoleUnauthorized},
+		},
+		{name: "subject without a role", request: models.AuthCheckReq{Subject: testOtherId, Path: "/v1/users", Method: "GET"}, wantCode: http.StatusBadRequest},
+		{name: "unknown role", request: models.AuthCheckReq{Role: "support", Path: "/v1/users", Method: "GET"}, wantCode: http.StatusNotFound},
+		{name: "relative path", request: models.AuthCheckReq{Role: RoleUser, Path: "v1/users", Method: "GET"}, wantCode: http.StatusBadRequest},
+		{name: "no method", request: models.AuthCheckReq{Role: RoleUser, Path: "/v1/users"}, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy+"g, sub:"+testUserId+", admin\n")
+
+			c, recorder := newAdminContext(http.MethodPost, "/v1/rbac/check", tt.request)
+			h.CheckAuthorization(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var response models.AuthCheckResp
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Allowed != tt.wantAllowed || response.Role != tt.wantRole || response.RoleSource != tt.wantRoleSource {
+				t.Fatalf("allowed %v for %s from %s, want %v for %s from %s",
+					response.Allowed, response.Role, response.RoleSource, tt.wantAllowed, tt.wantRole, tt.wantRoleSource)
+			}
+			if !reflect.DeepEqual(response.RoleChain, tt.wantChain) {
+				t.Fatalf("role chain = %v, want %v", response.RoleChain, tt.wantChain)
+			}
+			if !reflect.DeepEqual(response.Rule, tt.wantRule) {
+				t.Fatalf("rule = %+v, want %+v", response.Rule, tt.wantRule)
+			}
+			// a decision is allowed exactly when a line of the matcher allows it
+			if (len(response.Matches) != 0) != tt.wantAllowed {
+				t.Fatalf("matches = %d", len(response.Matches))
+			}
+		})
+	}
+}
+
+func TestCheckAuthorizationOfNotSuperAdmin(t *testing.T) {
+	h, _ := newRBACTestHandler(t, testRBACPolicy)
+
+	c, recorder := newRequestContext(http.MethodPost, "/v1/rbac/check", models.AuthCheckReq{Role: RoleUser, Path: "/v1/users", Method: "GET"}, testAdminId, RoleAdmin)
+	h.CheckAuthorization(c)
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("code = %d, want %d: %s", recorder.Code, http.StatusForbidden, recorder.Body)
+	}
+}
@@ -1,37 +1,71 @@
 package v1
 
 import (
+	"context"
+	"encoding/json"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
 	t "exam/api-gateway/api/handlers/v1/tokens"
 	"exam/api-gateway/config"
+	"exam/api-gateway/email"
 	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/pkg/oidc"
 	"exam/api-gateway/services"
 	admin "exam/api-gateway/storage/postgresrepo"
 	"exam/api-gateway/storage/repo"
+	"exam/pkg/auth"
+	"net/http"
+	"time"
 
 	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
 )
 
 const (
-	ErrorCodeInvalidURL          = "INVALID_URL"
-	ErrorCodeInvalidJSON         = "INVALID_JSON"
-	ErrorCodeInvalidParams       = "INVALID_PARAMS"
-	ErrorCodeInternalServerError = "INTERNAL_SERVER_ERROR"
-	ErrorCodeUnauthorized        = "UNAUTHORIZED"
-	ErrorCodeAlreadyExists       = "ALREADY_EXISTS"
-	ErrorCodeNotFound            = "NOT_FOUND"
-	ErrorCodeInvalidCode         = "INVALID_CODE"
-	ErrorBadRequest              = "BAD_REQUEST"
-	ErrorInvalidCredentials      = "INVALID_CREDENTIALS"
+	ErrorCodeInvalidURL             = "INVALID_URL"
+	ErrorCodeInvalidJSON            = "INVALID_JSON"
+	ErrorCodeInvalidParams          = "INVALID_PARAMS"
+	ErrorCodeInternalServerError    = "INTERNAL_SERVER_ERROR"
+	ErrorCodeUnauthorized           = "UNAUTHORIZED"
+	ErrorCodeAlreadyExists          = "ALREADY_EXISTS"
+	ErrorCodeNotFound               = "NOT_FOUND"
+	ErrorCodeInvalidCode            = "INVALID_CODE"
+	ErrorBadRequest                 = "BAD_REQUEST"
+	ErrorInvalidCredentials         = "INVALID_CREDENTIALS"
+	ErrorCodePermissionDenied       = "PERMISSION_DENIED"
+	ErrorCodeTooManyAttempts        = "TOO_MANY_ATTEMPTS"
+	ErrorCodeAccountDisabled        = "ACCOUNT_DISABLED"
+	ErrorCodePasswordChangeRequired = "PASSWORD_CHANGE_REQUIRED"
 )
 
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "superadmin"
+)
+
+// isAdminRole tells the roles an account in the admins table can have
+func isAdminRole(role string) bool {
+	return role == RoleAdmin || role == RoleSuperAdmin
+}
+
 type handlerV1 struct {
 	inMemoryStorage repo.InMemoryStorageI
 	log             logger.Logger
 	serviceManager  services.IServiceManager
 	cfg             config.Config
 	jwtHandler      t.JWTHandler
+	jwtKeys         *t.Keys
 	postgres        admin.AdminStorageI
-	casbin          *casbin.Enforcer
+	sessions        admin.SessionStorageI
+	mfa             admin.MFAStorageI
+	roles           admin.RoleStorageI
+	policies        admin.PolicyStorageI
+	apiKeys         admin.APIKeyStorageI
+	oidcProviders   map[string]oidc.Provider
+	routes          func() gin.RoutesInfo
+	mailer          email.Mailer
+	casbin          *casbin.SyncedEnforcer
 }
 
 type HandlerV1Config struct {
@@ -40,8 +74,19 @@ type HandlerV1Config struct {
 	ServiceManager  services.IServiceManager
 	Cfg             config.Config
 	JWTHandler      t.JWTHandler
+	JWTKeys         *t.Keys
 	Postgres        admin.AdminStorageI
-	Casbin          *casbin.Enforcer
+	Sessions        admin.SessionStorageI
+	MFA             admin.MFAStorageI
+	Roles           admin.RoleStorageI
+	Policies        admin.PolicyStorageI
+	APIKeys         admin.APIKeyStorageI
+	// OIDCProviders are the identity providers users can log in with by name
+	OIDCProviders map[string]oidc.Provider
+	// Routes lists the routes of the engine, policies are validated against them
+	Routes func() gin.RoutesInfo
+	Mailer email.Mailer
+	Casbin *casbin.SyncedEnforcer
 }
 
 func New(c *HandlerV1Config) *handlerV1 {
@@ -51,8 +96,127 @@ func New(c *HandlerV1Config) *handlerV1 {
 		serviceManager:  c.ServiceManager,
 		cfg:             c.Cfg,
 		jwtHandler:      c.JWTHandler,
+		jwtKeys:         c.JWTKeys,
 		postgres:        c.Postgres,
+		sessions:        c.Sessions,
+		mfa:             c.MFA,
+		roles:           c.Roles,
+		policies:        c.Policies,
+		apiKeys:         c.APIKeys,
+		oidcProviders:   c.OIDCProviders,
+		routes:          c.Routes,
+		mailer:          c.Mailer,
 		casbin:          c.Casbin,
 	}
 
 }
+
+// GetSuperAdmin returns the id of the superadmin calling the endpoint,
+// role and sub are taken from the access token by the auth middleware
+func (h *handlerV1) GetSuperAdmin(c *gin.Context) (string, bool) {
+	if c.GetString("role") != RoleSuperAdmin || c.GetString("sub") == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ResponseError{
+			Code:    ErrorCodePermissionDenied,
+			Message: "only superadmin can access this endpoint",
+		})
+		return "", false
+	}
+
+	return c.GetString("sub"), true
+}
+
+// GetUserId returns the subject of the access token the request was made with
+func (h *handlerV1) GetUserId(c *gin.Context) (string, bool) {
+	sub := c.GetString("sub")
+	if sub == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "access token is required",
+		})
+		return "", false
+	}
+
+	return sub, true
+}
+
+// CheckOwner answers 403 and returns false unless the access token belongs
+// to the user the data is of, admin roles and the roles inheriting
+// from them may access the data of anybody
+func (h *handlerV1) CheckOwner(c *gin.Context, userId string) bool {
+	sub, ok := h.GetUserId(c)
+	if !ok {
+		return false
+	}
+	if sub == userId || h.isAdminOverride(c.GetString("role")) {
+		return true
+	}
+
+	c.AbortWithStatusJSON(http.StatusForbidden, models.ResponseError{
+		Code:    ErrorCodePermissionDenied,
+		Message: "you can only access your own data",
+	})
+	return false
+}
+
+// isAdminOverride tells whether the role is an admin role or inherits from one
+func (h *handlerV1) isAdminOverride(role string) bool {
+	if isAdminRole(role) {
+		return true
+	}
+
+	parents, err := h.casbin.GetImplicitRolesForUser(role)
+	if err != nil {
+		h.log.Error("cannot get implicit roles", logger.Error(err))
+		return false
+	}
+	for _, parent := range parents {
+		if isAdminRole(parent) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// saveUntil stores value as json in the in-memory storage until expiresAt
+func (h *handlerV1) saveUntil(key string, value interface{}, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	valueJson, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return h.inMemoryStorage.SetWithTTL(key, string(valueJson), int(ttl.Seconds())+1)
+}
+
+// rpcContext carries the caller to the grpc services, the service manager
+// signs it for every service, which checks it against the roles each rpc
+// needs. A nil gin context stands for the gateway calling on its own behalf
+// outside of a request
+func (h *handlerV1) rpcContext(c *gin.Context) context.Context {
+	if c == nil {
+		return auth.WithCaller(context.Background(), "", []string{auth.RoleGateway})
+	}
+
+	role := c.GetString("role")
+	if role == "" {
+		role = casb.RoleUnauthorized
+	}
+
+	parents, err := h.casbin.GetImplicitRolesForUser(role)
+	if err != nil {
+		h.log.Error("cannot get implicit roles", logger.Error(err))
+	}
+
+	return auth.WithCaller(c.Request.Context(), c.GetString("sub"), append([]string{role}, parents...))
+}
+
+// gatewayContext calls the services on the gateway's own behalf for the
+// request, the rpcs handling credentials are only open to the gateway
+func (h *handlerV1) gatewayContext(c *gin.Context) context.Context {
+	return auth.WithCaller(c.Request.Context(), "", []string{auth.RoleGateway})
+}
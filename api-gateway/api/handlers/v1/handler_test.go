@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"exam/api-gateway/config"
+	"exam/api-gateway/email"
+	"exam/api-gateway/pkg/logger"
+	"fmt"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gomodule/redigo/redis"
+)
+
+// memoryStorage is the in-memory storage of the tests, values are returned
+// as []byte and missing keys as redis.ErrNil like redis does
+type memoryStorage struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		values:  map[string]string{},
+		expires: map[string]time.Time{},
+	}
+}
+
+func (m *memoryStorage) Set(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[key] = value
+	delete(m.expires, key)
+	return nil
+}
+
+func (m *memoryStorage) SetWithTTL(key, value string, seconds int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[key] = value
+	m.expires[key] = time.Now().Add(time.Duration(seconds) * time.Second)
+	return nil
+}
+
+func (m *memoryStorage) Get(key string) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.get(key)
+	if !ok {
+		return nil, redis.ErrNil
+	}
+	return []byte(value), nil
+}
+
+func (m *memoryStorage) GetDel(key string) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.get(key)
+	if !ok {
+		return nil, redis.ErrNil
+	}
+	delete(m.values, key)
+	delete(m.expires, key)
+	return []byte(value), nil
+}
+
+func (m *memoryStorage) Incr(key string, seconds int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, _ := m.get(key)
+	count, _ := strconv.ParseInt(value, 10, 64)
+	count++
+
+	m.values[key] = strconv.FormatInt(count, 10)
+	m.expires[key] = time.Now().Add(time.Duration(seconds) * time.Second)
+	return count, nil
+}
+
+func (m *memoryStorage) Del(keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.values, key)
+		delete(m.expires, key)
+	}
+	return nil
+}
+
+func (m *memoryStorage) get(key string) (string, bool) {
+	if expiresAt, ok := m.expires[key]; ok && time.Now().After(expiresAt) {
+		delete(m.values, key)
+		delete(m.expires, key)
+	}
+
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// mailbox keeps the messages the handlers send
+type mailbox struct {
+	mu       sync.Mutex
+	messages []email.Message
+}
+
+func (m *mailbox) Send(message email.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// to returns the messages sent to the address
+func (m *mailbox) to(address string) []email.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var messages []email.Message
+	for _, message := range m.messages {
+		if message.To == address {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+func newTestHandler(t *testing.T) (*handlerV1, *memoryStorage) {
+	t.Helper()
+
+	storage := newMemoryStorage()
+	return New(&HandlerV1Config{
+		InMemoryStorage: storage,
+		Log:             logger.New("error", "test"),
+		Cfg: config.Config{
+			AccessTokenTimeout:  15,
+			RefreshTokenTimeout: 720,
+			CtxTimeOut:          7,
+		},
+	}), storage
+}
+
+// newTestContext is a gin context of a request from ip
+func newTestContext(ip string) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest("POST", "/", nil)
+	c.Request.RemoteAddr = fmt.Sprintf("%s:1234", ip)
+	return c, recorder
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Get public keys the tokens are verified with
+// @Router /.well-known/jwks.json [get]
+// @Summary get json web key set
+// @Tags Auth
+// @Description Public keys of the gateway, empty while tokens are signed with HS256
+// @Accept json
+// @Produce json
+// @Success 200 {object} tokens.JWKS
+func (h *handlerV1) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtKeys.JWKS())
+}
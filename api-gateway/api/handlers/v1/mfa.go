@@ -0,0 +1,566 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/api/handlers/v1/tokens"
+	"exam/api-gateway/pkg/etc"
+	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/pkg/totp"
+	"exam/api-gateway/pkg/utils"
+	admin "exam/api-gateway/storage/postgresrepo"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	mfaMaxAttempts     = 5
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+func mfaAttemptsKey(jti string) string {
+	return "mfa_attempts:" + jti
+}
+
+// mfaAccountKey holds the attempt key of the account that got the mfa token
+func mfaAccountKey(jti string) string {
+	return "mfa_account:" + jti
+}
+
+// RequireMFA is called by the login handlers once the password is checked,
+// when the second factor is needed it answers with an mfa token and returns true.
+// The failures of the second factor are counted for accountKey, the login
+// handlers forget its failures only once RequireMFA returned false
+func (h *handlerV1) RequireMFA(c *gin.Context, accountKey, sub, role string) bool {
+	enrolment, err := h.mfa.Get(sub)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get mfa enrolment", logger.Error(err))
+		return true
+	}
+	enabled := enrolment != nil && enrolment.Enabled
+
+	required, err := h.mfaRequired(role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot check mfa policy", logger.Error(err))
+		return true
+	}
+
+	if !enabled && !required {
+		return false
+	}
+
+	jwtHandler := tokens.JWTHandler{
+		Sub:  sub,
+		Role: role,
+		Keys: h.jwtKeys,
+		Log:  h.log,
+	}
+	mfaToken, jti, err := jwtHandler.GenerateMFAJWT()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot create mfa token", logger.Error(err))
+		return true
+	}
+
+	err = h.inMemoryStorage.SetWithTTL(mfaAccountKey(jti), accountKey, int(tokens.MFATokenLifetime.Seconds())+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot save mfa account", logger.Error(err))
+		return true
+	}
+
+	c.JSON(http.StatusOK, models.MFARequiredResp{
+		MFARequired:   true,
+		SetupRequired: !enabled,
+		MFAToken:      mfaToken,
+	})
+	return true
+}
+
+// Start 2FA enrolment
+// @Router /v1/mfa/setup [post]
+// @Security BearerAuth
+// @Summary setup two-factor authentication
+// @Tags MFA
+// @Description Create a TOTP secret, send the access token or, when login asked for setup, the mfa token
+// @Accept json
+// @Produce json
+// @Param mfa-token body models.MFATokenReq false "mfa token"
+// @Success 200 {object} models.MFASetupResp
+// @Failure 401 string Error models.ResponseError
+// @Failure 409 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) SetupMFA(c *gin.Context) {
+	var body models.MFATokenReq
+
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	claims, _, ok := h.mfaClaims(c, body.MFAToken)
+	if !ok {
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot generate totp secret", logger.Error(err))
+		return
+	}
+
+	err = h.mfa.Save(&models.MFA{
+		Subject: claims.Subject,
+		Secret:  secret,
+	})
+	if errors.Is(err, admin.ErrMFAEnabled) {
+		c.JSON(http.StatusConflict, models.ResponseError{
+			Code:    ErrorCodeAlreadyExists,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot save mfa enrolment", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MFASetupResp{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(h.cfg.MFAIssuer, claims.Subject, secret),
+	})
+}
+
+// Activate 2FA
+// @Router /v1/mfa/activate [post]
+// @Security BearerAuth
+// @Summary activate two-factor authentication
+// @Tags MFA
+// @Description Confirm the enrolment with a code of the authenticator app, recovery codes are shown only once. Activating with the mfa token also logs in
+// @Accept json
+// @Produce json
+// @Param code body models.MFAActivateReq true "code"
+// @Success 200 {object} models.MFAActivateResp
+// @Failure 400 string Error models.ResponseError
+// @Failure 401 string Error models.ResponseError
+// @Failure 409 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) ActivateMFA(c *gin.Context) {
+	var body models.MFAActivateReq
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	claims, pending, ok := h.mfaClaims(c, body.MFAToken)
+	if !ok {
+		return
+	}
+
+	enrolment, err := h.mfa.Get(claims.Subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "two-factor authentication is not set up",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get mfa enrolment", logger.Error(err))
+		return
+	}
+	if enrolment.Enabled {
+		c.JSON(http.StatusConflict, models.ResponseError{
+			Code:    ErrorCodeAlreadyExists,
+			Message: admin.ErrMFAEnabled.Error(),
+		})
+		return
+	}
+
+	if !h.checkSecondFactor(c, claims, enrolment, body.Code, "") {
+		return
+	}
+
+	recoveryCodes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot generate recovery codes", logger.Error(err))
+		return
+	}
+
+	if err := h.mfa.Enable(claims.Subject, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot enable mfa", logger.Error(err))
+		return
+	}
+
+	h.log.Info("mfa enabled",
+		logger.String("subject", claims.Subject),
+		logger.String("role", claims.Role))
+
+	response := models.MFAActivateResp{
+		RecoveryCodes: recoveryCodes,
+	}
+	if pending {
+		h.burnMFAToken(claims)
+		response.AccessToken, response.RefreshToken, err = h.NewSession(c, claims.Subject, claims.Role)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ResponseError{
+				Code:    ErrorCodeInternalServerError,
+				Message: err.Error(),
+			})
+			h.log.Error("cannot create access and refresh token", logger.Error(err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Verify the second factor
+// @Router /v1/mfa/verify [post]
+// @Summary verify two-factor authentication
+// @Tags MFA
+// @Description Exchange the mfa token returned by login and a code of the authenticator app or a recovery code for access and refresh tokens
+// @Accept json
+// @Produce json
+// @Param code body models.MFAVerifyReq true "code"
+// @Success 200 {object} models.MFAVerifyResp
+// @Failure 400 string Error models.ResponseError
+// @Failure 401 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) VerifyMFA(c *gin.Context) {
+	var body models.MFAVerifyReq
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	claims, err := tokens.ExtractClaim(body.MFAToken, h.jwtKeys, tokens.TypeMFA)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "mfa token is invalid or expired, login again",
+		})
+		return
+	}
+
+	enrolment, err := h.mfa.Get(claims.Subject)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get mfa enrolment", logger.Error(err))
+		return
+	}
+	if enrolment == nil || !enrolment.Enabled {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "two-factor authentication is not set up",
+		})
+		return
+	}
+
+	if !h.checkSecondFactor(c, claims, enrolment, body.Code, body.RecoveryCode) {
+		return
+	}
+	h.burnMFAToken(claims)
+
+	access, refresh, err := h.NewSession(c, claims.Subject, claims.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot create access and refresh token", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MFAVerifyResp{
+		AccessToken:  access,
+		RefreshToken: refresh,
+	})
+}
+
+// Disable 2FA
+// @Router /v1/mfa/disable [post]
+// @Security BearerAuth
+// @Summary disable two-factor authentication
+// @Tags MFA
+// @Description Turn two-factor authentication off, not allowed for roles it is required for
+// @Accept json
+// @Produce json
+// @Param code body models.MFADisableReq true "code"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string Error models.ResponseError
+// @Failure 401 string Error models.ResponseError
+// @Failure 403 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) DisableMFA(c *gin.Context) {
+	var body models.MFADisableReq
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	claims, _, ok := h.mfaClaims(c, "")
+	if !ok {
+		return
+	}
+
+	required, err := h.mfaRequired(claims.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot check mfa policy", logger.Error(err))
+		return
+	}
+	if required {
+		c.JSON(http.StatusForbidden, models.ResponseError{
+			Code:    ErrorCodePermissionDenied,
+			Message: "two-factor authentication is required for your role",
+		})
+		return
+	}
+
+	enrolment, err := h.mfa.Get(claims.Subject)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get mfa enrolment", logger.Error(err))
+		return
+	}
+	if enrolment == nil || !enrolment.Enabled {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "two-factor authentication is not set up",
+		})
+		return
+	}
+
+	if !h.checkSecondFactor(c, claims, enrolment, body.Code, body.RecoveryCode) {
+		return
+	}
+
+	if err := h.mfa.Delete(claims.Subject); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot delete mfa enrolment", logger.Error(err))
+		return
+	}
+
+	h.log.Info("mfa disabled",
+		logger.String("subject", claims.Subject),
+		logger.String("role", claims.Role))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "two-factor authentication is disabled",
+	})
+}
+
+// mfaClaims returns the claims of the access token of the request, requests without
+// one may send the mfa token login returned, pending is true in that case
+func (h *handlerV1) mfaClaims(c *gin.Context, mfaToken string) (*tokens.Claims, bool, bool) {
+	if claims, ok := c.Get("claims"); ok {
+		return claims.(*tokens.Claims), false, true
+	}
+
+	if mfaToken != "" {
+		claims, err := tokens.ExtractClaim(mfaToken, h.jwtKeys, tokens.TypeMFA)
+		if err == nil {
+			return claims, true, true
+		}
+	}
+
+	c.JSON(http.StatusUnauthorized, models.ResponseError{
+		Code:    ErrorCodeUnauthorized,
+		Message: "access token or mfa token is required",
+	})
+	return nil, false, false
+}
+
+// mfaRequired reports whether the role or a role it inherits has the mfa policy
+func (h *handlerV1) mfaRequired(role string) (bool, error) {
+	roles, err := h.casbin.GetImplicitRolesForUser(role)
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range append([]string{role}, roles...) {
+		if h.casbin.HasPolicy(r, casb.MFAPolicyObject, casb.MFAPolicyAction) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// checkSecondFactor accepts a code of the authenticator app or a recovery code,
+// every token may be tried mfaMaxAttempts times, an attempt is taken atomically
+// before the code is checked so parallel requests cannot get more of them.
+// Wrong codes are login failures of the account, so logging in again for
+// a new token does not give more of them than the account lockout allows
+func (h *handlerV1) checkSecondFactor(c *gin.Context, claims *tokens.Claims, enrolment *models.MFA, code, recoveryCode string) bool {
+	accountKey := h.secondFactorAccount(claims)
+	if !h.CheckLockout(c, accountKey) {
+		return false
+	}
+
+	attempts, err := h.inMemoryStorage.Incr(mfaAttemptsKey(claims.ID), int(time.Until(claims.ExpiresAt.Time).Seconds())+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot count mfa attempt", logger.Error(err))
+		return false
+	}
+	if attempts > mfaMaxAttempts {
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "too many attempts, login again",
+		})
+		return false
+	}
+
+	var valid bool
+	switch {
+	case code != "":
+		step, ok := totp.Validate(enrolment.Secret, strings.TrimSpace(code), time.Now())
+		if ok {
+			valid, err = h.mfa.UseStep(enrolment.Subject, step)
+		}
+	case recoveryCode != "":
+		valid, err = h.mfa.UseRecoveryCode(enrolment.Subject, etc.HashToken(normalizeRecoveryCode(recoveryCode)))
+		if valid {
+			h.log.Warn("mfa recovery code used", logger.String("subject", enrolment.Subject))
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot check second factor", logger.Error(err))
+		return false
+	}
+
+	if !valid {
+		h.LoginFailed(c, accountKey)
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "code is incorrect, try again.",
+		})
+		return false
+	}
+	h.LoginSucceeded(accountKey)
+
+	return true
+}
+
+// secondFactorAccount returns the attempt key of the account that logged in
+// for the mfa token, codes sent with an access token count for the subject
+func (h *handlerV1) secondFactorAccount(claims *tokens.Claims) string {
+	if claims.Type == tokens.TypeMFA {
+		accountKey, err := redis.String(h.inMemoryStorage.Get(mfaAccountKey(claims.ID)))
+		if err == nil {
+			return accountKey
+		}
+		if !errors.Is(err, redis.ErrNil) {
+			h.log.Error("cannot get mfa account", logger.Error(err))
+		}
+	}
+
+	return attemptKey("mfa", claims.Subject)
+}
+
+// burnMFAToken makes sure an mfa token is exchanged for a session only once
+func (h *handlerV1) burnMFAToken(claims *tokens.Claims) {
+	err := h.saveUntil(mfaAttemptsKey(claims.ID), mfaMaxAttempts, claims.ExpiresAt.Time)
+	if err != nil {
+		h.log.Error("cannot burn mfa token", logger.Error(err))
+	}
+}
+
+// generateRecoveryCodes returns the codes shown to the user and the hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateSecureCode(recoveryCodeLength)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, etc.HashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/etc"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// mfaStorage keeps the enrolments of the tests in memory
+type mfaStorage struct {
+	mu         sync.Mutex
+	enrolments map[string]*models.MFA
+	usedSteps  map[string]int64
+	usedCodes  map[string]bool
+}
+
+func (m *mfaStorage) Get(subject string) (*models.MFA, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enrolment, ok := m.enrolments[subject]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *enrolment
+	return &copied, nil
+}
+
+func (m *mfaStorage) Save(mfa *models.MFA) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := *mfa
+	m.enrolments[mfa.Subject] = &saved
+	return nil
+}
+
+func (m *mfaStorage) Enable(subject string, recoveryCodes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enrolment, ok := m.enrolments[subject]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	enrolment.Enabled = true
+	enrolment.RecoveryCodes = recoveryCodes
+	return nil
+}
+
+func (m *mfaStorage) UseStep(subject string, step int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if used, ok := m.usedSteps[subject]; ok && step <= used {
+		return false, nil
+	}
+	m.usedSteps[subject] = step
+	return true, nil
+}
+
+func (m *mfaStorage) UseRecoveryCode(subject, codeHash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enrolment, ok := m.enrolments[subject]
+	if !ok || m.usedCodes[codeHash] {
+		return false, nil
+	}
+	for _, hash := range enrolment.RecoveryCodes {
+		if hash == codeHash {
+			m.usedCodes[codeHash] = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mfaStorage) Delete(subject string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.enrolments, subject)
+	return nil
+}
+
+const testRecoveryCode = "abcde-12345"
+
+func newMFATestHandler(t *testing.T) *handlerV1 {
+	t.Helper()
+
+	h, _, _ := newSessionTestHandler(t)
+	h.casbin, _ = newTestEnforcer(t, "g, user, unauthorized\n")
+	h.mfa = &mfaStorage{
+		enrolments: map[string]*models.MFA{"user-1": {
+			Subject:       "user-1",
+			Secret:        "JBSWY3DPEHPK3PXP",
+			Enabled:       true,
+			RecoveryCodes: []string{etc.HashToken(normalizeRecoveryCode(testRecoveryCode))},
+		}},
+		usedSteps: map[string]int64{},
+		usedCodes: map[string]bool{},
+	}
+	return h
+}
+
+// requireMFA logs user-1 in as far as the password and returns the mfa token
+func requireMFA(t *testing.T, h *handlerV1, accountKey string) string {
+	t.Helper()
+
+	c, recorder := newTestContext("10.0.3.1")
+	if !h.RequireMFA(c, accountKey, "user-1", RoleUser) {
+		t.Fatal("enrolled user is not asked for the second factor")
+	}
+
+	var resp models.MFARequiredResp
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil || resp.MFAToken == "" {
+		t.Fatalf("no mfa token in %s", recorder.Body)
+	}
+	return resp.MFAToken
+}
+
+func verifyMFA(h *handlerV1, mfaToken, recoveryCode string) int {
+	body, _ := json.Marshal(models.MFAVerifyReq{MFAToken: mfaToken, RecoveryCode: recoveryCode})
+
+	c, recorder := newTestContext("10.0.3.1")
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/mfa/verify", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.VerifyMFA(c)
+	return recorder.Code
+}
+
+func TestVerifyMFACountsAgainstAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		status   int
+	}{
+		{name: "no failures", failures: 0, status: http.StatusOK},
+		{name: "attempts left", failures: accountMaxFailures - 1, status: http.StatusOK},
+		// every failure is made with a new mfa token, logging in
+		// again must not give more attempts than the account has
+		{name: "out of attempts", failures: accountMaxFailures, status: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newMFATestHandler(t)
+			accountKey := attemptKey("user", "user@exam.local")
+
+			for i := 0; i < tt.failures; i++ {
+				if status := verifyMFA(h, requireMFA(t, h, accountKey), "wrong-code"); status != http.StatusBadRequest {
+					t.Fatalf("wrong code answered %d", status)
+				}
+			}
+
+			if status := verifyMFA(h, requireMFA(t, h, accountKey), testRecoveryCode); status != tt.status {
+				t.Fatalf("recovery code answered %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestSecondFactorForgetsFailures(t *testing.T) {
+	h := newMFATestHandler(t)
+	accountKey := attemptKey("user", "user@exam.local")
+
+	for i := 0; i < accountMaxFailures-1; i++ {
+		verifyMFA(h, requireMFA(t, h, accountKey), "wrong-code")
+	}
+	if status := verifyMFA(h, requireMFA(t, h, accountKey), testRecoveryCode); status != http.StatusOK {
+		t.Fatalf("recovery code answered %d", status)
+	}
+
+	// a whole set of attempts again after the successful login
+	for i := 0; i < accountMaxFailures-1; i++ {
+		verifyMFA(h, requireMFA(t, h, accountKey), "wrong-code")
+	}
+	c, _ := newTestContext("10.0.3.1")
+	if !h.CheckLockout(c, accountKey) {
+		t.Fatal("failures before the successful login are still counted")
+	}
+}
@@ -0,0 +1,37 @@
+package v1
+
+import (
+	"context"
+	"exam/api-gateway/email"
+	pb "exam/api-gateway/genproto/user-service"
+	"exam/api-gateway/pkg/logger"
+	"time"
+)
+
+// notify sends a notification email to the user in their locale,
+// the request already succeeded so a failure is only logged
+func (h *handlerV1) notify(user *pb.User, name string, data interface{}) {
+	if err := email.Notify(h.mailer, name, user.Locale, user.Email, data); err != nil {
+		h.log.Error("cannot send notification",
+			logger.String("template", name),
+			logger.String("user_id", user.Id),
+			logger.Error(err))
+	}
+}
+
+// notifyById looks the user up before notifying them
+func (h *handlerV1) notifyById(userId, name string, data func(user *pb.User) interface{}) {
+	ctx, cancel := context.WithTimeout(h.rpcContext(nil), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	user, err := h.serviceManager.UserService().GetUserById(ctx, &pb.GetUserId{UserId: userId})
+	if err != nil {
+		h.log.Error("cannot get user to notify",
+			logger.String("template", name),
+			logger.String("user_id", userId),
+			logger.Error(err))
+		return
+	}
+
+	h.notify(user, name, data(user))
+}
@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	"testing"
+)
+
+func TestNotifyById(t *testing.T) {
+	tests := []struct {
+		name        string
+		userId      string
+		locale      string
+		wantSubject string
+	}{
+		{name: "english user", userId: testUserId, locale: "en", wantSubject: "Your password was changed"},
+		{name: "russian user", userId: testUserId, locale: "ru-RU", wantSubject: "Пароль изменён"},
+		{name: "unsupported locale", userId: testUserId, locale: "de", wantSubject: "Your password was changed"},
+		{name: "unknown user", userId: "7a4c2e1d-9b8f-4a6e-8d3c-1f0e2b9a7c54"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, services, mails := newUserTestHandler(t)
+			user, _ := services.users.get(testUserId)
+			user.Locale = tt.locale
+			services.users.add(user)
+
+			h.notifyPasswordChanged(tt.userId)
+
+			sent := mails.to(testUserEmail)
+			if tt.wantSubject == "" {
+				if len(sent) != 0 {
+					t.Fatalf("sent %+v", sent)
+				}
+				return
+			}
+			if len(sent) != 1 || sent[0].Subject != tt.wantSubject {
+				t.Fatalf("sent %+v, want one with subject %q", sent, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestChangePasswordNotifies(t *testing.T) {
+	h, _, mails := newUserTestHandler(t)
+
+	for _, current := range []string{"wrong-password", testUserPass} {
+		c, _ := newRequestContext("POST", "/v1/user/password/change",
+			models.ChangePasswordReq{CurrentPassword: current, NewPassword: "newpass1"}, testUserId, RoleUser)
+		h.ChangePassword(c)
+	}
+
+	// only the change that happened is told about
+	sent := mails.to(testUserEmail)
+	if len(sent) != 1 || sent[0].Subject != "Your password was changed" {
+		t.Fatalf("sent %+v", sent)
+	}
+}
@@ -0,0 +1,355 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/email"
+	pb "exam/api-gateway/genproto/user-service"
+	"exam/api-gateway/pkg/etc"
+	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/pkg/oidc"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+)
+
+// oidcLoginTimeout is how long the user has to sign in at the identity provider
+const oidcLoginTimeout = time.Minute * 10
+
+func oidcStateKey(state string) string {
+	return "oidc_state:" + state
+}
+
+// List identity providers
+// @Summary list identity providers
+// @Tags User
+// @Description Names of the identity providers users can log in with at /v1/oauth/{provider}/login
+// @Produce json
+// @Success 200 {object} models.OIDCProviderList
+// @Router /v1/oauth/providers [get]
+func (h *handlerV1) ListOIDCProviders(c *gin.Context) {
+	response := models.OIDCProviderList{Providers: []string{}}
+	for name := range h.oidcProviders {
+		response.Providers = append(response.Providers, name)
+	}
+	sort.Strings(response.Providers)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Login with identity provider
+// @Summary login with identity provider
+// @Tags User
+// @Description Redirect the browser to the identity provider to sign in with the authorization code flow and PKCE,
+// @Description the provider redirects back to /v1/oauth/{provider}/callback
+// @Param provider path string true "provider"
+// @Success 302
+// @Failure 404 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+// @Router /v1/oauth/{provider}/login [get]
+func (h *handlerV1) OIDCLogin(c *gin.Context) {
+	provider, ok := h.getOIDCProvider(c)
+	if !ok {
+		return
+	}
+
+	// the state finds the login again, the nonce binds the id token to it
+	// and the verifier proves the code is redeemed by who asked for it
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := oidc.RandomToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ResponseError{
+				Code:    ErrorCodeInternalServerError,
+				Message: err.Error(),
+			})
+			h.log.Error("cannot generate oidc state", logger.Error(err))
+			return
+		}
+		secrets[i] = secret
+	}
+	stateId := secrets[0]
+	state := models.OIDCState{
+		Provider:     provider.Name(),
+		Nonce:        secrets[1],
+		CodeVerifier: secrets[2],
+		ExpiresAt:    time.Now().Add(oidcLoginTimeout),
+	}
+
+	if err := h.saveUntil(oidcStateKey(stateId), state, state.ExpiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot save oidc state", logger.Error(err))
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), stateId, state.Nonce, oidc.CodeChallenge(state.CodeVerifier))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: "the identity provider is not available, try again later",
+		})
+		h.log.Error("cannot build oidc authorization url", logger.String("provider", provider.Name()), logger.Error(err))
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Identity provider callback
+// @Summary identity provider callback
+// @Tags User
+// @Description The identity provider redirects here after the user signed in. The verified email of the user
+// @Description logs into the account with that email, an account is created when there is none
+// @Produce json
+// @Param provider path string true "provider"
+// @Param code query string true "code"
+// @Param state query string true "state"
+// @Success 200 {object} models.UserModel
+// @Success 200 {object} models.MFARequiredResp
+// @Failure 400 string Error models.ResponseError
+// @Failure 403 string Error models.ResponseError
+// @Failure 404 string Error models.ResponseError
+// @Router /v1/oauth/{provider}/callback [get]
+func (h *handlerV1) OIDCCallback(c *gin.Context) {
+	provider, ok := h.getOIDCProvider(c)
+	if !ok {
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: strings.TrimSpace("the identity provider refused the login: " + providerErr + " " + c.Query("error_description")),
+		})
+		return
+	}
+
+	state, ok := h.takeOIDCState(c, provider.Name(), c.Query("state"))
+	if !ok {
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), state.CodeVerifier, state.Nonce)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorInvalidCredentials,
+			Message: "the login with the identity provider failed, start again",
+		})
+		h.log.Error("oidc code exchange failed", logger.String("provider", provider.Name()), logger.Error(err))
+		return
+	}
+
+	// an unverified email would let anybody take over the account with that email
+	if identity.Email == "" || !identity.EmailVerified {
+		c.JSON(http.StatusForbidden, models.ResponseError{
+			Code:    ErrorCodePermissionDenied,
+			Message: "the identity provider has not verified your email",
+		})
+		return
+	}
+
+	user, ok := h.oidcUser(c, identity)
+	if !ok {
+		return
+	}
+
+	// a wrong second factor counts against the same account as at password login
+	role := h.subjectRole(user.Id, RoleUser)
+	if h.RequireMFA(c, attemptKey("user", strings.ToLower(user.Email)), user.Id, role) {
+		return
+	}
+
+	access, refresh, err := h.NewSession(c, user.Id, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot create access and refresh token", logger.Error(err))
+		return
+	}
+
+	h.log.Info("user logged in with identity provider",
+		logger.String("provider", identity.Provider),
+		logger.String("user", user.Id))
+
+	c.JSON(http.StatusOK, models.UserModel{
+		Id:           user.Id,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Age:          user.Age,
+		Email:        user.Email,
+		Locale:       user.Locale,
+		AccessToken:  access,
+		RefreshToken: refresh,
+	})
+}
+
+func (h *handlerV1) getOIDCProvider(c *gin.Context) (oidc.Provider, bool) {
+	provider, ok := h.oidcProviders[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "identity provider " + c.Param("provider") + " is not configured",
+		})
+		return nil, false
+	}
+
+	return provider, true
+}
+
+// takeOIDCState returns the login the state belongs to and deletes it in
+// the same step, of parallel callbacks with the state only one gets it
+func (h *handlerV1) takeOIDCState(c *gin.Context, provider, stateId string) (*models.OIDCState, bool) {
+	invalid := func() (*models.OIDCState, bool) {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "the login is invalid or expired, start again",
+		})
+		return nil, false
+	}
+
+	if stateId == "" {
+		return invalid()
+	}
+
+	stateJson, err := redis.Bytes(h.inMemoryStorage.GetDel(oidcStateKey(stateId)))
+	if errors.Is(err, redis.ErrNil) {
+		return invalid()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get oidc state", logger.Error(err))
+		return nil, false
+	}
+
+	var state models.OIDCState
+	if err := json.Unmarshal(stateJson, &state); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot unmarshal oidc state", logger.Error(err))
+		return nil, false
+	}
+	if state.Provider != provider || time.Now().After(state.ExpiresAt) {
+		return invalid()
+	}
+
+	return &state, true
+}
+
+// oidcUser returns the user with the email of the identity, the user is
+// created with a random password when there is none, they can set one
+// with the forgotten password flow
+func (h *handlerV1) oidcUser(c *gin.Context, identity *oidc.Identity) (*pb.User, bool) {
+	ctx, cancel := context.WithTimeout(h.gatewayContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	exists, err := h.serviceManager.UserService().CheckField(ctx, &pb.CheckFieldRequest{
+		Field: "email",
+		Data:  identity.Email,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to check email", logger.Error(err))
+		return nil, false
+	}
+
+	if exists.Status {
+		user, err := h.serviceManager.UserService().Check(ctx, &pb.IfExists{
+			Email: identity.Email,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ResponseError{
+				Code:    ErrorCodeInternalServerError,
+				Message: err.Error(),
+			})
+			h.log.Error("cannot get user by email", logger.Error(err))
+			return nil, false
+		}
+		return user, true
+	}
+
+	password, err := oidc.RandomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot generate password", logger.Error(err))
+		return nil, false
+	}
+	passwordHash, err := etc.HashPassword(password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot hash the password", logger.Error(err))
+		return nil, false
+	}
+
+	firstName, lastName := identity.GivenName, identity.FamilyName
+	if firstName == "" {
+		firstName, lastName, _ = strings.Cut(identity.Name, " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	created, err := h.serviceManager.UserService().CreateUser(ctx, &pb.User{
+		Id:        uuid.NewString(),
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     identity.Email,
+		Password:  passwordHash,
+		Locale:    email.Locale(""),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot create user", logger.Error(err))
+		return nil, false
+	}
+
+	// the email is taken now, a registration waiting for it must not create it again
+	pending, err := h.findPendingRegistration(identity.Email)
+	if err != nil {
+		h.log.Error("cannot get pending registration", logger.Error(err))
+	}
+	if pending != nil {
+		pending.Verified = true
+		if err := h.saveUntil(registrationKey(pending.Email), pending, pending.ExpiresAt); err != nil {
+			h.log.Error("cannot burn pending registration", logger.Error(err))
+		}
+	}
+
+	h.log.Info("user created from identity provider",
+		logger.String("provider", identity.Provider),
+		logger.String("user", created.Id))
+
+	h.notify(created, email.TemplateWelcome, email.WelcomeData{
+		UserName: created.FirstName,
+	})
+
+	return created, true
+}
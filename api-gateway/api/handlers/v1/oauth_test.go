@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTakeOIDCState(t *testing.T) {
+	h, _ := newTestHandler(t)
+	save := func(stateId string, state models.OIDCState) {
+		if err := h.saveUntil(oidcStateKey(stateId), state, state.ExpiresAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	valid := models.OIDCState{Provider: "local", Nonce: "nonce", ExpiresAt: time.Now().Add(time.Minute)}
+
+	tests := []struct {
+		name     string
+		stateId  string
+		provider string
+		replay   bool
+		wantCode int
+	}{
+		{name: "valid", stateId: "state-valid", provider: "local", wantCode: http.StatusOK},
+		{name: "replayed", stateId: "state-replayed", provider: "local", replay: true, wantCode: http.StatusBadRequest},
+		{name: "other provider", stateId: "state-other", provider: "google", wantCode: http.StatusBadRequest},
+		{name: "unknown", stateId: "state-unknown", provider: "local", wantCode: http.StatusBadRequest},
+		{name: "empty", provider: "local", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stateId != "" && tt.stateId != "state-unknown" {
+				save(tt.stateId, valid)
+			}
+			if tt.replay {
+				c, _ := newTestContext("10.0.6.1")
+				if _, ok := h.takeOIDCState(c, tt.provider, tt.stateId); !ok {
+					t.Fatal("the first callback was refused")
+				}
+			}
+
+			c, recorder := newTestContext("10.0.6.1")
+			state, ok := h.takeOIDCState(c, tt.provider, tt.stateId)
+			if ok != (tt.wantCode == http.StatusOK) {
+				t.Fatalf("taken = %v, want code %d", ok, tt.wantCode)
+			}
+			if !ok && recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", recorder.Code, tt.wantCode)
+			}
+			if ok && state.Nonce != valid.Nonce {
+				t.Fatalf("nonce = %q, want %q", state.Nonce, valid.Nonce)
+			}
+		})
+	}
+}
+
+func TestParallelCallbacksTakeStateOnce(t *testing.T) {
+	h, _ := newTestHandler(t)
+	state := models.OIDCState{Provider: "local", ExpiresAt: time.Now().Add(time.Minute)}
+	if err := h.saveUntil(oidcStateKey("state"), state, state.ExpiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	var taken int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, _ := newTestContext("10.0.6.2")
+			if _, ok := h.takeOIDCState(c, "local", "state"); ok {
+				atomic.AddInt32(&taken, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if taken != 1 {
+		t.Fatalf("the state was taken %d times, want once", taken)
+	}
+}
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"encoding/json"
+	"exam/api-gateway/api/handlers/models"
+	pbu "exam/api-gateway/genproto/user-service"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOwnership(t *testing.T) {
+	endpoints := []struct {
+		name   string
+		method string
+		body   interface{}
+		handle func(h *handlerV1, c *gin.Context)
+		// changed reports whether the user was changed
+		changed func(user *pbu.User) bool
+	}{
+		{
+			name:   "get user",
+			method: http.MethodGet,
+			handle: func(h *handlerV1, c *gin.Context) { h.GetUserById(c) },
+		},
+		{
+			name:   "update user",
+			method: http.MethodPut,
+			body:   models.UserRequest{FirstName: "Changed", LastName: "Userov", Age: 30},
+			handle: func(h *handlerV1, c *gin.Context) { h.UpdateUser(c) },
+			changed: func(user *pbu.User) bool {
+				return user.FirstName == "Changed"
+			},
+		},
+		{
+			name:   "get purchases",
+			method: http.MethodGet,
+			handle: func(h *handlerV1, c *gin.Context) { h.GetPurchasedProductsByUserId(c) },
+		},
+	}
+
+	callers := []struct {
+		name     string
+		sub      string
+		role     string
+		wantCode int
+	}{
+		{name: "owner", sub: testUserId, role: RoleUser, wantCode: http.StatusOK},
+		{name: "another user", sub: testOtherId, role: RoleUser, wantCode: http.StatusForbidden},
+		{name: "admin", sub: testAdminId, role: RoleAdmin, wantCode: http.StatusOK},
+		{name: "superadmin", sub: testSuperAdminId, role: RoleSuperAdmin, wantCode: http.StatusOK},
+		{name: "role inheriting admin", sub: testAdminId, role: "auditor", wantCode: http.StatusOK},
+		{name: "role not inheriting admin", sub: testAdminId, role: "support", wantCode: http.StatusForbidden},
+		{name: "anonymous", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, endpoint := range endpoints {
+		for _, caller := range callers {
+			t.Run(endpoint.name+"/"+caller.name, func(t *testing.T) {
+				h, services, _ := newUserTestHandler(t)
+				if _, err := h.casbin.AddGroupingPolicies([][]string{{"auditor", RoleAdmin}, {"support", RoleUser}}); err != nil {
+					t.Fatal(err)
+				}
+
+				c, recorder := newRequestContext(endpoint.method, "/v1/user/"+testUserId, endpoint.body, caller.sub, caller.role, gin.Param{Key: "id", Value: testUserId})
+				endpoint.handle(h, c)
+				if recorder.Code != caller.wantCode {
+					t.Fatalf("code = %d, want %d: %s", recorder.Code, caller.wantCode, recorder.Body)
+				}
+				if endpoint.changed != nil {
+					user, _ := services.users.get(testUserId)
+					if changed := endpoint.changed(user); changed != (caller.wantCode == http.StatusOK) {
+						t.Fatalf("changed = %v", changed)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestBuyProductForCaller(t *testing.T) {
+	h, services, mails := newUserTestHandler(t)
+	services.products.stock[7] = 5
+
+	// a user id in the body does not make the purchase somebody else's
+	c, recorder := newRequestContext(http.MethodPost, "/v1/product/buy", map[string]interface{}{
+		"user_id":    testOtherId,
+		"product_id": 7,
+		"amount":     2,
+	}, testUserId, RoleUser)
+	h.BuyProduct(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("buy answered %d: %s", recorder.Code, recorder.Body)
+	}
+
+	var response models.BuyProductResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	purchases := services.products.purchases
+	if len(purchases) != 1 || purchases[0].UserId != testUserId || response.UserId != testUserId {
+		t.Fatalf("purchases = %v, response = %+v", purchases, response)
+	}
+	if services.products.stock[7] != 3 {
+		t.Fatalf("stock = %d, want 3", services.products.stock[7])
+	}
+	if len(mails.to(testUserEmail)) != 1 || len(mails.to("other@example.com")) != 0 {
+		t.Fatal("the receipt is not sent to the buyer only")
+	}
+}
+
+func TestBuyProductAnonymously(t *testing.T) {
+	h, services, _ := newUserTestHandler(t)
+	services.products.stock[7] = 5
+
+	c, recorder := newRequestContext(http.MethodPost, "/v1/product/buy", models.BuyProductRequest{ProductId: 7, Amount: 1}, "", "")
+	h.BuyProduct(c)
+	if recorder.Code != http.StatusUnauthorized || len(services.products.purchases) != 0 {
+		t.Fatalf("buy answered %d with %d purchases", recorder.Code, len(services.products.purchases))
+	}
+}
@@ -0,0 +1,481 @@
+package v1
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/email"
+	pb "exam/api-gateway/genproto/user-service"
+	"exam/api-gateway/pkg/etc"
+	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/pkg/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/go-ozzo/ozzo-validation/v3/is"
+	"github.com/gomodule/redigo/redis"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	passwordResetTimeout     = time.Minute * 15
+	passwordResetCodeLength  = 6
+	passwordResetMaxAttempts = 5
+
+	// a reset request is remembered that long, its resends and failed
+	// attempts are counted over all the codes sent meanwhile
+	passwordResetWindow = time.Hour * 24
+)
+
+func passwordResetKey(email string) string {
+	return "password_reset:" + email
+}
+
+func passwordResetAttemptsKey(email string) string {
+	return "password_reset_attempts:" + email
+}
+
+// Forgot password
+// @Summary forgot password
+// @Tags User
+// @Description Send a one-time password reset code to the email of the user, every resend waits twice as long as the previous one
+// @Accept json
+// @Produce json
+// @Param email body models.ForgotPasswordReq true "email"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string Error models.ResponseError
+// @Failure 429 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+// @Router /v1/user/password/forgot [post]
+func (h *handlerV1) ForgotPassword(c *gin.Context) {
+	var body models.ForgotPasswordReq
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+	body.Email = strings.ToLower(strings.TrimSpace(body.Email))
+
+	if err := validation.Validate(body.Email, validation.Required, is.Email); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// unknown emails get the same answer, cooldown and work,
+	// so the endpoint cannot be used to find out who is registered
+	response := models.SuperAdminMessage{
+		Message: "if the email is registered, a password reset code was sent to it",
+	}
+
+	reset, err := h.findPasswordReset(body.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get password reset", logger.Error(err))
+		return
+	}
+	if reset == nil {
+		reset = &models.PasswordResetModel{
+			ExpiresAt: time.Now().Add(passwordResetWindow),
+		}
+	} else {
+		if !h.checkCooldown(c, reset.Resends, reset.LastSentAt) {
+			return
+		}
+		reset.Resends++
+	}
+
+	code, err := utils.GenerateSecureCode(passwordResetCodeLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot generate password reset code", logger.Error(err))
+		return
+	}
+
+	// a new code replaces the previous one, the failed attempts are kept
+	reset.CodeHash = etc.HashToken(code)
+	reset.CodeExpiresAt = time.Now().Add(passwordResetTimeout)
+	reset.LastSentAt = time.Now()
+	if err := h.saveUntil(passwordResetKey(body.Email), reset, reset.ExpiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot set with ttl", logger.Error(err))
+		return
+	}
+
+	// looking the user up and queueing the email would take longer for
+	// registered emails, so both happen after the answer
+	go h.sendPasswordResetCode(body.Email, code)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// sendPasswordResetCode emails the code when a user has the email
+func (h *handlerV1) sendPasswordResetCode(userEmail, code string) {
+	ctx, cancel := context.WithTimeout(h.rpcContext(nil), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	user, err := h.findUserByEmail(ctx, userEmail)
+	if err != nil {
+		h.log.Error("cannot get user by email", logger.Error(err))
+		return
+	}
+	if user == nil {
+		return
+	}
+
+	err = email.SendVerificationCode(h.mailer, email.Params{
+		To:       user.Email,
+		Locale:   user.Locale,
+		Purpose:  email.PurposePasswordReset,
+		Code:     code,
+		UserName: user.FirstName,
+	})
+	if err != nil {
+		h.log.Error("cannot send a code to an email", logger.Error(err))
+	}
+}
+
+// findUserByEmail returns nil when no user has the email
+func (h *handlerV1) findUserByEmail(ctx context.Context, userEmail string) (*pb.User, error) {
+	exists, err := h.serviceManager.UserService().CheckField(ctx, &pb.CheckFieldRequest{
+		Field: "email",
+		Data:  userEmail,
+	})
+	if err != nil || !exists.Status {
+		return nil, err
+	}
+
+	return h.serviceManager.UserService().Check(ctx, &pb.IfExists{
+		Email: userEmail,
+	})
+}
+
+// findPasswordReset returns nil when no reset was requested for the email lately
+func (h *handlerV1) findPasswordReset(userEmail string) (*models.PasswordResetModel, error) {
+	resetJson, err := redis.Bytes(h.inMemoryStorage.Get(passwordResetKey(userEmail)))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var reset models.PasswordResetModel
+	if err := json.Unmarshal(resetJson, &reset); err != nil {
+		return nil, err
+	}
+	if time.Now().After(reset.ExpiresAt) {
+		return nil, nil
+	}
+
+	return &reset, nil
+}
+
+// Reset password
+// @Summary reset password
+// @Tags User
+// @Description Set a new password with the code sent by /v1/user/password/forgot, every session of the user is closed
+// @Accept json
+// @Produce json
+// @Param reset body models.ResetPasswordReq true "reset"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string Error models.ResponseError
+// @Failure 429 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+// @Router /v1/user/password/reset [post]
+func (h *handlerV1) ResetPassword(c *gin.Context) {
+	var body models.ResetPasswordReq
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+	body.Email = strings.ToLower(strings.TrimSpace(body.Email))
+
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	accountKey := attemptKey("reset", body.Email)
+	if !h.CheckLockout(c, accountKey) {
+		return
+	}
+
+	reset, err := h.findPasswordReset(body.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get password reset", logger.Error(err))
+		return
+	}
+	if reset == nil || reset.CodeHash == "" || time.Now().After(reset.CodeExpiresAt) {
+		h.LoginFailed(c, accountKey)
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "code is expired, try again.",
+		})
+		return
+	}
+
+	// the attempt is taken before the code is compared, so parallel
+	// guesses cannot get more of them, new codes do not give them back
+	attempts, err := h.inMemoryStorage.Incr(passwordResetAttemptsKey(body.Email), int(time.Until(reset.ExpiresAt).Seconds())+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot count password reset attempt", logger.Error(err))
+		return
+	}
+	if attempts > passwordResetMaxAttempts {
+		c.JSON(http.StatusTooManyRequests, models.ResponseError{
+			Code:    ErrorCodeTooManyAttempts,
+			Message: "too many attempts, try again later",
+		})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(etc.HashToken(body.Code)), []byte(reset.CodeHash)) != 1 {
+		h.LoginFailed(c, accountKey)
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "code is incorrect, try again.",
+		})
+		return
+	}
+
+	password, err := etc.HashPassword(body.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot hash the password", logger.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(h.gatewayContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	// codes are sent for unknown emails too, they never match a user
+	user, err := h.findUserByEmail(ctx, body.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get user by email", logger.Error(err))
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "code is expired, try again.",
+		})
+		return
+	}
+
+	updated, err := h.serviceManager.UserService().UpdatePassword(ctx, &pb.UpdatePasswordReq{
+		UserId:   user.Id,
+		Password: password,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot update password", logger.Error(err))
+		return
+	}
+	if !updated.Success {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "user not found",
+		})
+		return
+	}
+
+	// the code is one-time, burn it once the password is set
+	if err := h.inMemoryStorage.Del(passwordResetKey(body.Email), passwordResetAttemptsKey(body.Email)); err != nil {
+		h.log.Error("cannot burn password reset code", logger.Error(err))
+	}
+	h.LoginSucceeded(accountKey)
+
+	revoked, err := h.RevokeAllSessions(user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot revoke sessions after password reset", logger.Error(err))
+		return
+	}
+
+	h.log.Info("password reset",
+		logger.String("user_id", user.Id),
+		logger.Any("revoked_sessions", revoked))
+
+	h.notifyPasswordChanged(user.Id)
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "password was reset, login again",
+	})
+}
+
+// Change password
+// @Router /v1/user/password/change [post]
+// @Security BearerAuth
+// @Summary change password
+// @Tags User
+// @Description Change the password of the logged in user, every session of the user is closed
+// @Accept json
+// @Produce json
+// @Param password body models.ChangePasswordReq true "password"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string Error models.ResponseError
+// @Failure 401 string Error models.ResponseError
+// @Failure 404 string Error models.ResponseError
+// @Failure 429 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) ChangePassword(c *gin.Context) {
+	var body models.ChangePasswordReq
+
+	userId, ok := h.GetUserId(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	user, err := h.serviceManager.UserService().GetUserById(ctx, &pb.GetUserId{UserId: userId})
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "user not found",
+		})
+		h.log.Error("cannot get user to change password", logger.Error(err))
+		return
+	}
+
+	// guessing the current password counts against the same account as
+	// guessing it at login, a stolen session gets no more attempts
+	accountKey := attemptKey("user", strings.ToLower(strings.TrimSpace(user.Email)))
+	if !h.CheckLockout(c, accountKey) {
+		return
+	}
+
+	_, err = h.serviceManager.UserService().ChangePassword(ctx, &pb.ChangePasswordReq{
+		UserId:          userId,
+		CurrentPassword: body.CurrentPassword,
+		NewPassword:     body.NewPassword,
+	})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.InvalidArgument:
+		h.LoginFailed(c, accountKey)
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorInvalidCredentials,
+			Message: "current password is incorrect",
+		})
+		return
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "user not found",
+		})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot change password", logger.Error(err))
+		return
+	}
+
+	h.LoginSucceeded(accountKey)
+
+	revoked, err := h.RevokeAllSessions(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot revoke sessions after password change", logger.Error(err))
+		return
+	}
+
+	h.log.Info("password changed",
+		logger.String("user_id", userId),
+		logger.Any("revoked_sessions", revoked))
+
+	h.notifyPasswordChanged(userId)
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "password was changed, login again",
+	})
+}
+
+// notifyPasswordChanged warns the user, so a stolen account is noticed
+func (h *handlerV1) notifyPasswordChanged(userId string) {
+	changedAt := time.Now()
+	h.notifyById(userId, email.TemplatePasswordChanged, func(user *pb.User) interface{} {
+		return email.PasswordChangedData{
+			UserName:  user.FirstName,
+			ChangedAt: changedAt,
+		}
+	})
+}
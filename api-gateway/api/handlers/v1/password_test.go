@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	"net/http"
+	"testing"
+)
+
+func TestChangePasswordLockout(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		current  string
+		wantCode int
+	}{
+		{name: "right password", current: testUserPass, wantCode: http.StatusOK},
+		{name: "wrong password", current: "wrong-password", wantCode: http.StatusBadRequest},
+		{name: "attempts left", failures: accountMaxFailures - 1, current: testUserPass, wantCode: http.StatusOK},
+		{name: "out of attempts", failures: accountMaxFailures, current: testUserPass, wantCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newUserTestHandler(t)
+			change := func(current string) int {
+				c, recorder := newRequestContext("POST", "/v1/user/password/change",
+					models.ChangePasswordReq{CurrentPassword: current, NewPassword: "newpass1"}, testUserId, RoleUser)
+				h.ChangePassword(c)
+				return recorder.Code
+			}
+
+			for i := 0; i < tt.failures; i++ {
+				if code := change("wrong-password"); code != http.StatusBadRequest {
+					t.Fatalf("failure %d answered %d", i+1, code)
+				}
+			}
+
+			if code := change(tt.current); code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestChangePasswordFailuresLockLogin(t *testing.T) {
+	h, _, _ := newUserTestHandler(t)
+	for i := 0; i < accountMaxFailures; i++ {
+		c, _ := newRequestContext("POST", "/v1/user/password/change",
+			models.ChangePasswordReq{CurrentPassword: "wrong-password", NewPassword: "newpass1"}, testUserId, RoleUser)
+		h.ChangePassword(c)
+	}
+
+	// the address has attempts left, the account has not
+	c, recorder := newRequestContext("POST", "/v1/user/login", models.LoginRequest{Email: testUserEmail, Password: testUserPass}, "", "")
+	h.Login(c)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("login answered %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,187 @@
+package v1
+
+import (
+	"bytes"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// a policy file is a few hundred lines at most
+const maxPolicySize = 1 << 20
+
+// policyFormat answers 400 and returns false for an unknown format query parameter
+func (h *handlerV1) policyFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", casb.PolicyFormatCSV)
+	if format != casb.PolicyFormatCSV && format != casb.PolicyFormatJSON {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidParams,
+			Message: "format must be csv or json",
+		})
+		return "", false
+	}
+
+	return format, true
+}
+
+// validatePolicy checks the rules against the defined roles and the routes of the gateway
+func (h *handlerV1) validatePolicy(c *gin.Context, rules []models.CasbinRule) (*models.PolicyReport, bool) {
+	roles, err := h.roles.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot list roles", logger.Error(err))
+		return nil, false
+	}
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+
+	return casb.ValidatePolicy(rules, names, h.routes()), true
+}
+
+// Export policy
+// @Router /v1/rbac/policy/export [get]
+// @Security BearerAuth
+// @Summary export policy
+// @Tags Role-management
+// @Description Download the live role policies and grouping rules as a casbin csv or a json file, role assignments of subjects are not exported
+// @Produce plain
+// @Param format query string false "csv or json, csv by default"
+// @Success 200 {string} string "policy file"
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+func (h *handlerV1) ExportPolicy(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	format, ok := h.policyFormat(c)
+	if !ok {
+		return
+	}
+
+	var file bytes.Buffer
+	if err := casb.WritePolicy(&file, format, casb.ExportPolicy(h.casbin)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot export policy", logger.Error(err))
+		return
+	}
+
+	contentType := "text/csv"
+	if format == casb.PolicyFormatJSON {
+		contentType = "application/json"
+	}
+	c.Header("Content-Disposition", "attachment; filename=policy."+format)
+	c.Data(http.StatusOK, contentType, file.Bytes())
+}
+
+// Validate policy
+// @Router /v1/rbac/policy/validate [get]
+// @Security BearerAuth
+// @Summary validate policy
+// @Tags Role-management
+// @Description Check the live policy against the registered routes and the defined roles, report malformed rules, rules that match no route and rules of undefined roles
+// @Produce json
+// @Success 200 {object} models.PolicyReport
+// @Failure 403 string error models.ResponseError
+func (h *handlerV1) ValidatePolicy(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	report, ok := h.validatePolicy(c, casb.ExportPolicy(h.casbin))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Import policy
+// @Router /v1/rbac/policy/import [post]
+// @Security BearerAuth
+// @Summary import policy
+// @Tags Role-management
+// @Description Replace every role policy and grouping rule with the ones of the file in one transaction, role assignments of subjects are kept.
+// @Description A file that does not pass the validation is refused unless force is set, dry_run only validates it
+// @Accept plain
+// @Produce json
+// @Param format query string false "csv or json, csv by default"
+// @Param dry_run query bool false "only validate the file"
+// @Param force query bool false "import even if rules match no route or belong to undefined roles"
+// @Param file body string true "policy file"
+// @Success 200 {object} models.PolicyReport
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+func (h *handlerV1) ImportPolicy(c *gin.Context) {
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	format, ok := h.policyFormat(c)
+	if !ok {
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	force, _ := strconv.ParseBool(c.Query("force"))
+
+	rules, err := casb.ReadPolicy(http.MaxBytesReader(c.Writer, c.Request.Body, maxPolicySize), format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "cannot read the policy file: " + err.Error(),
+		})
+		return
+	}
+
+	report, ok := h.validatePolicy(c, rules)
+	if !ok {
+		return
+	}
+
+	// malformed rules would break the enforcer, they are never imported
+	if len(report.Invalid) > 0 || (!report.Valid && !force) {
+		c.JSON(http.StatusBadRequest, report)
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	if err := h.policies.Replace(rules); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot import policy", logger.Error(err))
+		return
+	}
+
+	// every gateway reloads on the notification of the import, this one
+	// reloads right away so the response already reflects the new policy
+	if err := h.casbin.LoadPolicy(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot reload policy", logger.Error(err))
+		return
+	}
+
+	h.log.Info("policy imported",
+		logger.String("superadmin", superAdmin),
+		logger.Int("rules", len(rules)),
+		logger.Bool("forced", !report.Valid))
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,259 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	admin "exam/api-gateway/storage/postgresrepo"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	"github.com/gin-gonic/gin"
+)
+
+// roleStorage keeps the roles in memory like the roles table
+type roleStorage struct {
+	roles []*models.Role
+}
+
+func (r *roleStorage) List() ([]*models.Role, error) { return r.roles, nil }
+
+func (r *roleStorage) Get(name string) (*models.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			copied := *role
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *roleStorage) Create(role *models.Role) error {
+	if _, err := r.Get(role.Name); err == nil {
+		return admin.ErrRoleExists
+	}
+	created := *role
+	r.roles = append(r.roles, &created)
+	return nil
+}
+
+func (r *roleStorage) Update(role *models.Role) error {
+	for _, stored := range r.roles {
+		if stored.Name == role.Name {
+			stored.Description = role.Description
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *roleStorage) Delete(name string) error {
+	for i, role := range r.roles {
+		if role.Name == name {
+			r.roles = append(r.roles[:i], r.roles[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// policyStorage replaces the policy file the enforcer of the test loads,
+// subject assignments are kept like the casbin_rule table keeps them
+type policyStorage struct {
+	file string
+}
+
+func (p *policyStorage) Replace(rules []models.CasbinRule) error {
+	previous, err := os.ReadFile(p.file)
+	if err != nil {
+		return err
+	}
+
+	var file bytes.Buffer
+	if err := casb.WritePolicy(&file, casb.PolicyFormatCSV, rules); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(previous), "\n") {
+		if strings.HasPrefix(line, "g, "+casb.SubjectPrefix) {
+			file.WriteString(line + "\n")
+		}
+	}
+
+	return os.WriteFile(p.file, file.Bytes(), 0o600)
+}
+
+// newTestEnforcer returns an enforcer of the policy and the file it is loaded from
+func newTestEnforcer(t *testing.T, policy string) (*casbin.SyncedEnforcer, string) {
+	t.Helper()
+
+	policyFile := filepath.Join(t.TempDir(), "policy.csv")
+	if err := os.WriteFile(policyFile, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	enforcer, err := casbin.NewSyncedEnforcer("../../../config/auth.conf", fileadapter.NewAdapter(policyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return enforcer, policyFile
+}
+
+func newPolicyTestHandler(t *testing.T, policy string) *handlerV1 {
+	t.Helper()
+
+	enforcer, policyFile := newTestEnforcer(t, policy)
+	h, _ := newTestHandler(t)
+	h.casbin = enforcer
+	h.policies = &policyStorage{file: policyFile}
+	h.roles = &roleStorage{roles: []*models.Role{
+		{Name: casb.RoleUnauthorized}, {Name: RoleUser}, {Name: RoleAdmin}, {Name: RoleSuperAdmin},
+	}}
+	h.routes = func() gin.RoutesInfo {
+		return gin.RoutesInfo{
+			{Method: "GET", Path: "/v1/user/:id"},
+			{Method: "POST", Path: "/v1/user/login"},
+			{Method: "DELETE", Path: "/v1/user/delete/:id"},
+			{Method: "GET", Path: "/v1/rbac/policy/export"},
+			{Method: "POST", Path: "/v1/rbac/policy/import"},
+		}
+	}
+	return h
+}
+
+func newSuperAdminContext(method, target string, body []byte) (*gin.Context, *httptest.ResponseRecorder) {
+	c, recorder := newTestContext("10.0.2.1")
+	c.Request = httptest.NewRequest(method, target, bytes.NewReader(body))
+	c.Set("role", RoleSuperAdmin)
+	c.Set("sub", "root")
+	return c, recorder
+}
+
+func TestImportExportedPolicy(t *testing.T) {
+	h := newPolicyTestHandler(t, `p, user, /v1/user/{id}, GET
+p, unauthorized, /v1/user/login, POST
+p, admin, /v1/user/delete/{id}, DELETE
+p, admin, mfa, required
+g, user, unauthorized
+g, admin, user
+g, sub:user-1, admin
+`)
+
+	for _, format := range []string{casb.PolicyFormatCSV, casb.PolicyFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			c, recorder := newSuperAdminContext(http.MethodGet, "/v1/rbac/policy/export?format="+format, nil)
+			h.ExportPolicy(c)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("export answered %d: %s", recorder.Code, recorder.Body)
+			}
+			exported := recorder.Body.Bytes()
+
+			c, recorder = newSuperAdminContext(http.MethodGet, "/v1/rbac/policy/validate", nil)
+			h.ValidatePolicy(c)
+			var report models.PolicyReport
+			if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil || !report.Valid {
+				t.Fatalf("live policy is reported invalid: %s", recorder.Body)
+			}
+
+			c, recorder = newSuperAdminContext(http.MethodPost, "/v1/rbac/policy/import?format="+format, exported)
+			h.ImportPolicy(c)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("import answered %d: %s", recorder.Code, recorder.Body)
+			}
+
+			if !h.casbin.HasPolicy(RoleAdmin, casb.MFAPolicyObject, casb.MFAPolicyAction) {
+				t.Fatal("mfa requirement is lost by the import")
+			}
+			if role := h.subjectRole("user-1", ""); role != RoleAdmin {
+				t.Fatalf("subject assignment is lost by the import, role %q", role)
+			}
+		})
+	}
+}
+
+func TestImportPolicy(t *testing.T) {
+	policy := `p, user, /v1/user/{id}, GET
+p, unauthorized, /v1/user/login, POST
+g, user, unauthorized
+`
+
+	tests := []struct {
+		name     string
+		query    string
+		file     string
+		status   int
+		imported bool
+	}{
+		{
+			name:     "valid",
+			file:     policy + "p, admin, mfa, required\n",
+			status:   http.StatusOK,
+			imported: true,
+		},
+		{
+			name:   "dry run",
+			query:  "&dry_run=true",
+			file:   policy + "p, admin, mfa, required\n",
+			status: http.StatusOK,
+		},
+		{
+			name:   "unmatched",
+			file:   policy + "p, admin, /v1/missing, GET\n",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:     "unmatched forced",
+			query:    "&force=true",
+			file:     policy + "p, admin, /v1/missing, GET\n",
+			status:   http.StatusOK,
+			imported: true,
+		},
+		{
+			name:   "invalid forced",
+			query:  "&force=true",
+			file:   policy + "p, admin, mfa, GET\n",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "malformed",
+			file:   "p, admin\n",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newPolicyTestHandler(t, policy)
+
+			c, recorder := newSuperAdminContext(http.MethodPost, "/v1/rbac/policy/import?format=csv"+tt.query, []byte(tt.file))
+			h.ImportPolicy(c)
+			if recorder.Code != tt.status {
+				t.Fatalf("import answered %d, want %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+
+			imported := len(h.casbin.GetPolicy()) == strings.Count(tt.file, "p, ")
+			if imported != tt.imported {
+				t.Fatalf("policy imported = %v, want %v: %v", imported, tt.imported, h.casbin.GetPolicy())
+			}
+		})
+	}
+}
+
+func TestImportPolicyRequiresSuperAdmin(t *testing.T) {
+	h := newPolicyTestHandler(t, "p, user, /v1/user/{id}, GET\n")
+
+	c, recorder := newSuperAdminContext(http.MethodPost, "/v1/rbac/policy/import", []byte("p, admin, mfa, required\n"))
+	c.Set("role", RoleAdmin)
+	h.ImportPolicy(c)
+
+	if recorder.Code != http.StatusForbidden || h.casbin.HasPolicy(RoleAdmin, casb.MFAPolicyObject, casb.MFAPolicyAction) {
+		t.Fatalf("admin imported a policy, answered %d", recorder.Code)
+	}
+}
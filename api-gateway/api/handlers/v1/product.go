@@ -3,7 +3,9 @@ package v1
 import (
 	"context"
 	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/email"
 	pb "exam/api-gateway/genproto/product-service"
+	pbu "exam/api-gateway/genproto/user-service"
 	"exam/api-gateway/pkg/logger"
 	"fmt"
 	"net/http"
@@ -52,7 +54,7 @@ func (h *handlerV1) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	resp, err := h.serviceManager.ProductService().CreateProduct(ctx, &pb.Product{
@@ -131,7 +133,7 @@ func (h *handlerV1) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	response, err := h.serviceManager.ProductService().UpdateProduct(ctx, &pb.Product{
@@ -180,7 +182,7 @@ func (h *handlerV1) GetProductById(c *gin.Context) {
 		h.log.Error("cannot convert to int", logger.Error(err))
 		return
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	response, err := h.serviceManager.ProductService().GetProductById(ctx, &pb.GetProductId{
@@ -225,7 +227,7 @@ func (h *handlerV1) DeleteProduct(c *gin.Context) {
 		h.log.Error("cannot convert to int", logger.Error(err))
 		return
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	response, err := h.serviceManager.ProductService().DeleteProduct(ctx, &pb.GetProductId{
@@ -283,7 +285,7 @@ func (h *handlerV1) ListProducts(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	response, err := h.serviceManager.ProductService().ListProducts(ctx, &pb.GetListRequest{
@@ -308,19 +310,24 @@ func (h *handlerV1) ListProducts(c *gin.Context) {
 // @Security BearerAuth
 // @Summary get all purchased products by user id
 // @Tags Product
-// @Description get all purchased products by user id
+// @Description get all purchased products by user id, users can only get their own purchases, admins anybody's
 // @Accept json
 // @Produce json
 // @Param page path string true "id"
 // @Success 201 {object} models.PurchasedProductsList
 // @Failure 400 string Error models.ResponseError
+// @Failure 403 string Error models.ResponseError
 // @Failure 500 string Error models.ResponseError
 func (h *handlerV1) GetPurchasedProductsByUserId(c *gin.Context) {
 	var jspbMarshal protojson.MarshalOptions
 	jspbMarshal.UseProtoNames = true
 
 	userId := c.Param("id")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	if !h.CheckOwner(c, userId) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	response, err := h.serviceManager.ProductService().GetPurchasedProductsByUserId(ctx, &pb.GetUserID{
@@ -344,7 +351,7 @@ func (h *handlerV1) GetPurchasedProductsByUserId(c *gin.Context) {
 // @Security BearerAuth
 // @Summary buy a product
 // @Tags Product
-// @Description buy a product
+// @Description buy a product, the purchase is made for the user of the access token
 // @Accept json
 // @Produce json
 // @Param PurchaseInfo body models.BuyProductRequest true "Purchase a product"
@@ -359,6 +366,13 @@ func (h *handlerV1) BuyProduct(c *gin.Context) {
 	)
 
 	jspbMarshal.UseProtoNames = true
+
+	// purchases are always attributed to the authenticated user
+	userId, ok := h.GetUserId(c)
+	if !ok {
+		return
+	}
+
 	err := c.ShouldBindJSON(&body)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ResponseError{
@@ -378,11 +392,15 @@ func (h *handlerV1) BuyProduct(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
+	// the stock is checked and taken by the gateway, users may only buy
+	stockCtx, stockCancel := context.WithTimeout(h.gatewayContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer stockCancel()
+
 	//first check if the product exists
-	status, err := h.serviceManager.ProductService().CheckAmount(ctx, &pb.GetProductId{ProductId: body.ProductId})
+	status, err := h.serviceManager.ProductService().CheckAmount(stockCtx, &pb.GetProductId{ProductId: body.ProductId})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ResponseError{
 			Code:    ErrorCodeInternalServerError,
@@ -413,7 +431,7 @@ func (h *handlerV1) BuyProduct(c *gin.Context) {
 	}
 	//Buy a product
 	buyResp, err := h.serviceManager.ProductService().BuyProduct(ctx, &pb.BuyProductRequest{
-		UserId:    body.UserId,
+		UserId:    userId,
 		ProductId: body.ProductId,
 		Amount:    body.Amount,
 	})
@@ -427,7 +445,7 @@ func (h *handlerV1) BuyProduct(c *gin.Context) {
 		return
 	}
 	//Decrease amount of product from database
-	_, err = h.serviceManager.ProductService().DecreaseProductAmount(ctx, &pb.ProductAmountRequest{
+	_, err = h.serviceManager.ProductService().DecreaseProductAmount(stockCtx, &pb.ProductAmountRequest{
 		ProductId: body.ProductId,
 		AmountBy:  body.Amount,
 	})
@@ -440,9 +458,21 @@ func (h *handlerV1) BuyProduct(c *gin.Context) {
 		return
 	}
 
+	purchasedAt := time.Now()
+	h.notifyById(userId, email.TemplatePurchaseReceipt, func(user *pbu.User) interface{} {
+		return email.PurchaseReceiptData{
+			UserName:    user.FirstName,
+			ProductName: buyResp.Name,
+			Quantity:    body.Amount,
+			Price:       buyResp.Price,
+			Total:       buyResp.Price * float32(body.Amount),
+			PurchasedAt: purchasedAt,
+		}
+	})
+
 	res.Message = "congrats, you've just purchased it!"
 	res.ProductId = body.ProductId
-	res.UserId = body.UserId
+	res.UserId = userId
 	res.Amount = body.Amount
 	res.ProductName = buyResp.Name
 
@@ -452,6 +482,7 @@ func (h *handlerV1) BuyProduct(c *gin.Context) {
 // Increase the amount of product
 // @Router /v1/product/increase [post]
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Summary increase the amount
 // @Tags Product
 // @Description Increase the amount of product
@@ -486,7 +517,7 @@ func (h *handlerV1) IncreaseAmountOfProduct(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	_, err = h.serviceManager.ProductService().IncreaseProductAmount(ctx, &pb.ProductAmountRequest{
@@ -507,6 +538,7 @@ func (h *handlerV1) IncreaseAmountOfProduct(c *gin.Context) {
 // Decrease the amount of product
 // @Router /v1/product/decrease [post]
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Summary decrease the amount
 // @Tags Product
 // @Description Decrease the amount of product
@@ -523,6 +555,7 @@ func (h *handlerV1) DecreaseAmountOfProduct(c *gin.Context) {
 	)
 
 	jspbMarshal.UseProtoNames = true
+
 	err := c.ShouldBindJSON(&body)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ResponseError{
@@ -542,7 +575,7 @@ func (h *handlerV1) DecreaseAmountOfProduct(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	_, err = h.serviceManager.ProductService().DecreaseProductAmount(ctx, &pb.ProductAmountRequest{
@@ -1,8 +1,14 @@
 package v1
 
 import (
+	"database/sql"
+	"errors"
+	casb "exam/api-gateway/api/casbin"
 	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/logger"
+	admin "exam/api-gateway/storage/postgresrepo"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -13,32 +19,37 @@ import (
 // @Security BearerAuth
 // @Summary get all roles
 // @Tags Role-management
-// @Description Get all roles
+// @Description Get all roles with the roles they inherit from
 // @Accept json
 // @Product json
-// @Param super-username query string true "super-username"
-// @Param super-password query string true "super-password"
-// @Success 201 {object} models.RbacAllRolesResp
-// @Failure 400 string error models.Error
+// @Success 201 {object} models.RoleList
 // @Failure 400 string error models.Error
+// @Failure 403 string error models.Error
 func (h *handlerV1) ListAllRoles(c *gin.Context) {
 	var (
 		jspbMarshal protojson.MarshalOptions
 	)
 	jspbMarshal.UseProtoNames = true
 
-	superAdminUsername := c.Query("super-username")
-	superAdminPassword := c.Query("super-password")
-	if superAdminPassword == "b" && superAdminUsername == "a" {
-		roles := h.casbin.GetAllRoles()
-		c.JSON(http.StatusOK, models.RbacAllRolesResp{
-			Roles: roles,
-		})
-	} else {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"message": "you cannot get all roles, provide correct username and password",
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	roles, err := h.roles.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
 		})
+		h.log.Error("cannot list roles", logger.Error(err))
+		return
 	}
+	for _, role := range roles {
+		role.Parents = h.roleParents(role.Name)
+	}
+
+	c.JSON(http.StatusOK, models.RoleList{
+		Roles: roles,
+	})
 }
 
 // List all policies of a role
@@ -49,35 +60,30 @@ func (h *handlerV1) ListAllRoles(c *gin.Context) {
 // @Description Get all policies of a role
 // @Accept json
 // @Product json
-// @Param super-username query string true "super-username"
-// @Param super-password query string true "super-password"
 // @Param role path string true "role"
 // @Success 201 {object} models.ListRolePolicyResp
 // @Failure 400 string error models.Error
-// @Failure 400 string error models.Error
+// @Failure 403 string error models.Error
 func (h *handlerV1) ListRolePolicies(c *gin.Context) {
 	var (
 		jspbMarshal protojson.MarshalOptions
 	)
 	jspbMarshal.UseProtoNames = true
-	superAdminUsername := c.Query("super-username")
-	superAdminPassword := c.Query("super-password")
-	if superAdminPassword == "b" && superAdminUsername == "a" {
-		role := c.Param("role")
-		var response models.ListRolePolicyResp
-		for _, p := range h.casbin.GetFilteredPolicy(0, role) {
-			response.Policies = append(response.Policies, &models.Policy{
-				Role:     p[0],
-				EndPoint: p[1],
-				Method:   p[2],
-			})
-		}
-		c.JSON(http.StatusOK, response)
-	} else {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"message": "you cannot get role policies, provide correct username and password",
+
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	role := c.Param("role")
+	var response models.ListRolePolicyResp
+	for _, p := range h.casbin.GetFilteredPolicy(0, role) {
+		response.Policies = append(response.Policies, &models.Policy{
+			Role:     p[0],
+			EndPoint: p[1],
+			Method:   p[2],
 		})
 	}
+	c.JSON(http.StatusOK, response)
 }
 
 // Add policy to a role
@@ -88,12 +94,10 @@ func (h *handlerV1) ListRolePolicies(c *gin.Context) {
 // @Description Add policy to a role
 // @Accept json
 // @Product json
-// @Param super-username query string true "super-username"
-// @Param super-password query string true "super-password"
 // @Param policy body models.AddPolicyRequest true "policy"
 // @Success 201 {object} models.SuperAdminMessage
 // @Failure 400 string error models.Error
-// @Failure 400 string error models.Error
+// @Failure 403 string error models.Error
 func (h *handlerV1) AddPolicyToRole(c *gin.Context) {
 	var (
 		jspbMarshal protojson.MarshalOptions
@@ -101,34 +105,39 @@ func (h *handlerV1) AddPolicyToRole(c *gin.Context) {
 	)
 	jspbMarshal.UseProtoNames = true
 
-	superAdminUsername := c.Query("super-username")
-	superAdminPassword := c.Query("super-password")
-	if superAdminPassword == "b" && superAdminUsername == "a" {
-		err := c.BindJSON(&body)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   err.Error(),
-				"message": "failed, try again",
-			})
-			return
-		}
-		p := []string{body.Policy.Role, body.Policy.EndPoint, body.Policy.Method}
-		if _, err := h.casbin.AddPolicy(p); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   err.Error(),
-				"message": "failed, try again",
-			})
-			return
-		}
-		h.casbin.SavePolicy()
-		c.JSON(http.StatusOK, models.SuperAdminMessage{
-			Message: "success",
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"message": "failed, try again",
 		})
-	} else {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"message": "you cannot grand permission to the admin role, provide correct username and password",
+		return
+	}
+	if _, ok := h.getRole(c, body.Policy.Role); !ok {
+		return
+	}
+
+	p := []string{body.Policy.Role, body.Policy.EndPoint, body.Policy.Method}
+	if _, err := h.casbin.AddPolicy(p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"message": "failed, try again",
 		})
+		return
 	}
+
+	h.log.Info("policy added",
+		logger.String("superadmin", superAdmin),
+		logger.Any("policy", p))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "success",
+	})
 }
 
 // Delete policy
@@ -139,44 +148,679 @@ func (h *handlerV1) AddPolicyToRole(c *gin.Context) {
 // @Description Delete policy
 // @Accept json
 // @Product json
-// @Param super-username query string true "super-username"
-// @Param super-password query string true "super-password"
 // @Param policy body models.AddPolicyRequest true "policy"
 // @Success 201 {object} models.SuperAdminMessage
 // @Failure 400 string error models.Error
-// @Failure 400 string error models.Error
+// @Failure 403 string error models.Error
 func (h *handlerV1) DeletePolicy(c *gin.Context) {
 	var (
 		jspbMarshal protojson.MarshalOptions
 		body        models.AddPolicyRequest
 	)
 	jspbMarshal.UseProtoNames = true
-	superAdminUsername := c.Query("super-username")
-	superAdminPassword := c.Query("super-password")
-	if superAdminPassword == "b" && superAdminUsername == "a" {
-		err := c.BindJSON(&body)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   err.Error,
-				"message": "failed, try again",
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"message": "failed, try again",
+		})
+		return
+	}
+	p := []string{body.Policy.Role, body.Policy.EndPoint, body.Policy.Method}
+	if _, err := h.casbin.RemovePolicy(p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"message": "failed, try again",
+		})
+		return
+	}
+
+	h.log.Info("policy deleted",
+		logger.String("superadmin", superAdmin),
+		logger.Any("policy", p))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "success",
+	})
+}
+
+// Create role
+// @Router /v1/rbac/roles [post]
+// @Security BearerAuth
+// @Summary create role
+// @Tags Role-management
+// @Description Define a new role, give it policies with /v1/rbac/add/policy or let it inherit from another role
+// @Accept json
+// @Produce json
+// @Param role body models.RoleReq true "role"
+// @Success 201 {object} models.Role
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 409 string error models.ResponseError
+func (h *handlerV1) CreateRole(c *gin.Context) {
+	var body models.RoleReq
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	role := models.Role{
+		Name:        body.Name,
+		Description: body.Description,
+		Parents:     []string{},
+	}
+	err = h.roles.Create(&role)
+	if errors.Is(err, admin.ErrRoleExists) {
+		c.JSON(http.StatusConflict, models.ResponseError{
+			Code:    ErrorCodeAlreadyExists,
+			Message: "this role already exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot create role", logger.Error(err))
+		return
+	}
+
+	h.log.Info("role created",
+		logger.String("superadmin", superAdmin),
+		logger.String("role", role.Name))
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// Get role
+// @Router /v1/rbac/roles/{role} [get]
+// @Security BearerAuth
+// @Summary get role
+// @Tags Role-management
+// @Description Get a role with the roles it inherits from
+// @Produce json
+// @Param role path string true "role"
+// @Success 200 {object} models.Role
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) GetRole(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	role, ok := h.getRole(c, c.Param("role"))
+	if !ok {
+		return
+	}
+	role.Parents = h.roleParents(role.Name)
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Update role
+// @Router /v1/rbac/roles/{role} [put]
+// @Security BearerAuth
+// @Summary update role
+// @Tags Role-management
+// @Description Update the description of a role, roles cannot be renamed
+// @Accept json
+// @Produce json
+// @Param role path string true "role"
+// @Param description body models.RoleReq true "description"
+// @Success 200 {object} models.Role
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) UpdateRole(c *gin.Context) {
+	var body models.RoleReq
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	body.Name = c.Param("role")
+	if err := body.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	role := models.Role{
+		Name:        body.Name,
+		Description: body.Description,
+	}
+	err = h.roles.Update(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "role not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot update role", logger.Error(err))
+		return
+	}
+	role.Parents = h.roleParents(role.Name)
+
+	h.log.Info("role updated",
+		logger.String("superadmin", superAdmin),
+		logger.String("role", role.Name))
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Delete role
+// @Router /v1/rbac/roles/{role} [delete]
+// @Security BearerAuth
+// @Summary delete role
+// @Tags Role-management
+// @Description Delete a role with its policies and grouping rules, built in roles and roles assigned to somebody cannot be deleted
+// @Produce json
+// @Param role path string true "role"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+// @Failure 409 string error models.ResponseError
+func (h *handlerV1) DeleteRole(c *gin.Context) {
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	role, ok := h.getRole(c, c.Param("role"))
+	if !ok {
+		return
+	}
+
+	if role.BuiltIn {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "built in roles cannot be deleted",
+		})
+		return
+	}
+
+	// subjects would silently lose their role, they must be reassigned first
+	for _, rule := range h.casbin.GetFilteredGroupingPolicy(1, role.Name) {
+		if strings.HasPrefix(rule[0], casb.SubjectPrefix) {
+			c.JSON(http.StatusConflict, models.ResponseError{
+				Code:    ErrorCodeAlreadyExists,
+				Message: "the role is assigned to " + strings.TrimPrefix(rule[0], casb.SubjectPrefix) + ", reassign it first",
 			})
 			return
 		}
-		p := []string{body.Policy.Role, body.Policy.EndPoint, body.Policy.Method}
-		if _, err := h.casbin.RemovePolicy(p); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   err.Error,
-				"message": "failed, try again",
+	}
+
+	// keys scoped to the role would stop working without notice
+	keys, err := h.apiKeys.CountByScope(role.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot count api keys of role", logger.Error(err))
+		return
+	}
+	if keys > 0 {
+		c.JSON(http.StatusConflict, models.ResponseError{
+			Code:    ErrorCodeAlreadyExists,
+			Message: "the role is a scope of api keys, revoke them first",
+		})
+		return
+	}
+
+	if err := h.roles.Delete(role.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot delete role", logger.Error(err))
+		return
+	}
+
+	if _, err := h.casbin.DeleteRole(role.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot delete casbin rules of role", logger.Error(err))
+		return
+	}
+	// DeleteRole keeps the rules the role inherits with, a role
+	// created later with the name would get its parents back
+	if _, err := h.casbin.RemoveFilteredGroupingPolicy(0, role.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot delete casbin rules of role", logger.Error(err))
+		return
+	}
+
+	h.log.Info("role deleted",
+		logger.String("superadmin", superAdmin),
+		logger.String("role", role.Name))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "role successfully deleted",
+	})
+}
+
+// List role inheritance
+// @Router /v1/rbac/inheritance [get]
+// @Security BearerAuth
+// @Summary list role inheritance
+// @Tags Role-management
+// @Description List the grouping rules between roles, a role gets every permission of its parent
+// @Produce json
+// @Success 200 {object} models.RoleInheritanceList
+// @Failure 403 string error models.ResponseError
+func (h *handlerV1) ListRoleInheritance(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	response := models.RoleInheritanceList{Rules: []*models.RoleInheritance{}}
+	for _, rule := range h.casbin.GetGroupingPolicy() {
+		if strings.HasPrefix(rule[0], casb.SubjectPrefix) {
+			continue
+		}
+		response.Rules = append(response.Rules, &models.RoleInheritance{
+			Role:   rule[0],
+			Parent: rule[1],
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Add role inheritance
+// @Router /v1/rbac/inheritance [post]
+// @Security BearerAuth
+// @Summary add role inheritance
+// @Tags Role-management
+// @Description Let a role inherit every permission of the parent role, like "g, admin, user"
+// @Accept json
+// @Produce json
+// @Param rule body models.RoleInheritance true "rule"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) AddRoleInheritance(c *gin.Context) {
+	var body models.RoleInheritance
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	if _, ok := h.getRole(c, body.Role); !ok {
+		return
+	}
+	if _, ok := h.getRole(c, body.Parent); !ok {
+		return
+	}
+
+	if body.Role == body.Parent {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "a role cannot inherit from itself",
+		})
+		return
+	}
+
+	ancestors, err := h.casbin.GetImplicitRolesForUser(body.Parent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot get implicit roles", logger.Error(err))
+		return
+	}
+	for _, ancestor := range ancestors {
+		if ancestor == body.Role {
+			c.JSON(http.StatusBadRequest, models.ResponseError{
+				Code:    ErrorBadRequest,
+				Message: body.Parent + " already inherits from " + body.Role,
 			})
 			return
 		}
-		h.casbin.SavePolicy()
-		c.JSON(http.StatusOK, models.SuperAdminMessage{
-			Message: "success",
+	}
+
+	if _, err := h.casbin.AddGroupingPolicy(body.Role, body.Parent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"message": "failed, try again",
+		})
+		return
+	}
+
+	h.log.Info("role inheritance added",
+		logger.String("superadmin", superAdmin),
+		logger.String("role", body.Role),
+		logger.String("parent", body.Parent))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "success",
+	})
+}
+
+// Delete role inheritance
+// @Router /v1/rbac/inheritance [delete]
+// @Security BearerAuth
+// @Summary delete role inheritance
+// @Tags Role-management
+// @Description Stop a role from inheriting the permissions of the parent role
+// @Accept json
+// @Produce json
+// @Param rule body models.RoleInheritance true "rule"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) DeleteRoleInheritance(c *gin.Context) {
+	var body models.RoleInheritance
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	// filtered, so rules seeded with an extra domain field are removed too
+	removed, err := h.casbin.RemoveFilteredGroupingPolicy(0, body.Role, body.Parent)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"message": "failed, try again",
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "the role does not inherit from this parent",
+		})
+		return
+	}
+
+	h.log.Info("role inheritance deleted",
+		logger.String("superadmin", superAdmin),
+		logger.String("role", body.Role),
+		logger.String("parent", body.Parent))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "success",
+	})
+}
+
+// Get subject role
+// @Router /v1/rbac/subjects/{sub}/role [get]
+// @Security BearerAuth
+// @Summary get the role of a subject
+// @Tags Role-management
+// @Description Get the role assigned to a user id or an admin id
+// @Produce json
+// @Param sub path string true "user id or admin id"
+// @Success 200 {object} models.SubjectRole
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) GetSubjectRole(c *gin.Context) {
+	if _, ok := h.GetSuperAdmin(c); !ok {
+		return
+	}
+
+	sub := c.Param("sub")
+	role := h.subjectRole(sub, "")
+	if role == "" {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "no role is assigned, the subject gets the default role of its account",
 		})
-	} else {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"message": "you cannot grand permission to the admin role, provide correct username and password",
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SubjectRole{
+		Subject: sub,
+		Role:    role,
+	})
+}
+
+// Assign subject role
+// @Router /v1/rbac/subjects/{sub}/role [put]
+// @Security BearerAuth
+// @Summary assign a role to a subject
+// @Tags Role-management
+// @Description Assign a role to a user id or an admin id, it replaces the previous one and becomes the role claim of their tokens, every session of the subject is closed
+// @Accept json
+// @Produce json
+// @Param sub path string true "user id or admin id"
+// @Param role body models.SubjectRoleReq true "role"
+// @Success 200 {object} models.SubjectRole
+// @Failure 400 string error models.ResponseError
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) AssignSubjectRole(c *gin.Context) {
+	var body models.SubjectRoleReq
+
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+
+	sub := c.Param("sub")
+	if sub == superAdmin && body.Role != RoleSuperAdmin {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "you cannot take the superadmin role from yourself",
+		})
+		return
+	}
+
+	role, ok := h.getRole(c, body.Role)
+	if !ok {
+		return
+	}
+	if role.Name == casb.RoleUnauthorized {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "the unauthorized role cannot be assigned",
+		})
+		return
+	}
+
+	if err := h.replaceSubjectRole(sub, role.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot assign role", logger.Error(err))
+		return
+	}
+
+	// tokens carry the role, the old ones must not outlive it
+	if _, err := h.RevokeAllSessions(sub); err != nil {
+		h.log.Error("cannot revoke sessions after role assignment", logger.Error(err))
+	}
+
+	h.log.Info("role assigned",
+		logger.String("superadmin", superAdmin),
+		logger.String("subject", sub),
+		logger.String("role", role.Name))
+
+	c.JSON(http.StatusOK, models.SubjectRole{
+		Subject: sub,
+		Role:    role.Name,
+	})
+}
+
+// Unassign subject role
+// @Router /v1/rbac/subjects/{sub}/role [delete]
+// @Security BearerAuth
+// @Summary unassign the role of a subject
+// @Tags Role-management
+// @Description Remove the role assigned to a user id or an admin id, they get the default role of their account again, every session of the subject is closed
+// @Produce json
+// @Param sub path string true "user id or admin id"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 403 string error models.ResponseError
+// @Failure 404 string error models.ResponseError
+func (h *handlerV1) UnassignSubjectRole(c *gin.Context) {
+	superAdmin, ok := h.GetSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	sub := c.Param("sub")
+	removed, err := h.casbin.RemoveFilteredGroupingPolicy(0, subjectKey(sub))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot remove role assignment", logger.Error(err))
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "no role is assigned to this subject",
 		})
+		return
+	}
+
+	if _, err := h.RevokeAllSessions(sub); err != nil {
+		h.log.Error("cannot revoke sessions after role unassignment", logger.Error(err))
 	}
+
+	h.log.Info("role unassigned",
+		logger.String("superadmin", superAdmin),
+		logger.String("subject", sub))
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "success",
+	})
+}
+
+// subjectKey keeps the casbin subjects of role assignments apart
+// from role names, a user id or an admin id can look like a role
+func subjectKey(sub string) string {
+	return casb.SubjectPrefix + sub
+}
+
+// replaceSubjectRole assigns the role to the subject instead of the previous one
+func (h *handlerV1) replaceSubjectRole(sub, role string) error {
+	if _, err := h.casbin.RemoveFilteredGroupingPolicy(0, subjectKey(sub)); err != nil {
+		return err
+	}
+	_, err := h.casbin.AddGroupingPolicy(subjectKey(sub), role)
+	return err
+}
+
+// subjectRole returns the role assigned to the subject of a token,
+// subjects without an assignment get the default role of their account
+func (h *handlerV1) subjectRole(sub, defaultRole string) string {
+	for _, rule := range h.casbin.GetFilteredGroupingPolicy(0, subjectKey(sub)) {
+		return rule[1]
+	}
+
+	return defaultRole
+}
+
+// roleParents returns the roles the role directly inherits from
+func (h *handlerV1) roleParents(role string) []string {
+	parents := []string{}
+	for _, rule := range h.casbin.GetFilteredGroupingPolicy(0, role) {
+		parents = append(parents, rule[1])
+	}
+
+	return parents
+}
+
+// getRole answers 404 and returns false when the role is not defined
+func (h *handlerV1) getRole(c *gin.Context, name string) (*models.Role, bool) {
+	role, err := h.roles.Get(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "role " + name + " is not defined",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot get role", logger.Error(err))
+		return nil, false
+	}
+
+	return role, true
 }
@@ -0,0 +1,394 @@
+package v1
+
+import (
+	"encoding/json"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testRBACPolicy = `p, user, /v1/user/{id}, GET
+p, admin, /v1/users, GET
+g, user, unauthorized
+g, admin, user
+g, superadmin, admin
+`
+
+// newRBACTestHandler has the built in roles, the admins of
+// newAdminTestHandler and the policy in its enforcer
+func newRBACTestHandler(t *testing.T, policy string) (*handlerV1, *adminStorage) {
+	t.Helper()
+
+	h, admins, _ := newAdminTestHandler(t, policy)
+	h.roles = &roleStorage{roles: []*models.Role{
+		{Name: casb.RoleUnauthorized, BuiltIn: true},
+		{Name: RoleUser, BuiltIn: true},
+		{Name: RoleAdmin, BuiltIn: true},
+		{Name: RoleSuperAdmin, BuiltIn: true},
+		{Name: "auditor"},
+	}}
+	h.apiKeys = &apiKeyStorage{}
+	return h, admins
+}
+
+func TestSuperAdminOnlyEndpoints(t *testing.T) {
+	newPolicy := models.AddPolicyRequest{Policy: models.Policy{Role: RoleUser, EndPoint: "/v1/user/export", Method: "GET"}}
+	oldPolicy := models.AddPolicyRequest{Policy: models.Policy{Role: RoleUser, EndPoint: "/v1/user/{id}", Method: "GET"}}
+
+	endpoints := []struct {
+		name     string
+		method   string
+		target   string
+		body     interface{}
+		params   gin.Params
+		handle   func(h *handlerV1, c *gin.Context)
+		wantCode int
+		// done reports whether the endpoint changed anything
+		done func(h *handlerV1, admins *adminStorage) bool
+	}{
+		{
+			name:   "create admin",
+			method: http.MethodPost,
+			target: "/v1/auth/create",
+			body:   models.AdminReq{FullName: "New Admin", Email: "new-admin@example.com", UserName: "new-admin", Password: "new-admin-password"},
+			handle: func(h *handlerV1, c *gin.Context) { h.CreateAdmin(c) },
+			done: func(h *handlerV1, admins *adminStorage) bool {
+				return len(admins.created) != 0
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:   "delete admin",
+			method: http.MethodDelete,
+			target: "/v1/auth/delete",
+			body:   models.DeleteAdmin{Username: "admin", Password: testAdminPass},
+			handle: func(h *handlerV1, c *gin.Context) { h.DeleteAdmin(c) },
+			done: func(h *handlerV1, admins *adminStorage) bool {
+				_, err := admins.GetById(testAdminId)
+				return err != nil
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "list roles",
+			method:   http.MethodGet,
+			target:   "/v1/rbac/roles",
+			handle:   func(h *handlerV1, c *gin.Context) { h.ListAllRoles(c) },
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "list policies of role",
+			method:   http.MethodGet,
+			target:   "/v1/rbac/policies/user",
+			params:   gin.Params{{Key: "role", Value: RoleUser}},
+			handle:   func(h *handlerV1, c *gin.Context) { h.ListRolePolicies(c) },
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "add policy",
+			method: http.MethodPost,
+			target: "/v1/rbac/add/policy",
+			body:   newPolicy,
+			handle: func(h *handlerV1, c *gin.Context) { h.AddPolicyToRole(c) },
+			done: func(h *handlerV1, admins *adminStorage) bool {
+				return h.casbin.HasPolicy(RoleUser, "/v1/user/export", "GET")
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "delete policy",
+			method: http.MethodDelete,
+			target: "/v1/rbac/delete/policy",
+			body:   oldPolicy,
+			handle: func(h *handlerV1, c *gin.Context) { h.DeletePolicy(c) },
+			done: func(h *handlerV1, admins *adminStorage) bool {
+				return !h.casbin.HasPolicy(RoleUser, "/v1/user/{id}", "GET")
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	callers := []struct {
+		name  string
+		sub   string
+		role  string
+		query string
+		allow bool
+	}{
+		{name: "anonymous", role: casb.RoleUnauthorized},
+		{name: "user", sub: testUserId, role: RoleUser},
+		{name: "admin", sub: testAdminId, role: RoleAdmin},
+		// the credentials that used to open these endpoints open nothing
+		{name: "admin with superadmin credentials in the url", sub: testAdminId, role: RoleAdmin, query: "?super-username=a&super-password=b"},
+		{name: "superadmin without subject", role: RoleSuperAdmin},
+		{name: "superadmin", sub: testSuperAdminId, role: RoleSuperAdmin, allow: true},
+	}
+
+	for _, endpoint := range endpoints {
+		for _, caller := range callers {
+			t.Run(endpoint.name+"/"+caller.name, func(t *testing.T) {
+				h, admins := newRBACTestHandler(t, testRBACPolicy)
+
+				c, recorder := newRequestContext(endpoint.method, endpoint.target+caller.query, endpoint.body, caller.sub, caller.role, endpoint.params...)
+				if caller.sub == "" {
+					c.Set("role", caller.role)
+				}
+				endpoint.handle(h, c)
+
+				wantCode := http.StatusForbidden
+				if caller.allow {
+					wantCode = endpoint.wantCode
+				}
+				if recorder.Code != wantCode {
+					t.Fatalf("code = %d, want %d: %s", recorder.Code, wantCode, recorder.Body)
+				}
+				if endpoint.done != nil && endpoint.done(h, admins) != caller.allow {
+					t.Fatalf("changed = %v, want %v", !caller.allow, caller.allow)
+				}
+			})
+		}
+	}
+}
+
+func TestCreateRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		wantCode int
+	}{
+		{name: "new role", role: "support", wantCode: http.StatusCreated},
+		{name: "defined role", role: RoleUser, wantCode: http.StatusConflict},
+		{name: "invalid name", role: "Support Team", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy)
+
+			c, recorder := newAdminContext(http.MethodPost, "/v1/rbac/roles", models.RoleReq{Name: tt.role, Description: "answers customers"})
+			h.CreateRole(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusCreated {
+				return
+			}
+
+			c, recorder = newAdminContext(http.MethodGet, "/v1/rbac/roles/"+tt.role, nil, gin.Param{Key: "role", Value: tt.role})
+			h.GetRole(c)
+			var role models.Role
+			if err := json.Unmarshal(recorder.Body.Bytes(), &role); err != nil {
+				t.Fatal(err)
+			}
+			if recorder.Code != http.StatusOK || role.Name != tt.role || role.Description != "answers customers" || role.BuiltIn {
+				t.Fatalf("get answered %d: %s", recorder.Code, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestUpdateRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		wantCode int
+	}{
+		{name: "defined role", role: "auditor", wantCode: http.StatusOK},
+		{name: "unknown role", role: "support", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy+"g, auditor, admin\n")
+
+			c, recorder := newAdminContext(http.MethodPut, "/v1/rbac/roles/"+tt.role, models.RoleReq{Description: "reads everything"}, gin.Param{Key: "role", Value: tt.role})
+			h.UpdateRole(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var role models.Role
+			if err := json.Unmarshal(recorder.Body.Bytes(), &role); err != nil {
+				t.Fatal(err)
+			}
+			if role.Description != "reads everything" || len(role.Parents) != 1 || role.Parents[0] != RoleAdmin {
+				t.Fatalf("role = %+v", role)
+			}
+		})
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		policy   string
+		keys     []*models.APIKey
+		wantCode int
+	}{
+		{name: "custom role", role: "auditor", policy: "p, auditor, /v1/users, GET\ng, auditor, user\n", wantCode: http.StatusOK},
+		{name: "built in role", role: RoleAdmin, wantCode: http.StatusBadRequest},
+		{name: "unknown role", role: "support", wantCode: http.StatusNotFound},
+		{name: "assigned role", role: "auditor", policy: "g, sub:" + testAdminId + ", auditor\n", wantCode: http.StatusConflict},
+		{name: "scope of an api key", role: "auditor", keys: []*models.APIKey{{Id: "key", Scopes: []string{"auditor"}}}, wantCode: http.StatusConflict},
+		{name: "scope of a revoked api key", role: "auditor", keys: []*models.APIKey{{Id: "key", Scopes: []string{"auditor"}, RevokedAt: &time.Time{}}}, wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy+tt.policy)
+			h.apiKeys = &apiKeyStorage{keys: tt.keys}
+
+			c, recorder := newAdminContext(http.MethodDelete, "/v1/rbac/roles/"+tt.role, nil, gin.Param{Key: "role", Value: tt.role})
+			h.DeleteRole(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+
+			_, err := h.roles.Get(tt.role)
+			if deleted := err != nil; deleted != (tt.wantCode == http.StatusOK || tt.wantCode == http.StatusNotFound) {
+				t.Fatalf("deleted = %v", deleted)
+			}
+			if tt.wantCode == http.StatusOK {
+				if len(h.casbin.GetFilteredPolicy(0, tt.role)) != 0 || len(h.casbin.GetFilteredGroupingPolicy(0, tt.role)) != 0 {
+					t.Fatal("rules of the deleted role are left")
+				}
+			}
+		})
+	}
+}
+
+func TestAddRoleInheritance(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     models.RoleInheritance
+		wantCode int
+	}{
+		{name: "new parent", rule: models.RoleInheritance{Role: "auditor", Parent: RoleAdmin}, wantCode: http.StatusOK},
+		{name: "itself", rule: models.RoleInheritance{Role: "auditor", Parent: "auditor"}, wantCode: http.StatusBadRequest},
+		{name: "direct cycle", rule: models.RoleInheritance{Role: RoleUser, Parent: RoleAdmin}, wantCode: http.StatusBadRequest},
+		{name: "cycle through a chain", rule: models.RoleInheritance{Role: casb.RoleUnauthorized, Parent: RoleSuperAdmin}, wantCode: http.StatusBadRequest},
+		{name: "unknown role", rule: models.RoleInheritance{Role: "support", Parent: RoleUser}, wantCode: http.StatusNotFound},
+		{name: "unknown parent", rule: models.RoleInheritance{Role: "auditor", Parent: "support"}, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy)
+			rules := len(h.casbin.GetGroupingPolicy())
+
+			c, recorder := newAdminContext(http.MethodPost, "/v1/rbac/inheritance", tt.rule)
+			h.AddRoleInheritance(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				if len(h.casbin.GetGroupingPolicy()) != rules {
+					t.Fatal("a rejected rule was added")
+				}
+				return
+			}
+			// the role gets the permissions of the parent and of its parents
+			for _, want := range [][]interface{}{{tt.rule.Role, "/v1/users", "GET"}, {tt.rule.Role, "/v1/user/{id}", "GET"}} {
+				if allowed, err := h.casbin.Enforce(want...); err != nil || !allowed {
+					t.Fatalf("%v is not allowed: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteRoleInheritance(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     models.RoleInheritance
+		wantCode int
+	}{
+		{name: "inherited parent", rule: models.RoleInheritance{Role: RoleAdmin, Parent: RoleUser}, wantCode: http.StatusOK},
+		{name: "not a parent", rule: models.RoleInheritance{Role: RoleUser, Parent: RoleAdmin}, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy)
+
+			c, recorder := newAdminContext(http.MethodDelete, "/v1/rbac/inheritance", tt.rule)
+			h.DeleteRoleInheritance(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			if allowed, _ := h.casbin.Enforce(tt.rule.Role, "/v1/user/{id}", "GET"); allowed {
+				t.Fatal("the role still has the permissions of the parent")
+			}
+		})
+	}
+}
+
+func TestAssignSubjectRole(t *testing.T) {
+	tests := []struct {
+		name         string
+		sub          string
+		role         string
+		wantCode     int
+		wantAssigned string
+	}{
+		{name: "defined role", sub: testUserId, role: "auditor", wantCode: http.StatusOK, wantAssigned: "auditor"},
+		{name: "built in role", sub: testAdminId, role: RoleSuperAdmin, wantCode: http.StatusOK, wantAssigned: RoleSuperAdmin},
+		{name: "unknown role", sub: testUserId, role: "support", wantCode: http.StatusNotFound},
+		{name: "unauthorized role", sub: testUserId, role: casb.RoleUnauthorized, wantCode: http.StatusBadRequest},
+		{name: "superadmin demoting itself", sub: testSuperAdminId, role: RoleAdmin, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newRBACTestHandler(t, testRBACPolicy)
+			sessions := h.sessions.(*sessionStorage)
+			sessions.sessions["session"] = &models.Session{Id: "session", UserId: tt.sub}
+
+			c, recorder := newAdminContext(http.MethodPut, "/v1/rbac/subjects/"+tt.sub+"/role", models.SubjectRoleReq{Role: tt.role}, gin.Param{Key: "sub", Value: tt.sub})
+			h.AssignSubjectRole(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+
+			// the assigned role becomes the role claim of the next tokens
+			if role := h.subjectRole(tt.sub, ""); role != tt.wantAssigned {
+				t.Fatalf("assigned %q, want %q", role, tt.wantAssigned)
+			}
+			if revoked := sessions.sessions["session"].Revoked; revoked != (tt.wantCode == http.StatusOK) {
+				t.Fatalf("session revoked = %v", revoked)
+			}
+		})
+	}
+}
+
+func TestUnassignSubjectRole(t *testing.T) {
+	h, _ := newRBACTestHandler(t, testRBACPolicy+"g, sub:"+testUserId+", auditor\n")
+	unassign := func() int {
+		c, recorder := newAdminContext(http.MethodDelete, "/v1/rbac/subjects/"+testUserId+"/role", nil, gin.Param{Key: "sub", Value: testUserId})
+		h.UnassignSubjectRole(c)
+		return recorder.Code
+	}
+
+	if code := unassign(); code != http.StatusOK {
+		t.Fatalf("unassign answered %d", code)
+	}
+	if role := h.subjectRole(testUserId, RoleUser); role != RoleUser {
+		t.Fatalf("role = %q, want the default role of the account", role)
+	}
+	if code := unassign(); code != http.StatusNotFound {
+		t.Fatalf("second unassign answered %d", code)
+	}
+}
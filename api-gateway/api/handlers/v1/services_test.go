@@ -0,0 +1,249 @@
+package v1
+
+import (
+	"context"
+	pbp "exam/api-gateway/genproto/product-service"
+	pbu "exam/api-gateway/genproto/user-service"
+	"exam/api-gateway/services"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serviceManager hands the fakes of the services to the handlers
+type serviceManager struct {
+	users    *userService
+	products *productService
+}
+
+func (s *serviceManager) UserService() pbu.UserServiceClient       { return s.users }
+func (s *serviceManager) ProductService() pbp.ProductServiceClient { return s.products }
+
+var _ services.IServiceManager = (*serviceManager)(nil)
+
+func newServiceManager() *serviceManager {
+	return &serviceManager{
+		users:    &userService{users: map[string]*pbu.User{}},
+		products: &productService{stock: map[int32]int32{}},
+	}
+}
+
+// userService keeps the users of the tests in memory, deleted users
+// have DeletedAt set like the user service marks them
+type userService struct {
+	mu    sync.Mutex
+	users map[string]*pbu.User
+}
+
+func (u *userService) add(user *pbu.User) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.users[user.Id] = user
+}
+
+func (u *userService) get(id string) (*pbu.User, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.users[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *user
+	return &copied, true
+}
+
+// active returns the user with the email that is not deleted
+func (u *userService) active(email string) *pbu.User {
+	for _, user := range u.users {
+		if user.Email == email && user.DeletedAt == "" {
+			return user
+		}
+	}
+	return nil
+}
+
+func (u *userService) CreateUser(ctx context.Context, in *pbu.User, opts ...grpc.CallOption) (*pbu.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	created := *in
+	u.users[in.Id] = &created
+	return in, nil
+}
+
+func (u *userService) GetUserById(ctx context.Context, in *pbu.GetUserId, opts ...grpc.CallOption) (*pbu.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.users[in.UserId]
+	if !ok || user.DeletedAt != "" {
+		return nil, status.Error(codes.Unknown, "sql: no rows in result set")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (u *userService) UpdateUser(ctx context.Context, in *pbu.User, opts ...grpc.CallOption) (*pbu.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.users[in.Id]
+	if !ok || user.DeletedAt != "" {
+		return nil, status.Error(codes.Unknown, "sql: no rows in result set")
+	}
+	user.FirstName, user.LastName, user.Age = in.FirstName, in.LastName, in.Age
+	copied := *user
+	return &copied, nil
+}
+
+func (u *userService) DeleteUser(ctx context.Context, in *pbu.GetUserId, opts ...grpc.CallOption) (*pbu.Status, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.users[in.UserId]
+	if !ok || user.DeletedAt != "" {
+		return &pbu.Status{Success: false}, nil
+	}
+	user.DeletedAt = "2026-01-01T00:00:00Z"
+	return &pbu.Status{Success: true}, nil
+}
+
+func (u *userService) ListUsers(ctx context.Context, in *pbu.GetListRequest, opts ...grpc.CallOption) (*pbu.GetListResponse, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	list := &pbu.GetListResponse{}
+	for _, user := range u.users {
+		if user.DeletedAt != "" && !in.IncludeDeleted {
+			continue
+		}
+		copied := *user
+		list.Users = append(list.Users, &copied)
+		list.Count++
+	}
+	return list, nil
+}
+
+func (u *userService) CheckField(ctx context.Context, in *pbu.CheckFieldRequest, opts ...grpc.CallOption) (*pbu.CheckFieldResponse, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return &pbu.CheckFieldResponse{Status: in.Field == "email" && u.active(in.Data) != nil}, nil
+}
+
+func (u *userService) Check(ctx context.Context, in *pbu.IfExists, opts ...grpc.CallOption) (*pbu.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user := u.active(in.Email)
+	if user == nil {
+		return nil, status.Error(codes.Unknown, "sql: no rows in result set")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (u *userService) UpdateRefreshToken(ctx context.Context, in *pbu.UpdateRefreshTokenReq, opts ...grpc.CallOption) (*pbu.Status, error) {
+	return &pbu.Status{Success: true}, nil
+}
+
+func (u *userService) UpdatePassword(ctx context.Context, in *pbu.UpdatePasswordReq, opts ...grpc.CallOption) (*pbu.Status, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.users[in.UserId]
+	if !ok || user.DeletedAt != "" {
+		return &pbu.Status{Success: false}, nil
+	}
+	user.Password = in.Password
+	return &pbu.Status{Success: true}, nil
+}
+
+func (u *userService) ChangePassword(ctx context.Context, in *pbu.ChangePasswordReq, opts ...grpc.CallOption) (*pbu.Status, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.users[in.UserId]
+	if !ok || user.DeletedAt != "" {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(in.CurrentPassword)) != nil {
+		return nil, status.Error(codes.InvalidArgument, "current password is incorrect")
+	}
+	password, err := bcrypt.GenerateFromPassword([]byte(in.NewPassword), bcrypt.MinCost)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	user.Password = string(password)
+	return &pbu.Status{Success: true}, nil
+}
+
+func (u *userService) UpdateEmail(ctx context.Context, in *pbu.UpdateEmailReq, opts ...grpc.CallOption) (*pbu.Status, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.users[in.UserId]
+	if !ok || user.DeletedAt != "" {
+		return &pbu.Status{Success: false}, nil
+	}
+	user.Email = in.Email
+	return &pbu.Status{Success: true}, nil
+}
+
+func (u *userService) RestoreUser(ctx context.Context, in *pbu.GetUserId, opts ...grpc.CallOption) (*pbu.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.users[in.UserId]
+	if !ok || user.DeletedAt == "" {
+		return nil, status.Error(codes.NotFound, "deleted user not found")
+	}
+	if u.active(user.Email) != nil {
+		return nil, status.Error(codes.AlreadyExists, "the email of the user is used by another user")
+	}
+	user.DeletedAt = ""
+	copied := *user
+	return &copied, nil
+}
+
+// productService answers only what the user and purchase handlers ask for,
+// the other rpcs are not called by the tests
+type productService struct {
+	pbp.ProductServiceClient
+
+	mu        sync.Mutex
+	stock     map[int32]int32
+	purchases []*pbp.BuyProductRequest
+}
+
+func (p *productService) GetPurchasedProductsByUserId(ctx context.Context, in *pbp.GetUserID, opts ...grpc.CallOption) (*pbp.GetPurchasedProductsResponse, error) {
+	return &pbp.GetPurchasedProductsResponse{}, nil
+}
+
+func (p *productService) CheckAmount(ctx context.Context, in *pbp.GetProductId, opts ...grpc.CallOption) (*pbp.CheckAmountResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return &pbp.CheckAmountResponse{ProductId: in.ProductId, Amount: p.stock[in.ProductId]}, nil
+}
+
+func (p *productService) BuyProduct(ctx context.Context, in *pbp.BuyProductRequest, opts ...grpc.CallOption) (*pbp.Product, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.purchases = append(p.purchases, in)
+	return &pbp.Product{Id: in.ProductId, Name: "Notebook", Price: 12.5, Amount: in.Amount}, nil
+}
+
+func (p *productService) DecreaseProductAmount(ctx context.Context, in *pbp.ProductAmountRequest, opts ...grpc.CallOption) (*pbp.ProductAmountResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stock[in.ProductId] -= in.AmountBy
+	return &pbp.ProductAmountResponse{}, nil
+}
@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/api/handlers/v1/tokens"
+	"exam/api-gateway/pkg/etc"
+	"exam/api-gateway/pkg/logger"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxDeviceIdLength = 200
+
+// NewSession issues an access and refresh token pair bound to a new session
+// of the calling device, the previous session of that device is revoked
+func (h *handlerV1) NewSession(c *gin.Context, sub, role string) (string, string, error) {
+	session := models.Session{
+		Id:        uuid.NewString(),
+		UserId:    sub,
+		Role:      role,
+		DeviceId:  GetDeviceId(c),
+		UserAgent: c.Request.UserAgent(),
+		ExpiresAt: time.Now().Add(tokens.RefreshTokenLifetime(h.cfg.RefreshTokenTimeout)),
+	}
+
+	jwtHandler := tokens.JWTHandler{
+		Sub:            sub,
+		Role:           role,
+		Keys:           h.jwtKeys,
+		Log:            h.log,
+		Timeout:        h.cfg.AccessTokenTimeout,
+		RefreshTimeout: h.cfg.RefreshTokenTimeout,
+		SessionId:      session.Id,
+	}
+
+	access, refresh, err := jwtHandler.GenerateAuthJWT()
+	if err != nil {
+		return "", "", err
+	}
+
+	session.RefreshTokenHash = etc.HashToken(refresh)
+	if err := h.sessions.Create(&session); err != nil {
+		return "", "", err
+	}
+
+	return access, refresh, nil
+}
+
+// GetDeviceId identifies the device by the X-Device-Id header,
+// clients that do not send it are told apart by their user agent
+func GetDeviceId(c *gin.Context) string {
+	deviceId := strings.TrimSpace(c.GetHeader("X-Device-Id"))
+	if deviceId == "" {
+		deviceId = c.Request.UserAgent()
+	}
+	if deviceId == "" {
+		return "unknown"
+	}
+	if len(deviceId) > maxDeviceIdLength {
+		return etc.HashToken(deviceId)
+	}
+
+	return deviceId
+}
+
+// CloseSession denies the access token of the request until it expires
+// and closes the session its refresh token belongs to
+func (h *handlerV1) CloseSession(c *gin.Context) {
+	claims, ok := c.Get("claims")
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "access token is required",
+		})
+		return
+	}
+	tokenClaims := claims.(*tokens.Claims)
+
+	jti := tokenClaims.ID
+	if jti == "" {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: "access token cannot be revoked, it has no id",
+		})
+		return
+	}
+
+	ttl := time.Until(tokenClaims.ExpiresAt.Time)
+	if ttl > 0 {
+		err := h.inMemoryStorage.SetWithTTL(tokens.DenylistKey(jti), "1", int(ttl.Seconds())+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ResponseError{
+				Code:    ErrorCodeInternalServerError,
+				Message: err.Error(),
+			})
+			h.log.Error("cannot deny access token", logger.Error(err))
+			return
+		}
+	}
+
+	if sessionId := tokenClaims.SessionId; sessionId != "" {
+		if err := h.sessions.Revoke(sessionId); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ResponseError{
+				Code:    ErrorCodeInternalServerError,
+				Message: err.Error(),
+			})
+			h.log.Error("cannot revoke session", logger.Error(err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.SuperAdminMessage{
+		Message: "logged out",
+	})
+}
+
+// RevokeAllSessions closes every session of the subject and
+// denies all of its access tokens issued until now
+func (h *handlerV1) RevokeAllSessions(sub string) (int64, error) {
+	if err := h.DenyAccessTokens(sub); err != nil {
+		return 0, err
+	}
+
+	return h.sessions.RevokeAll(sub)
+}
+
+// DenyAccessTokens denies every access token of the subject issued
+// until now, its refresh token sessions are left open
+func (h *handlerV1) DenyAccessTokens(sub string) error {
+	if sub == "" {
+		return errors.New("subject is empty")
+	}
+
+	ttl := tokens.AccessTokenLifetime(h.cfg.AccessTokenTimeout)
+	notBefore := strconv.FormatInt(time.Now().Unix(), 10)
+	return h.inMemoryStorage.SetWithTTL(tokens.NotBeforeKey(sub), notBefore, int(ttl.Seconds())+1)
+}
@@ -0,0 +1,208 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/api/handlers/v1/tokens"
+	"exam/api-gateway/config"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sessionStorage keeps the sessions of the tests in memory
+type sessionStorage struct {
+	mu       sync.Mutex
+	sessions map[string]*models.Session
+}
+
+func (s *sessionStorage) Create(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.sessions {
+		if other.UserId == session.UserId && other.DeviceId == session.DeviceId {
+			other.Revoked = true
+		}
+	}
+	created := *session
+	created.CreatedAt = time.Now()
+	s.sessions[session.Id] = &created
+	return nil
+}
+
+func (s *sessionStorage) Get(id string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (s *sessionStorage) Rotate(id, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.Revoked || session.RefreshTokenHash != oldHash {
+		return false, nil
+	}
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (s *sessionStorage) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok {
+		session.Revoked = true
+	}
+	return nil
+}
+
+func (s *sessionStorage) RevokeAll(userId string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var revoked int64
+	for _, session := range s.sessions {
+		if session.UserId == userId && !session.Revoked {
+			session.Revoked = true
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func newSessionTestHandler(t *testing.T) (*handlerV1, *memoryStorage, *sessionStorage) {
+	t.Helper()
+
+	h, storage := newTestHandler(t)
+	keys, err := tokens.LoadKeys(config.Config{
+		SignInKey:   "test-sign-in-key",
+		JWTIssuer:   "exam-api-gateway",
+		JWTAudience: "exam",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := &sessionStorage{sessions: map[string]*models.Session{}}
+	h.jwtKeys = keys
+	h.sessions = sessions
+	return h, storage, sessions
+}
+
+// refresh exchanges the refresh token and returns the status and the new token pair
+func refresh(h *handlerV1, refreshToken string) (int, models.AccessTokenUpdateResp) {
+	body, _ := json.Marshal(models.AccessTokenUpdateReq{RefreshToken: refreshToken})
+
+	c, recorder := newTestContext("10.0.1.1")
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/user/refresh", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.UpdateRefreshToken(c)
+
+	var resp models.AccessTokenUpdateResp
+	_ = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	return recorder.Code, resp
+}
+
+func TestUpdateRefreshToken(t *testing.T) {
+	h, storage, sessions := newSessionTestHandler(t)
+
+	c, _ := newTestContext("10.0.1.1")
+	access, _, err := h.NewSession(c, "user-1", RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  func() string
+		status int
+	}{
+		{name: "access token", token: func() string { return access }, status: http.StatusUnauthorized},
+		{name: "garbage", token: func() string { return "not-a-token" }, status: http.StatusUnauthorized},
+		{name: "unknown session", token: func() string {
+			_, refresh, _ := tokens.JWTHandler{Sub: "user-1", Keys: h.jwtKeys, Log: h.log, Timeout: 15, RefreshTimeout: 720, SessionId: "missing"}.GenerateAuthJWT()
+			return refresh
+		}, status: http.StatusUnauthorized},
+		{name: "another subject", token: func() string {
+			var sessionId string
+			for id := range sessions.sessions {
+				sessionId = id
+			}
+			_, refresh, _ := tokens.JWTHandler{Sub: "user-2", Keys: h.jwtKeys, Log: h.log, Timeout: 15, RefreshTimeout: 720, SessionId: sessionId}.GenerateAuthJWT()
+			return refresh
+		}, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := refresh(h, tt.token()); status != tt.status {
+				t.Fatalf("refresh answered %d, want %d", status, tt.status)
+			}
+		})
+	}
+
+	if _, err := storage.Get(tokens.NotBeforeKey("user-1")); err == nil {
+		t.Fatal("access tokens are denied by refresh tokens that were never issued")
+	}
+}
+
+func TestUpdateRefreshTokenRotation(t *testing.T) {
+	h, storage, sessions := newSessionTestHandler(t)
+
+	c, _ := newTestContext("10.0.1.1")
+	_, first, err := h.NewSession(c, "user-1", RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, second := refresh(h, first)
+	if status != http.StatusOK || second.RefreshToken == "" || second.AccessToken == "" {
+		t.Fatalf("first refresh answered %d", status)
+	}
+	if second.RefreshToken == first {
+		t.Fatal("refresh token was not rotated")
+	}
+	if _, err := storage.Get(tokens.NotBeforeKey("user-1")); err == nil {
+		t.Fatal("a rotation denied the access tokens of the user")
+	}
+
+	status, third := refresh(h, second.RefreshToken)
+	if status != http.StatusOK {
+		t.Fatalf("refresh with the rotated token answered %d", status)
+	}
+
+	// the first token was exchanged already, presenting it again is a reuse
+	if status, _ := refresh(h, first); status != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token answered %d", status)
+	}
+
+	claims, err := tokens.ExtractClaim(third.RefreshToken, h.jwtKeys, tokens.TypeRefresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sessions.sessions[claims.SessionId].Revoked {
+		t.Fatal("session stays open after a reuse")
+	}
+	if _, err := storage.Get(tokens.NotBeforeKey("user-1")); err != nil {
+		t.Fatal("access tokens of the session stay valid after a reuse")
+	}
+
+	// the legitimate holder has to login again too
+	if status, _ := refresh(h, third.RefreshToken); status != http.StatusUnauthorized {
+		t.Fatalf("refresh of a revoked session answered %d", status)
+	}
+}
@@ -0,0 +1,253 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"exam/api-gateway/config"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Keys holds the key tokens are signed with and every key
+// that is still accepted for verification during a rotation
+type Keys struct {
+	method     jwt.SigningMethod
+	signingKey interface{}
+	keyId      string
+	publicKeys map[string]interface{}
+	issuer     string
+	audience   string
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeys reads the signing key and the verification keys from the config,
+// verification keys are <kid>.pem public keys in cfg.VerificationKeysDir
+func LoadKeys(cfg config.Config) (*Keys, error) {
+	keys := &Keys{
+		keyId:      cfg.SigningKeyId,
+		publicKeys: make(map[string]interface{}),
+		issuer:     cfg.JWTIssuer,
+		audience:   cfg.JWTAudience,
+	}
+
+	switch cfg.SigningAlgorithm {
+	case AlgorithmHS256, "":
+		if cfg.SignInKey == "" {
+			return nil, errors.New("SIGN_IN_KEY is required to sign tokens with HS256")
+		}
+		keys.method = jwt.SigningMethodHS256
+		keys.signingKey = []byte(cfg.SignInKey)
+		return keys, nil
+	case AlgorithmRS256:
+		keys.method = jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		keys.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.SigningAlgorithm)
+	}
+
+	if keys.keyId == "" {
+		return nil, errors.New("signing key id is required")
+	}
+
+	privateKey, err := readPrivateKey(cfg.SigningKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if keys.method != jwt.SigningMethodRS256 {
+			return nil, errors.New("signing key is not an ed25519 key")
+		}
+		keys.signingKey = key
+		keys.publicKeys[keys.keyId] = &key.PublicKey
+	case ed25519.PrivateKey:
+		if keys.method != jwt.SigningMethodEdDSA {
+			return nil, errors.New("signing key is not an rsa key")
+		}
+		keys.signingKey = key
+		keys.publicKeys[keys.keyId] = key.Public()
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", privateKey)
+	}
+
+	if cfg.VerificationKeysDir == "" {
+		return keys, nil
+	}
+
+	// every pem of the directory is read as a public key, so the
+	// private key has to live somewhere else
+	signingDir, err := filepath.Abs(filepath.Dir(cfg.SigningKeyPath))
+	if err != nil {
+		return nil, err
+	}
+	verificationDir, err := filepath.Abs(cfg.VerificationKeysDir)
+	if err != nil {
+		return nil, err
+	}
+	if signingDir == verificationDir {
+		return nil, errors.New("signing key must not be kept in the verification keys directory")
+	}
+
+	files, err := filepath.Glob(filepath.Join(cfg.VerificationKeysDir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		if kid == keys.keyId {
+			continue
+		}
+
+		publicKey, err := readPublicKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read verification key %s: %w", file, err)
+		}
+		keys.publicKeys[kid] = publicKey
+	}
+
+	return keys, nil
+}
+
+// Sign signs the claims with the active key and sets its kid header
+func (k *Keys) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.keyId != "" {
+		token.Header["kid"] = k.keyId
+	}
+
+	return token.SignedString(k.signingKey)
+}
+
+// Keyfunc picks the verification key by the kid header of the token
+func (k *Keys) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+
+	if k.method == jwt.SigningMethodHS256 {
+		return k.signingKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = k.keyId
+	}
+
+	key, ok := k.publicKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+// JWKS returns the public verification keys, nothing is published for HS256
+func (k *Keys) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for kid, key := range k.publicKeys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: AlgorithmRS256,
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: AlgorithmEdDSA,
+				Kid: kid,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(key),
+			})
+		}
+	}
+
+	return jwks
+}
+
+func readPrivateKey(path string) (interface{}, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+func readPublicKey(path string) (interface{}, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+
+	return block, nil
+}
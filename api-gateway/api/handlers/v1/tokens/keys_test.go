@@ -0,0 +1,114 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"exam/api-gateway/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKeyPair writes an ed25519 private key to privateDir/signing.pem
+// and its public key to publicDir/<kid>.pem
+func writeKeyPair(t *testing.T, privateDir, publicDir, kid string) string {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateDer, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDer, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{privateDir, publicDir} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	privatePath := filepath.Join(privateDir, "signing.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(publicDir, kid+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return privatePath
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	// the key rotated out keeps verifying until its tokens expire
+	writeKeyPair(t, filepath.Join(dir, "old"), filepath.Join(dir, "public"), "old")
+	signingKey := writeKeyPair(t, filepath.Join(dir, "private"), filepath.Join(dir, "public"), "new")
+	sharedKey := writeKeyPair(t, filepath.Join(dir, "shared"), filepath.Join(dir, "shared"), "new")
+
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr bool
+		kids    []string
+	}{
+		{name: "hs256", cfg: config.Config{SigningAlgorithm: AlgorithmHS256, SignInKey: "secret"}},
+		{name: "hs256 by default", cfg: config.Config{SignInKey: "secret"}},
+		{name: "hs256 without key", cfg: config.Config{SigningAlgorithm: AlgorithmHS256}, wantErr: true},
+		{name: "unknown algorithm", cfg: config.Config{SigningAlgorithm: "none", SignInKey: "secret"}, wantErr: true},
+		{name: "eddsa without key id", cfg: config.Config{SigningAlgorithm: AlgorithmEdDSA, SigningKeyPath: signingKey}, wantErr: true},
+		{name: "eddsa with rsa algorithm", cfg: config.Config{SigningAlgorithm: AlgorithmRS256, SigningKeyPath: signingKey, SigningKeyId: "new"}, wantErr: true},
+		{name: "eddsa", cfg: config.Config{SigningAlgorithm: AlgorithmEdDSA, SigningKeyPath: signingKey, SigningKeyId: "new"}, kids: []string{"new"}},
+		{
+			name: "eddsa with verification keys",
+			cfg: config.Config{
+				SigningAlgorithm:    AlgorithmEdDSA,
+				SigningKeyPath:      signingKey,
+				SigningKeyId:        "new",
+				VerificationKeysDir: filepath.Join(dir, "public"),
+			},
+			kids: []string{"new", "old"},
+		},
+		{
+			name: "private key among verification keys",
+			cfg: config.Config{
+				SigningAlgorithm:    AlgorithmEdDSA,
+				SigningKeyPath:      sharedKey,
+				SigningKeyId:        "new",
+				VerificationKeysDir: filepath.Join(dir, "shared"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := LoadKeys(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			jwks := keys.JWKS()
+			if len(jwks.Keys) != len(tt.kids) {
+				t.Fatalf("JWKS() has %d keys, want %v", len(jwks.Keys), tt.kids)
+			}
+			for _, kid := range tt.kids {
+				if _, ok := keys.publicKeys[kid]; !ok {
+					t.Fatalf("key %q is not accepted", kid)
+				}
+			}
+		})
+	}
+}
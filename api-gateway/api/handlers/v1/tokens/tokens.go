@@ -1,54 +1,80 @@
 package tokens
 
 import (
+	"errors"
 	"exam/api-gateway/pkg/logger"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+	TypeMFA     = "mfa"
+	TypeVerify  = "verify"
+)
+
+// MFATokenLifetime is how long the second factor can be entered after the password
+const MFATokenLifetime = time.Minute * 5
+
+// VerifyTokenLifetime is how long a magic link of a registration can be opened
+const VerifyTokenLifetime = time.Minute * 30
+
+// ErrWrongTokenType is returned when a refresh token is presented as an access token or vice versa
+var ErrWrongTokenType = errors.New("token has wrong type")
+
 type JWTHandler struct {
-	Sub       string
-	Exp       string
-	Role      string
-	SignInKey string
-	Log       logger.Logger
-	Token     string
-	Timeout   int
+	Sub            string
+	Role           string
+	Keys           *Keys
+	Log            logger.Logger
+	Timeout        int //access token lifetime in minutes
+	RefreshTimeout int //refresh token lifetime in hours
+	SessionId      string
+}
+
+// Claims are the claims of both access and refresh tokens, Type tells them apart
+type Claims struct {
+	Role      string `json:"role,omitempty"`
+	SessionId string `json:"sid,omitempty"`
+	Type      string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// AccessTokenLifetime converts the configured access token timeout to a duration
+func AccessTokenLifetime(timeout int) time.Duration {
+	return time.Minute * time.Duration(timeout)
+}
+
+// RefreshTokenLifetime converts the configured refresh token timeout to a duration
+func RefreshTokenLifetime(timeout int) time.Duration {
+	return time.Hour * time.Duration(timeout)
+}
+
+// DenylistKey is the in-memory storage key of a revoked access token
+func DenylistKey(jti string) string {
+	return "denylist:" + jti
 }
 
-type CustomClaims struct {
-	*jwt.Token
-	Sub  string `json:"sub"`
-	Exp  string `json:"exp"`
-	Role string `json:"role"`
+// NotBeforeKey is the in-memory storage key holding the unix time
+// before which every access token of the subject is revoked
+func NotBeforeKey(sub string) string {
+	return "not_before:" + sub
 }
 
 // Generate jwt token
 func (jwtHandler JWTHandler) GenerateAuthJWT() (access string, refresh string, err error) {
-	var (
-		accessToken  *jwt.Token
-		refreshToken *jwt.Token
-		claims       jwt.MapClaims
-		rtClaims     jwt.MapClaims
-	)
-	accessToken = jwt.New(jwt.SigningMethodHS256)
-	refreshToken = jwt.New(jwt.SigningMethodHS256)
-
-	claims = accessToken.Claims.(jwt.MapClaims)
-	claims["exp"] = time.Now().Add(time.Second * time.Duration(jwtHandler.Timeout)).Unix()
-	claims["sub"] = jwtHandler.Sub
-	claims["role"] = jwtHandler.Role
-	access, err = accessToken.SignedString([]byte(jwtHandler.SignInKey))
+	now := time.Now()
+
+	access, err = jwtHandler.Keys.Sign(jwtHandler.claims(TypeAccess, now, AccessTokenLifetime(jwtHandler.Timeout)))
 	if err != nil {
 		jwtHandler.Log.Error("cannot generate access token", logger.Error(err))
 		return
 	}
 
-	rtClaims = refreshToken.Claims.(jwt.MapClaims)
-	rtClaims["sub"] = jwtHandler.Sub
-	rtClaims["exp"] = time.Now().Add(time.Hour * time.Duration(jwtHandler.Timeout)).Unix()
-	refresh, err = refreshToken.SignedString([]byte(jwtHandler.SignInKey))
+	refresh, err = jwtHandler.Keys.Sign(jwtHandler.claims(TypeRefresh, now, RefreshTokenLifetime(jwtHandler.RefreshTimeout)))
 	if err != nil {
 		jwtHandler.Log.Error("cannot generate refresh token", logger.Error(err))
 		return
@@ -57,23 +83,58 @@ func (jwtHandler JWTHandler) GenerateAuthJWT() (access string, refresh string, e
 	return access, refresh, nil
 }
 
-func ExtractClaim(tokenStr string, signinKey []byte) (jwt.MapClaims, error) {
-	var (
-		token *jwt.Token
-		err   error
-	)
+// GenerateMFAJWT issues the token login returns instead of the access and refresh
+// tokens until the second factor is verified
+func (jwtHandler JWTHandler) GenerateMFAJWT() (token string, jti string, err error) {
+	claims := jwtHandler.claims(TypeMFA, time.Now(), MFATokenLifetime)
+	token, err = jwtHandler.Keys.Sign(claims)
+	return token, claims.ID, err
+}
 
-	token, err = jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		return signinKey, nil
-	})
+// GenerateVerifyJWT issues the token of a magic link verifying the email in Sub,
+// its jti lets the pending registration accept only the latest link
+func (jwtHandler JWTHandler) GenerateVerifyJWT() (token string, jti string, err error) {
+	claims := jwtHandler.claims(TypeVerify, time.Now(), VerifyTokenLifetime)
+	token, err = jwtHandler.Keys.Sign(claims)
+	return token, claims.ID, err
+}
 
-	if !token.Valid {
-		return nil, err
+func (jwtHandler JWTHandler) claims(tokenType string, now time.Time, lifetime time.Duration) Claims {
+	return Claims{
+		Role:      jwtHandler.Role,
+		SessionId: jwtHandler.SessionId,
+		Type:      tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    jwtHandler.Keys.issuer,
+			Subject:   jwtHandler.Sub,
+			Audience:  jwt.ClaimStrings{jwtHandler.Keys.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
 	}
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
+// ExtractClaim verifies the token signature, issuer, audience and lifetime
+// and makes sure it is of the expected type
+func ExtractClaim(tokenStr string, keys *Keys, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(tokenStr, claims, keys.Keyfunc,
+		jwt.WithValidMethods([]string{keys.method.Alg()}),
+		jwt.WithIssuer(keys.issuer),
+		jwt.WithAudience(keys.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
 		return nil, err
 	}
+
+	if claims.Type != tokenType {
+		return nil, ErrWrongTokenType
+	}
+
 	return claims, nil
 }
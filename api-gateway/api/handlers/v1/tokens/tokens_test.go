@@ -0,0 +1,154 @@
+package tokens
+
+import (
+	"errors"
+	"exam/api-gateway/config"
+	"exam/api-gateway/pkg/logger"
+	"exam/pkg/auth"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testKeys(t *testing.T, key, issuer, audience string) *Keys {
+	t.Helper()
+
+	keys, err := LoadKeys(config.Config{
+		SignInKey:   key,
+		JWTIssuer:   issuer,
+		JWTAudience: audience,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestExtractClaim(t *testing.T) {
+	keys := testKeys(t, "test-sign-in-key", "exam-api-gateway", "exam")
+	jwtHandler := JWTHandler{
+		Sub:            "user-1",
+		Role:           "user",
+		Keys:           keys,
+		Log:            logger.New("error", "test"),
+		Timeout:        15,
+		RefreshTimeout: 720,
+		SessionId:      "session-1",
+	}
+
+	access, refresh, err := jwtHandler.GenerateAuthJWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfa, _, err := jwtHandler.GenerateMFAJWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify, _, err := jwtHandler.GenerateVerifyJWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the issuer and audience come from the keys the claims are made with
+	claimsOf := func(keys *Keys) Claims {
+		other := jwtHandler
+		other.Keys = keys
+		return other.claims(TypeAccess, time.Now(), time.Minute)
+	}
+	sign := func(keys *Keys, claims Claims) string {
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	now := time.Now()
+	expired := jwtHandler.claims(TypeAccess, now.Add(-time.Hour), time.Minute)
+	future := jwtHandler.claims(TypeAccess, now.Add(time.Hour), time.Hour)
+	withoutExpiry := jwtHandler.claims(TypeAccess, now, time.Minute)
+	withoutExpiry.ExpiresAt = nil
+
+	service, err := auth.Sign("test-sign-in-key", "user-service", "user-1", []string{"user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwtHandler.claims(TypeAccess, now, time.Minute)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType string
+		wantErr   error
+	}{
+		{name: "access as access", token: access, tokenType: TypeAccess},
+		{name: "refresh as refresh", token: refresh, tokenType: TypeRefresh},
+		{name: "mfa as mfa", token: mfa, tokenType: TypeMFA},
+		{name: "verify as verify", token: verify, tokenType: TypeVerify},
+		{name: "refresh as access", token: refresh, tokenType: TypeAccess, wantErr: ErrWrongTokenType},
+		{name: "access as refresh", token: access, tokenType: TypeRefresh, wantErr: ErrWrongTokenType},
+		{name: "mfa as access", token: mfa, tokenType: TypeAccess, wantErr: ErrWrongTokenType},
+		{name: "verify as access", token: verify, tokenType: TypeAccess, wantErr: ErrWrongTokenType},
+		{name: "expired", token: sign(keys, expired), tokenType: TypeAccess, wantErr: jwt.ErrTokenExpired},
+		{name: "issued in the future", token: sign(keys, future), tokenType: TypeAccess, wantErr: jwt.ErrTokenUsedBeforeIssued},
+		{name: "without expiry", token: sign(keys, withoutExpiry), tokenType: TypeAccess, wantErr: jwt.ErrTokenRequiredClaimMissing},
+		{name: "other key", token: sign(testKeys(t, "other-key", "exam-api-gateway", "exam"), claimsOf(keys)), tokenType: TypeAccess, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "other issuer", token: sign(keys, claimsOf(testKeys(t, "test-sign-in-key", "other", "exam"))), tokenType: TypeAccess, wantErr: jwt.ErrTokenInvalidIssuer},
+		{name: "other audience", token: sign(keys, claimsOf(testKeys(t, "test-sign-in-key", "exam-api-gateway", "other"))), tokenType: TypeAccess, wantErr: jwt.ErrTokenInvalidAudience},
+		{name: "service token", token: service, tokenType: TypeAccess, wantErr: jwt.ErrTokenInvalidIssuer},
+		{name: "unsigned", token: unsigned, tokenType: TypeAccess, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "garbage", token: "not.a.token", tokenType: TypeAccess, wantErr: jwt.ErrTokenMalformed},
+		{name: "empty", token: "", tokenType: TypeAccess, wantErr: jwt.ErrTokenMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ExtractClaim(tt.token, keys, tt.tokenType)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ExtractClaim() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractClaim() error = %v", err)
+			}
+			if claims.Subject != "user-1" || claims.Type != tt.tokenType || claims.ID == "" {
+				t.Fatalf("ExtractClaim() claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestGenerateAuthJWTSession(t *testing.T) {
+	keys := testKeys(t, "test-sign-in-key", "exam-api-gateway", "exam")
+	access, refresh, err := JWTHandler{Sub: "user-1", Role: "admin", Keys: keys, Timeout: 15, RefreshTimeout: 720, SessionId: "session-1"}.GenerateAuthJWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accessClaims, err := ExtractClaim(access, keys, TypeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshClaims, err := ExtractClaim(refresh, keys, TypeRefresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if accessClaims.Role != "admin" || accessClaims.SessionId != "session-1" || refreshClaims.SessionId != "session-1" {
+		t.Fatalf("claims lost the role or session: %+v %+v", accessClaims, refreshClaims)
+	}
+	if accessClaims.ID == refreshClaims.ID {
+		t.Fatal("access and refresh token share an id")
+	}
+	if lifetime := accessClaims.ExpiresAt.Sub(accessClaims.IssuedAt.Time); lifetime != AccessTokenLifetime(15) {
+		t.Fatalf("access token lives %s", lifetime)
+	}
+	if lifetime := refreshClaims.ExpiresAt.Sub(refreshClaims.IssuedAt.Time); lifetime != RefreshTokenLifetime(720) {
+		t.Fatalf("refresh token lives %s", lifetime)
+	}
+}
@@ -2,28 +2,28 @@ package v1
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
 	"exam/api-gateway/api/handlers/models"
 	"exam/api-gateway/api/handlers/v1/tokens"
 	"exam/api-gateway/email"
 	pbp "exam/api-gateway/genproto/product-service"
 	pb "exam/api-gateway/genproto/user-service"
-	"exam/api-gateway/pkg/utils"
 
 	//pbp "exam/api-gateway/genproto/product-service"
 	"exam/api-gateway/pkg/etc"
 	"exam/api-gateway/pkg/logger"
-	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/gomodule/redigo/redis"
 	"github.com/google/uuid"
-	"github.com/spf13/cast"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
@@ -41,7 +41,6 @@ import (
 func (h *handlerV1) Register(c *gin.Context) {
 	var (
 		body       models.UserRequest
-		code       string
 		jspMarshal protojson.MarshalOptions
 	)
 	jspMarshal.UseProtoNames = true
@@ -59,6 +58,7 @@ func (h *handlerV1) Register(c *gin.Context) {
 	body.Id = uuid.New().String()
 	body.Email = strings.TrimSpace(body.Email)
 	body.Email = strings.ToLower(body.Email)
+	body.Locale = email.Locale(body.Locale)
 	err = body.Validate()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -69,7 +69,7 @@ func (h *handlerV1) Register(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	exists, err := h.serviceManager.UserService().CheckField(ctx, &pb.CheckFieldRequest{
@@ -92,50 +92,48 @@ func (h *handlerV1) Register(c *gin.Context) {
 		h.log.Error("email is not unique", logger.Error(err))
 		return
 	}
-	code = utils.GenerateCode(5)
-	respUser := models.RegisterUserModel{
-		Id:        body.Id,
-		FirstName: body.FirstName,
-		LastName:  body.LastName,
-		Age:       body.Age,
-		Email:     body.Email,
-		Password:  body.Password,
-		Code:      code,
-	}
-
-	userJson, err := json.Marshal(respUser)
+	// registering again is a resend, it must not get around the cooldown
+	previous, err := h.findPendingRegistration(body.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ResponseError{
-			Code:    ErrorCodeInvalidJSON,
-			Message: "cannot bind json",
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
 		})
-		h.log.Error("cannot bind json", logger.Error(err))
+		h.log.Error("cannot get pending registration", logger.Error(err))
 		return
 	}
+	resends := 0
+	if previous != nil {
+		if !h.CheckResendCooldown(c, previous) {
+			return
+		}
+		resends = previous.Resends + 1
+	}
 
-	timeOut := time.Minute * 2
-
-	err = h.inMemoryStorage.SetWithTTL(respUser.Email, string(userJson), int(timeOut.Seconds()))
+	// the raw password never reaches the in-memory storage
+	passwordHash, err := etc.HashPassword(body.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ResponseError{
 			Code:    ErrorCodeInternalServerError,
 			Message: err.Error(),
 		})
-		h.log.Error("cannot set with ttl", logger.Error(err))
+		h.log.Error("cannot hash the password", logger.Error(err))
 		return
 	}
 
-	from := "mubinayigitaliyeva00@gmail.com"
-	password := "iocd vnhb lnvx digm"
-	err = email.SendVerificationCode(email.Params{
-		From:     from,
-		Password: password,
-		To:       respUser.Email,
-		Message:  fmt.Sprintf("Hi %s,", respUser.FirstName),
-		Code:     respUser.Code,
-	})
+	pending := models.RegisterUserModel{
+		Id:           body.Id,
+		FirstName:    body.FirstName,
+		LastName:     body.LastName,
+		Age:          body.Age,
+		Email:        body.Email,
+		PasswordHash: passwordHash,
+		Locale:       body.Locale,
+		Resends:      resends,
+		ExpiresAt:    time.Now().Add(registrationTimeout),
+	}
 
-	if err != nil {
+	if err := h.sendVerification(&pending); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ResponseError{
 			Code:    ErrorCodeInternalServerError,
 			Message: err.Error(),
@@ -158,104 +156,46 @@ func (h *handlerV1) Register(c *gin.Context) {
 // @Success 201 {object} models.VerifyUserResponse
 // @Failure 400 string error models.ResponseError
 // @Failure 400 string error models.ResponseError
+// @Failure 429 string Error models.ResponseError
 // @Router /v1/user/verify/{email}/{code} [get]
 func (h *handlerV1) Verify(c *gin.Context) {
-	var jspbMarshal protojson.MarshalOptions
-	jspbMarshal.UseProtoNames = true
-
-	userEmail := c.Param("email")
+	userEmail := strings.ToLower(strings.TrimSpace(c.Param("email")))
 	code := c.Param("code")
 
-	user, err := redis.Bytes(h.inMemoryStorage.Get(userEmail))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ResponseError{
-			Code:    ErrorCodeUnauthorized,
-			Message: "code is expired, try again.",
-		})
-		h.log.Error("Code is expired, TTL is over.")
+	accountKey := attemptKey("verify", userEmail)
+	if !h.CheckLockout(c, accountKey) {
 		return
 	}
 
-	var respUser models.RegisterUserModel
-	if err := json.Unmarshal(user, &respUser); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ResponseError{
-			Code:    ErrorCodeInternalServerError,
-			Message: err.Error(),
-		})
-		h.log.Error("cannot unmarshal user from redis", logger.Error(err))
-		fmt.Println(respUser)
+	pending, ok := h.getPendingRegistration(c, userEmail)
+	if !ok {
 		return
 	}
 
-	if respUser.Code != code {
+	if pending.Attempts >= verifyMaxAttempts || time.Now().After(pending.CodeExpiresAt) {
 		c.JSON(http.StatusBadRequest, models.ResponseError{
-			Code:    ErrorCodeInvalidCode,
-			Message: "code is incorrect, try again.",
-		})
-		h.log.Error("verification failed", logger.Error(err))
-		return
-	}
-
-	respUser.Password, err = etc.HashPassword(respUser.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ResponseError{
-			Code:    ErrorCodeInternalServerError,
-			Message: err.Error(),
-		})
-		h.log.Error("cannot hash the password", logger.Error(err))
-		return
-	}
-
-	h.jwtHandler = tokens.JWTHandler{
-		Sub:       respUser.Id,
-		Role:      "user",
-		SignInKey: h.cfg.SignInKey,
-		Log:       h.log,
-		Timeout:   h.cfg.AccessTokenTimeout,
-	}
-
-	access, refresh, err := h.jwtHandler.GenerateAuthJWT()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ResponseError{
-			Code:    ErrorCodeInternalServerError,
-			Message: err.Error(),
+			Code:    ErrorCodeUnauthorized,
+			Message: "code is expired, try again.",
 		})
-		h.log.Error("cannot create access and refresh token", logger.Error(err))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
-	defer cancel()
-
-	_, err = h.serviceManager.UserService().CreateUser(ctx, &pb.User{
-		Id:           respUser.Id,
-		FirstName:    respUser.FirstName,
-		LastName:     respUser.LastName,
-		Age:          respUser.Age,
-		Email:        respUser.Email,
-		Password:     respUser.Password,
-		RefreshToken: refresh,
-	})
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
+	if subtle.ConstantTimeCompare([]byte(etc.HashToken(code)), []byte(pending.CodeHash)) != 1 {
+		h.LoginFailed(c, accountKey)
+		pending.Attempts++
+		if err := h.saveUntil(registrationKey(userEmail), pending, pending.ExpiresAt); err != nil {
+			h.log.Error("cannot count verification attempt", logger.Error(err))
+		}
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "code is incorrect, try again.",
 		})
-		h.log.Error("cannot create user", logger.Error(err))
+		h.log.Error("verification failed")
 		return
 	}
+	h.LoginSucceeded(accountKey)
 
-	res := models.UserModel{
-		Id:          respUser.Id,
-		FirstName:   respUser.FirstName,
-		LastName:    respUser.LastName,
-		Age:         respUser.Age,
-		Email:       respUser.Email,
-		Password:    respUser.Password,
-		AccessToken: access,
-	}
-
-	c.JSON(http.StatusOK, res)
+	h.completeRegistration(c, pending)
 }
 
 // Login User
@@ -266,8 +206,10 @@ func (h *handlerV1) Verify(c *gin.Context) {
 // @Produce json
 // @Param User body models.LoginRequest true "Login"
 // @Success 201 {object} models.UserModel
+// @Success 200 {object} models.MFARequiredResp
 // @Failure 400 string Error models.ResponseError
 // @Failure 400 string Error models.ResponseError
+// @Failure 429 string Error models.ResponseError
 // @Router /v1/user/login [post]
 func (h *handlerV1) Login(c *gin.Context) {
 	var (
@@ -286,13 +228,19 @@ func (h *handlerV1) Login(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	accountKey := attemptKey("user", strings.ToLower(strings.TrimSpace(body.Email)))
+	if !h.CheckLockout(c, accountKey) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(h.gatewayContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	resp, err := h.serviceManager.UserService().Check(ctx, &pb.IfExists{
 		Email: body.Email,
 	})
 	if err != nil {
+		h.LoginFailed(c, accountKey)
 		c.JSON(http.StatusBadRequest, models.ResponseError{
 			Code:    ErrorCodeInternalServerError,
 			Message: err.Error(),
@@ -302,6 +250,7 @@ func (h *handlerV1) Login(c *gin.Context) {
 	}
 
 	if !etc.CompareHashPassword(resp.Password, body.Password) {
+		h.LoginFailed(c, accountKey)
 		c.JSON(http.StatusBadRequest, models.ResponseError{
 			Code:    ErrorBadRequest,
 			Message: "invalid code, try again",
@@ -309,32 +258,32 @@ func (h *handlerV1) Login(c *gin.Context) {
 		h.log.Error("wrong password", logger.Error(err))
 		return
 	}
-	h.jwtHandler = tokens.JWTHandler{
-		Sub:       resp.Id,
-		Role:      "user",
-		SignInKey: h.cfg.SignInKey,
-		Log:       h.log,
-		Timeout:   h.cfg.AccessTokenTimeout,
+
+	role := h.subjectRole(resp.Id, RoleUser)
+	if h.RequireMFA(c, accountKey, resp.Id, role) {
+		return
 	}
+	h.LoginSucceeded(accountKey)
 
-	access, _, err := h.jwtHandler.GenerateAuthJWT()
+	access, refresh, err := h.NewSession(c, resp.Id, role)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ResponseError{
 			Code:    ErrorCodeInternalServerError,
 			Message: err.Error(),
 		})
-		h.log.Error("cannot create access token", logger.Error(err))
+		h.log.Error("cannot create access and refresh token", logger.Error(err))
 		return
 	}
 
 	res := models.UserModel{
-		Id:          resp.Id,
-		FirstName:   resp.FirstName,
-		LastName:    resp.LastName,
-		Age:         resp.Age,
-		Email:       resp.Email,
-		Password:    resp.Password,
-		AccessToken: access,
+		Id:           resp.Id,
+		FirstName:    resp.FirstName,
+		LastName:     resp.LastName,
+		Age:          resp.Age,
+		Email:        resp.Email,
+		Locale:       resp.Locale,
+		AccessToken:  access,
+		RefreshToken: refresh,
 	}
 
 	c.JSON(http.StatusOK, res)
@@ -345,7 +294,7 @@ func (h *handlerV1) Login(c *gin.Context) {
 // @Security BearerAuth
 // @Summary create user
 // @Tags User
-// @Description Create a new user with provided details
+// @Description Create a new user with provided details, the user gets no tokens until they log in
 // @Accept json
 // @Produce json
 // @Param UserInfo body models.UserRequest true "Create user"
@@ -379,36 +328,19 @@ func (h *handlerV1) CreateUser(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.gatewayContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
+	// the user logs in on their own, the refresh tokens are kept by the sessions
 	body.Id = uuid.New().String()
-	h.jwtHandler = tokens.JWTHandler{
-		Sub:       body.Id,
-		Role:      "user",
-		SignInKey: h.cfg.SignInKey,
-		Log:       h.log,
-		Timeout:   h.cfg.AccessTokenTimeout,
-	}
-
-	access, refresh, err := h.jwtHandler.GenerateAuthJWT()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ResponseError{
-			Code:    ErrorCodeInternalServerError,
-			Message: err.Error(),
-		})
-		h.log.Error("cannot generate refresh token", logger.Error(err))
-		return
-	}
-
 	response, err := h.serviceManager.UserService().CreateUser(ctx, &pb.User{
-		Id:           body.Id,
-		FirstName:    body.FirstName,
-		LastName:     body.LastName,
-		Age:          body.Age,
-		Email:        body.Email,
-		Password:     body.Password,
-		RefreshToken: refresh,
+		Id:        body.Id,
+		FirstName: body.FirstName,
+		LastName:  body.LastName,
+		Age:       body.Age,
+		Email:     body.Email,
+		Password:  body.Password,
+		Locale:    email.Locale(body.Locale),
 	})
 
 	if err != nil {
@@ -420,15 +352,13 @@ func (h *handlerV1) CreateUser(c *gin.Context) {
 		return
 	}
 
-	res := models.UserModel{
-		Id:          response.Id,
-		FirstName:   response.FirstName,
-		LastName:    response.LastName,
-		Age:         response.Age,
-		Email:       response.Email,
-		Password:    response.Email,
-		AccessToken: access,
-	}
+	res := userModel(response)
+
+	h.notify(response, email.TemplateAdminCreated, email.AdminCreatedData{
+		UserName: response.FirstName,
+		Email:    response.Email,
+	})
+
 	c.JSON(http.StatusCreated, res)
 }
 
@@ -437,20 +367,24 @@ func (h *handlerV1) CreateUser(c *gin.Context) {
 // @Security BearerAuth
 // @Summary get user by id
 // @Tags User
-// @Description Get user
+// @Description Get user, users can only get themselves, admins anybody
 // @Accept json
 // @Produce json
 // @Param id path string true "Id"
 // @Success 201 {object} models.UserWithProducts
 // @Failure 400 string Error models.ResponseError
+// @Failure 403 string Error models.ResponseError
 // @Failure 500 string Error models.ResponseError
 func (h *handlerV1) GetUserById(c *gin.Context) {
 	var jspbMarshal protojson.MarshalOptions
 	jspbMarshal.UseProtoNames = true
 
 	id := c.Param("id")
+	if !h.CheckOwner(c, id) {
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 	response, err := h.serviceManager.UserService().GetUserById(ctx, &pb.GetUserId{
 		UserId: id,
@@ -465,14 +399,7 @@ func (h *handlerV1) GetUserById(c *gin.Context) {
 	}
 
 	resp := models.UserWithProducts{
-		User: models.UserRequest{
-			Id:        response.Id,
-			FirstName: response.FirstName,
-			LastName:  response.LastName,
-			Age:       response.Age,
-			Email:     response.Email,
-			Password:  response.Password,
-		},
+		User:     userModel(response),
 		Products: []*models.Product{},
 	}
 
@@ -505,13 +432,14 @@ func (h *handlerV1) GetUserById(c *gin.Context) {
 // @Security BearerAuth
 // @Summary update user
 // @Tags User
-// @Description Update user
+// @Description Update user, users can only update themselves, admins anybody
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
-// @Param UserInfo body models.VerifyUserResponse true "Update User"
-// @Success 201 {object} models.VerifyUserResponse
+// @Param UserInfo body models.UserRequest true "Update User"
+// @Success 200 {object} models.UserModel
 // @Failure 400 string Error models.ResponseError
+// @Failure 403 string Error models.ResponseError
 // @Failure 500 string Error models.ResponseError
 func (h *handlerV1) UpdateUser(c *gin.Context) {
 	var (
@@ -519,6 +447,9 @@ func (h *handlerV1) UpdateUser(c *gin.Context) {
 		jspbMarshal protojson.MarshalOptions
 	)
 	id := c.Param("id")
+	if !h.CheckOwner(c, id) {
+		return
+	}
 
 	jspbMarshal.UseProtoNames = true
 
@@ -532,9 +463,13 @@ func (h *handlerV1) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
+	if body.Locale != "" {
+		body.Locale = email.Locale(body.Locale)
+	}
+
 	response, err := h.serviceManager.UserService().UpdateUser(ctx, &pb.User{
 		Id:        id,
 		FirstName: body.FirstName,
@@ -542,6 +477,7 @@ func (h *handlerV1) UpdateUser(c *gin.Context) {
 		Age:       body.Age,
 		Email:     body.Email,
 		Password:  body.Password,
+		Locale:    body.Locale,
 	})
 
 	if err != nil {
@@ -553,7 +489,7 @@ func (h *handlerV1) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, userModel(response))
 }
 
 // Delete User
@@ -561,12 +497,13 @@ func (h *handlerV1) UpdateUser(c *gin.Context) {
 // @Security BearerAuth
 // @Summary delete user
 // @Tags User
-// @Description Delete user
+// @Description Delete user, the user is logged out everywhere and can be restored until the retention period of the user service ends
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Success 201 {object} models.Status
 // @Failure 400 string Error models.ResponseError
+// @Failure 404 string Error models.ResponseError
 // @Failure 500 string Error models.ResponseError
 func (h *handlerV1) DeleteUser(c *gin.Context) {
 	var jspbMarshal protojson.MarshalOptions
@@ -574,7 +511,7 @@ func (h *handlerV1) DeleteUser(c *gin.Context) {
 
 	id := c.Param("id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 
 	response, err := h.serviceManager.UserService().DeleteUser(ctx, &pb.GetUserId{
@@ -590,20 +527,87 @@ func (h *handlerV1) DeleteUser(c *gin.Context) {
 		h.log.Error("cannot delete user", logger.Error(err))
 		return
 	}
+	if !response.Success {
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "user not found",
+		})
+		return
+	}
+
+	// tokens of a deleted user must not keep working until they expire
+	if _, err := h.RevokeAllSessions(id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot revoke sessions of deleted user", logger.Error(err))
+		return
+	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// Restore User
+// @Router /v1/user/restore/{id} [post]
+// @Security BearerAuth
+// @Summary restore user
+// @Tags User
+// @Description Restore a deleted user, it fails when the email of the user was registered again meanwhile
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.UserModel
+// @Failure 404 string Error models.ResponseError
+// @Failure 409 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) RestoreUser(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	user, err := h.serviceManager.UserService().RestoreUser(ctx, &pb.GetUserId{
+		UserId: c.Param("id"),
+	})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, models.ResponseError{
+			Code:    ErrorCodeNotFound,
+			Message: "deleted user not found",
+		})
+		return
+	case codes.AlreadyExists:
+		c.JSON(http.StatusConflict, models.ResponseError{
+			Code:    ErrorCodeAlreadyExists,
+			Message: "the email of the user is used by another user",
+		})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot restore user", logger.Error(err))
+		return
+	}
+
+	h.log.Info("user restored",
+		logger.String("admin", c.GetString("sub")),
+		logger.String("user", user.Id))
+
+	c.JSON(http.StatusOK, userModel(user))
+}
+
 // Get All Users
 // @Router /v1/users/{page}/{limit} [get]
 // @Security BearerAuth
 // @Summary get all users
 // @Tags User
-// @Description get all users
+// @Description get all users, deleted ones only with include_deleted
 // @Accept json
 // @Produce json
 // @Param page path string true "page"
 // @Param limit path string true "limit"
+// @Param include_deleted query bool false "include_deleted"
 // @Success 201 {object} models.ListUsers
 // @Failure 400 string Error models.ResponseError
 // @Failure 500 string Error models.ResponseError
@@ -611,7 +615,7 @@ func (h *handlerV1) GetAllUsers(c *gin.Context) {
 	var jspbMarshal protojson.MarshalOptions
 	jspbMarshal.UseProtoNames = true
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	ctx, cancel := context.WithTimeout(h.rpcContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
 	defer cancel()
 	page := c.Param("page")
 	pageToInt, err := strconv.Atoi(page)
@@ -635,9 +639,20 @@ func (h *handlerV1) GetAllUsers(c *gin.Context) {
 		return
 	}
 
+	includeDeleted, err := strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorBadRequest,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot parse include_deleted query param", logger.Error(err))
+		return
+	}
+
 	response, err := h.serviceManager.UserService().ListUsers(ctx, &pb.GetListRequest{
-		Page:  int32(pageToInt),
-		Limit: int32(LimitToInt),
+		Page:           int32(pageToInt),
+		Limit:          int32(LimitToInt),
+		IncludeDeleted: includeDeleted,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -647,20 +662,29 @@ func (h *handlerV1) GetAllUsers(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	users := models.ListUsers{
+		Count: response.Count,
+		Users: []*models.UserModel{},
+	}
+	for _, user := range response.Users {
+		model := userModel(user)
+		users.Users = append(users.Users, &model)
+	}
+
+	c.JSON(http.StatusOK, users)
 }
 
 // Update Access token
 // @Router /v1/user/refresh [post]
-// @Security BearerAuth
 // @Summary update access token
 // @Tags User
-// @Description get access token updated
+// @Description exchange a refresh token for a new access and refresh token pair
 // @Accept json
 // @Produce json
 // @Param refresh-token body models.AccessTokenUpdateReq true "refresh-token"
 // @Success 201 {object} models.AccessTokenUpdateResp
 // @Failure 400 string Error models.ResponseError
+// @Failure 401 string Error models.ResponseError
 // @Failure 500 string Error models.ResponseError
 func (h *handlerV1) UpdateRefreshToken(c *gin.Context) {
 	var (
@@ -678,50 +702,175 @@ func (h *handlerV1) UpdateRefreshToken(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(h.cfg.CtxTimeOut))
-	defer cancel()
+	claims, err := tokens.ExtractClaim(body.RefreshToken, h.jwtKeys, tokens.TypeRefresh)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "refresh token is invalid or expired",
+		})
+		h.log.Error("cannot parse refresh token", logger.Error(err))
+		return
+	}
+
+	sessionId := claims.SessionId
+	if sessionId == "" {
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "refresh token is not bound to a session, login again",
+		})
+		return
+	}
 
-	claims, err := tokens.ExtractClaim(body.RefreshToken, []byte(h.cfg.SignInKey))
+	session, err := h.sessions.Get(sessionId)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "session does not exist, login again",
+		})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
 		})
+		h.log.Error("cannot get session", logger.Error(err))
 		return
 	}
-	userId := cast.ToString(claims["sub"])
 
-	h.jwtHandler = tokens.JWTHandler{
-		Sub:       userId,
-		Role:      "user",
-		SignInKey: h.cfg.SignInKey,
-		Log:       h.log,
-		Timeout:   h.cfg.AccessTokenTimeout,
+	if session.Revoked || time.Now().After(session.ExpiresAt) || session.UserId != claims.Subject {
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "session is closed, login again",
+		})
+		return
+	}
+	if !h.activeAdmin(c, session.UserId, session.Role) {
+		return
 	}
 
-	access, refresh, err := h.jwtHandler.GenerateAuthJWT()
+	jwtHandler := tokens.JWTHandler{
+		Sub:            session.UserId,
+		Role:           session.Role,
+		Keys:           h.jwtKeys,
+		Log:            h.log,
+		Timeout:        h.cfg.AccessTokenTimeout,
+		RefreshTimeout: h.cfg.RefreshTokenTimeout,
+		SessionId:      session.Id,
+	}
+
+	access, refresh, err := jwtHandler.GenerateAuthJWT()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
 		})
+		h.log.Error("cannot create access and refresh token", logger.Error(err))
 		return
 	}
 
-	_, err = h.serviceManager.UserService().UpdateRefreshToken(ctx, &pb.UpdateRefreshTokenReq{
-		UserId:       cast.ToString(claims["sub"]),
-		RefreshToken: refresh,
-	})
+	expiresAt := time.Now().Add(tokens.RefreshTokenLifetime(h.cfg.RefreshTokenTimeout))
+	rotated, err := h.sessions.Rotate(session.Id, etc.HashToken(body.RefreshToken), etc.HashToken(refresh), expiresAt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot rotate refresh token", logger.Error(err))
+		return
+	}
+
+	// the presented token was already exchanged, so it may be stolen:
+	// close the session and make every holder of it login again, the access
+	// tokens issued from the session are denied as well
+	if !rotated {
+		if err := h.sessions.Revoke(session.Id); err != nil {
+			h.log.Error("cannot revoke session", logger.Error(err))
+		}
+		if err := h.DenyAccessTokens(session.UserId); err != nil {
+			h.log.Error("cannot deny access tokens", logger.Error(err))
+		}
+		h.log.Warn("refresh token reuse detected, session revoked",
+			logger.String("session_id", session.Id),
+			logger.String("user_id", session.UserId),
+			logger.String("device_id", session.DeviceId))
+
+		c.JSON(http.StatusUnauthorized, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "refresh token was already used, login again",
 		})
 		return
 	}
 
 	resp := models.AccessTokenUpdateResp{
-		Status:      "success",
-		UserID:      userId,
-		AccessToken: access,
+		Status:       "success",
+		UserID:       session.UserId,
+		AccessToken:  access,
+		RefreshToken: refresh,
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// Logout User
+// @Router /v1/user/logout [post]
+// @Security BearerAuth
+// @Summary logout user
+// @Tags User
+// @Description revoke the access token and close the session of this device
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 401 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) LogoutUser(c *gin.Context) {
+	h.CloseSession(c)
+}
+
+// Revoke User Sessions
+// @Router /v1/user/sessions/{id} [delete]
+// @Security BearerAuth
+// @Summary revoke all sessions of a user
+// @Tags User
+// @Description revoke every refresh token session and access token of a user
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.RevokeSessionsResp
+// @Failure 400 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+func (h *handlerV1) RevokeUserSessions(c *gin.Context) {
+	id := c.Param("id")
+
+	revoked, err := h.RevokeAllSessions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot revoke sessions of user", logger.Error(err))
+		return
+	}
+
+	h.log.Info("all sessions of user revoked",
+		logger.String("user_id", id),
+		logger.String("revoked_by", c.GetString("sub")))
+
+	c.JSON(http.StatusOK, models.RevokeSessionsResp{
+		UserId:          id,
+		RevokedSessions: revoked,
+	})
+}
+
+// userModel leaves the password hash and the refresh token
+// the user service keeps out of the response
+func userModel(user *pb.User) models.UserModel {
+	return models.UserModel{
+		Id:        user.Id,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Age:       user.Age,
+		Email:     user.Email,
+		Locale:    user.Locale,
+	}
+}
@@ -0,0 +1,147 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"exam/api-gateway/api/handlers/models"
+	pbu "exam/api-gateway/genproto/user-service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testUserId    = "2c1f8a8e-1d5b-4c3e-9a6f-7e2b1d0c9f41"
+	testOtherId   = "9d3e4b2a-6c7f-4e1d-8b5a-3f2c1e0d7a62"
+	testUserEmail = "user@example.com"
+	testUserPass  = "user-password"
+)
+
+const testRolePolicy = `g, user, unauthorized
+g, admin, user
+g, superadmin, admin
+`
+
+// newUserTestHandler has the user and another user in the user service
+func newUserTestHandler(t *testing.T) (*handlerV1, *serviceManager, *mailbox) {
+	t.Helper()
+
+	h, _, _ := newSessionTestHandler(t)
+	h.casbin, _ = newTestEnforcer(t, testRolePolicy)
+	h.mfa = &mfaStorage{enrolments: map[string]*models.MFA{}, usedSteps: map[string]int64{}, usedCodes: map[string]bool{}}
+
+	// etc.HashPassword is too slow for tests, bcrypt accepts any cost
+	password, err := bcrypt.GenerateFromPassword([]byte(testUserPass), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := newServiceManager()
+	services.users.add(&pbu.User{Id: testUserId, FirstName: "Test", Email: testUserEmail, Password: string(password), Locale: "en"})
+	services.users.add(&pbu.User{Id: testOtherId, FirstName: "Other", Email: "other@example.com", Password: string(password), Locale: "en"})
+	h.serviceManager = services
+
+	mails := &mailbox{}
+	h.mailer = mails
+	return h, services, mails
+}
+
+// newRequestContext is a request with the body as json made with
+// an access token of sub as role, an empty sub makes it anonymous
+func newRequestContext(method, target string, body interface{}, sub, role string, params ...gin.Param) (*gin.Context, *httptest.ResponseRecorder) {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+
+	c, recorder := newTestContext("10.0.5.1")
+	c.Request = httptest.NewRequest(method, target, bytes.NewReader(data))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	if sub != "" {
+		c.Set("sub", sub)
+		c.Set("role", role)
+	}
+	return c, recorder
+}
+
+func TestCreateUserReturnsNoTokens(t *testing.T) {
+	h, services, _ := newUserTestHandler(t)
+
+	c, recorder := newRequestContext(http.MethodPost, "/v1/user/create", models.UserRequest{
+		FirstName: "New",
+		LastName:  "User",
+		Age:       30,
+		Email:     "new@example.com",
+		Password:  "new-user-password",
+	}, testAdminId, RoleAdmin)
+	h.CreateUser(c)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("create answered %d: %s", recorder.Code, recorder.Body)
+	}
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"access_token", "refresh_token", "password"} {
+		if value, ok := resp[field]; ok && value != "" {
+			t.Errorf("response has %s %v", field, value)
+		}
+	}
+
+	created, ok := services.users.get(resp["id"].(string))
+	if !ok {
+		t.Fatal("user is not created")
+	}
+	if created.RefreshToken != "" {
+		t.Error("an orphan refresh token is stored with the user")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(created.Password), []byte("new-user-password")) != nil {
+		t.Error("the password is not stored hashed")
+	}
+}
+
+func TestUserResponsesLeaveOutPassword(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(h *handlerV1) *httptest.ResponseRecorder
+	}{
+		{name: "login", handle: func(h *handlerV1) *httptest.ResponseRecorder {
+			c, recorder := newRequestContext(http.MethodPost, "/v1/user/login", models.LoginRequest{Email: testUserEmail, Password: testUserPass}, "", "")
+			h.Login(c)
+			return recorder
+		}},
+		{name: "get by id", handle: func(h *handlerV1) *httptest.ResponseRecorder {
+			c, recorder := newRequestContext(http.MethodGet, "/v1/user/"+testUserId, nil, testUserId, RoleUser, gin.Param{Key: "id", Value: testUserId})
+			h.GetUserById(c)
+			return recorder
+		}},
+		{name: "update", handle: func(h *handlerV1) *httptest.ResponseRecorder {
+			c, recorder := newRequestContext(http.MethodPut, "/v1/user/update/"+testUserId, models.UserRequest{FirstName: "Changed"}, testUserId, RoleUser, gin.Param{Key: "id", Value: testUserId})
+			h.UpdateUser(c)
+			return recorder
+		}},
+		{name: "list", handle: func(h *handlerV1) *httptest.ResponseRecorder {
+			c, recorder := newRequestContext(http.MethodGet, "/v1/users/1/10", nil, testAdminId, RoleAdmin, gin.Param{Key: "page", Value: "1"}, gin.Param{Key: "limit", Value: "10"})
+			h.GetAllUsers(c)
+			return recorder
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newUserTestHandler(t)
+
+			recorder := tt.handle(h)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("answered %d: %s", recorder.Code, recorder.Body)
+			}
+			if bytes.Contains(recorder.Body.Bytes(), []byte(`"password"`)) || bytes.Contains(recorder.Body.Bytes(), []byte("$2a$")) {
+				t.Fatalf("password hash in %s", recorder.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/api/handlers/v1/tokens"
+	"exam/api-gateway/email"
+	pb "exam/api-gateway/genproto/user-service"
+	"exam/api-gateway/pkg/etc"
+	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/pkg/utils"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// a pending registration outlives its codes, so a new one can be resent
+	registrationTimeout = time.Hour * 24
+
+	verifyCodeTimeout = time.Minute * 2
+	verifyCodeLength  = 6
+	verifyMaxAttempts = 5
+
+	// the first resend waits resendCooldown after the previous email,
+	// every next one twice as long
+	resendCooldown   = time.Minute
+	verifyMaxResends = 5
+)
+
+func registrationKey(email string) string {
+	return "registration:" + email
+}
+
+// sendVerification gives the pending registration a fresh code and magic link,
+// saves it and emails both, the previous code and link stop working
+func (h *handlerV1) sendVerification(pending *models.RegisterUserModel) error {
+	code, err := utils.GenerateSecureCode(verifyCodeLength)
+	if err != nil {
+		return err
+	}
+
+	jwtHandler := tokens.JWTHandler{
+		Sub:  pending.Email,
+		Keys: h.jwtKeys,
+		Log:  h.log,
+	}
+	linkToken, linkId, err := jwtHandler.GenerateVerifyJWT()
+	if err != nil {
+		return err
+	}
+
+	pending.CodeHash = etc.HashToken(code)
+	pending.Attempts = 0
+	pending.CodeExpiresAt = time.Now().Add(verifyCodeTimeout)
+	pending.LinkId = linkId
+	pending.LastSentAt = time.Now()
+
+	if err := h.saveUntil(registrationKey(pending.Email), pending, pending.ExpiresAt); err != nil {
+		return err
+	}
+
+	return email.SendVerificationCode(h.mailer, email.Params{
+		To:       pending.Email,
+		Locale:   pending.Locale,
+		Purpose:  email.PurposeRegister,
+		Code:     code,
+		Link:     strings.TrimSuffix(h.cfg.PublicURL, "/") + "/v1/user/verify/link?token=" + url.QueryEscape(linkToken),
+		UserName: pending.FirstName,
+	})
+}
+
+// CheckResendCooldown answers 429 and returns false while another
+// code cannot be sent for the pending registration yet
+func (h *handlerV1) CheckResendCooldown(c *gin.Context, pending *models.RegisterUserModel) bool {
+	return h.checkCooldown(c, pending.Resends, pending.LastSentAt)
+}
+
+// checkCooldown answers 429 and returns false while the resends ran out
+// or the cooldown after the code sent at lastSentAt did not pass yet
+func (h *handlerV1) checkCooldown(c *gin.Context, resends int, lastSentAt time.Time) bool {
+	if resends >= verifyMaxResends {
+		c.JSON(http.StatusTooManyRequests, models.ResponseError{
+			Code:    ErrorCodeTooManyAttempts,
+			Message: "too many codes were sent, try again later",
+		})
+		return false
+	}
+
+	cooldown := resendCooldown << resends
+	if wait := time.Until(lastSentAt.Add(cooldown)); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, models.ResponseError{
+			Code:    ErrorCodeTooManyAttempts,
+			Message: "a code was sent recently, try again later",
+		})
+		return false
+	}
+
+	return true
+}
+
+// getPendingRegistration answers 400 and returns false when there is
+// no registration waiting for the email
+func (h *handlerV1) getPendingRegistration(c *gin.Context, userEmail string) (*models.RegisterUserModel, bool) {
+	pending, err := h.findPendingRegistration(userEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get pending registration", logger.Error(err))
+		return nil, false
+	}
+	if pending == nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeUnauthorized,
+			Message: "code is expired, try again.",
+		})
+		return nil, false
+	}
+
+	return pending, true
+}
+
+// findPendingRegistration returns nil when the registration expired or was already verified
+func (h *handlerV1) findPendingRegistration(userEmail string) (*models.RegisterUserModel, error) {
+	pendingJson, err := redis.Bytes(h.inMemoryStorage.Get(registrationKey(userEmail)))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pending models.RegisterUserModel
+	if err := json.Unmarshal(pendingJson, &pending); err != nil {
+		return nil, err
+	}
+	if pending.Verified || time.Now().After(pending.ExpiresAt) {
+		return nil, nil
+	}
+
+	return &pending, nil
+}
+
+// completeRegistration creates the verified user and logs them in, the
+// registration stays pending until the user exists, so a failure can be retried
+func (h *handlerV1) completeRegistration(c *gin.Context, pending *models.RegisterUserModel) {
+	ctx, cancel := context.WithTimeout(h.gatewayContext(c), time.Second*time.Duration(h.cfg.CtxTimeOut))
+	defer cancel()
+
+	// the refresh tokens are kept by the sessions
+	created, err := h.serviceManager.UserService().CreateUser(ctx, &pb.User{
+		Id:        pending.Id,
+		FirstName: pending.FirstName,
+		LastName:  pending.LastName,
+		Age:       pending.Age,
+		Email:     pending.Email,
+		Password:  pending.PasswordHash,
+		Locale:    pending.Locale,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		h.log.Error("cannot create user", logger.Error(err))
+		return
+	}
+
+	// the registration is one-time, burn it together with its code and link
+	pending.Verified = true
+	if err := h.saveUntil(registrationKey(pending.Email), pending, pending.ExpiresAt); err != nil {
+		h.log.Error("cannot burn pending registration", logger.Error(err))
+	}
+
+	access, refresh, err := h.NewSession(c, pending.Id, h.subjectRole(pending.Id, RoleUser))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot create access and refresh token", logger.Error(err))
+		return
+	}
+
+	h.notify(created, email.TemplateWelcome, email.WelcomeData{
+		UserName: created.FirstName,
+	})
+
+	res := models.UserModel{
+		Id:           pending.Id,
+		FirstName:    pending.FirstName,
+		LastName:     pending.LastName,
+		Age:          pending.Age,
+		Email:        pending.Email,
+		Locale:       pending.Locale,
+		AccessToken:  access,
+		RefreshToken: refresh,
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Resend verification
+// @Summary resend verification code
+// @Tags User
+// @Description Send a new verification code and magic link for a pending registration, every resend waits twice as long as the previous one
+// @Accept json
+// @Produce json
+// @Param email body models.ResendVerificationReq true "email"
+// @Success 200 {object} models.SuperAdminMessage
+// @Failure 400 string Error models.ResponseError
+// @Failure 429 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+// @Router /v1/user/verify/resend [post]
+func (h *handlerV1) ResendVerification(c *gin.Context) {
+	var body models.ResendVerificationReq
+
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidJSON,
+			Message: err.Error(),
+		})
+		h.log.Error("failed to bind json", logger.Error(err))
+		return
+	}
+	body.Email = strings.ToLower(strings.TrimSpace(body.Email))
+
+	// the same answer is given when nothing is pending,
+	// so the endpoint cannot be used to find out who is registering
+	response := models.SuperAdminMessage{
+		Message: "if a registration is waiting for the email, a new code was sent to it",
+	}
+
+	pending, err := h.findPendingRegistration(body.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot get pending registration", logger.Error(err))
+		return
+	}
+	if pending == nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if !h.CheckResendCooldown(c, pending) {
+		return
+	}
+
+	pending.Resends++
+	if err := h.sendVerification(pending); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ResponseError{
+			Code:    ErrorCodeInternalServerError,
+			Message: err.Error(),
+		})
+		h.log.Error("cannot send a code to an email", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Verify user by magic link
+// @Summary verify user by magic link
+// @Tags User
+// @Description Verify a user with the signed link sent to their email, only the latest link of a registration works and only once
+// @Produce json
+// @Param token query string true "token"
+// @Success 200 {object} models.UserModel
+// @Failure 400 string Error models.ResponseError
+// @Failure 500 string Error models.ResponseError
+// @Router /v1/user/verify/link [get]
+func (h *handlerV1) VerifyLink(c *gin.Context) {
+	claims, err := tokens.ExtractClaim(c.Query("token"), h.jwtKeys, tokens.TypeVerify)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "link is invalid or expired, try again.",
+		})
+		return
+	}
+
+	pending, ok := h.getPendingRegistration(c, claims.Subject)
+	if !ok {
+		return
+	}
+
+	if pending.LinkId != claims.ID {
+		c.JSON(http.StatusBadRequest, models.ResponseError{
+			Code:    ErrorCodeInvalidCode,
+			Message: "link is invalid or expired, try again.",
+		})
+		return
+	}
+
+	h.completeRegistration(c, pending)
+}
@@ -0,0 +1,267 @@
+package v1
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/api/handlers/v1/tokens"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testPendingId    = "4b7e1c9a-2d3f-4e8b-a6c5-0f9d8e7c6b52"
+	testPendingEmail = "pending@example.com"
+)
+
+var mailedLinkPattern = regexp.MustCompile(`token=(\S+)`)
+
+// savePending keeps a registration waiting for the email as if its
+// last code was sent at lastSentAt
+func savePending(t *testing.T, h *handlerV1, resends int, lastSentAt time.Time, verified bool) {
+	t.Helper()
+
+	pending := models.RegisterUserModel{
+		Id:           testPendingId,
+		FirstName:    "Pending",
+		Email:        testPendingEmail,
+		PasswordHash: "$2a$04$hash",
+		Locale:       "en",
+		Resends:      resends,
+		LastSentAt:   lastSentAt,
+		Verified:     verified,
+		ExpiresAt:    time.Now().Add(registrationTimeout),
+	}
+	if err := h.saveUntil(registrationKey(testPendingEmail), pending, pending.ExpiresAt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// passCooldown moves the last email of the pending registration back,
+// its code and link stay as they were
+func passCooldown(t *testing.T, h *handlerV1) {
+	t.Helper()
+
+	pending, err := h.findPendingRegistration(testPendingEmail)
+	if err != nil || pending == nil {
+		t.Fatalf("pending = %v, %v", pending, err)
+	}
+	pending.LastSentAt = time.Now().Add(-time.Hour)
+	if err := h.saveUntil(registrationKey(testPendingEmail), pending, pending.ExpiresAt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func resend(h *handlerV1) (int, string) {
+	c, recorder := newRequestContext(http.MethodPost, "/v1/user/verify/resend", models.ResendVerificationReq{Email: " Pending@Example.com "}, "", "")
+	h.ResendVerification(c)
+	return recorder.Code, recorder.Header().Get("Retry-After")
+}
+
+// mailedLink returns the token of the magic link last sent to the address
+func mailedLink(t *testing.T, mails *mailbox, address string) string {
+	t.Helper()
+
+	messages := mails.to(address)
+	if len(messages) == 0 {
+		t.Fatalf("nothing was sent to %s", address)
+	}
+	match := mailedLinkPattern.FindStringSubmatch(messages[len(messages)-1].Text)
+	if match == nil {
+		t.Fatalf("no link in %q", messages[len(messages)-1].Text)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestResendVerification(t *testing.T) {
+	tests := []struct {
+		name       string
+		pending    bool
+		verified   bool
+		resends    int
+		sentAgo    time.Duration
+		wantCode   int
+		wantSent   bool
+		wantResend int
+	}{
+		// the answer does not tell whether somebody is registering
+		{name: "nothing pending", wantCode: http.StatusOK},
+		{name: "already verified", pending: true, verified: true, sentAgo: time.Hour, wantCode: http.StatusOK},
+		{name: "within cooldown", pending: true, sentAgo: resendCooldown / 2, wantCode: http.StatusTooManyRequests},
+		{name: "after cooldown", pending: true, sentAgo: resendCooldown, wantCode: http.StatusOK, wantSent: true, wantResend: 1},
+		{name: "within doubled cooldown", pending: true, resends: 2, sentAgo: resendCooldown * 3, wantCode: http.StatusTooManyRequests},
+		{name: "after doubled cooldown", pending: true, resends: 2, sentAgo: resendCooldown * 4, wantCode: http.StatusOK, wantSent: true, wantResend: 3},
+		{name: "out of resends", pending: true, resends: verifyMaxResends, sentAgo: time.Hour * 24, wantCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, mails := newUserTestHandler(t)
+			if tt.pending {
+				savePending(t, h, tt.resends, time.Now().Add(-tt.sentAgo), tt.verified)
+			}
+
+			code, retryAfter := resend(h)
+			if code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", code, tt.wantCode)
+			}
+			if sent := len(mails.to(testPendingEmail)) != 0; sent != tt.wantSent {
+				t.Fatalf("sent = %v, want %v", sent, tt.wantSent)
+			}
+			if tt.wantCode == http.StatusTooManyRequests && tt.resends < verifyMaxResends && retryAfter == "" {
+				t.Fatal("no Retry-After while cooling down")
+			}
+			if tt.wantSent {
+				pending, err := h.findPendingRegistration(testPendingEmail)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pending.Resends != tt.wantResend || pending.CodeHash == "" || pending.LinkId == "" {
+					t.Fatalf("pending = %+v", pending)
+				}
+				if strings.Contains(mails.to(testPendingEmail)[0].Text, pending.CodeHash) {
+					t.Fatal("the hash of the code was mailed")
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyLink(t *testing.T) {
+	tests := []struct {
+		name string
+		// link returns the token the user opens
+		link        func(t *testing.T, h *handlerV1, mails *mailbox) string
+		wantCode    int
+		wantCreated bool
+	}{
+		{
+			name:        "latest link",
+			link:        func(t *testing.T, h *handlerV1, mails *mailbox) string { return mailedLink(t, mails, testPendingEmail) },
+			wantCode:    http.StatusOK,
+			wantCreated: true,
+		},
+		{
+			name: "link opened twice",
+			link: func(t *testing.T, h *handlerV1, mails *mailbox) string {
+				token := mailedLink(t, mails, testPendingEmail)
+				c, recorder := newRequestContext(http.MethodGet, "/v1/user/verify/link?token="+url.QueryEscape(token), nil, "", "")
+				h.VerifyLink(c)
+				if recorder.Code != http.StatusOK {
+					t.Fatalf("first open answered %d", recorder.Code)
+				}
+				return token
+			},
+			wantCode:    http.StatusBadRequest,
+			wantCreated: true,
+		},
+		{
+			name: "link replaced by a resend",
+			link: func(t *testing.T, h *handlerV1, mails *mailbox) string {
+				token := mailedLink(t, mails, testPendingEmail)
+				passCooldown(t, h)
+				if code, _ := resend(h); code != http.StatusOK {
+					t.Fatalf("resend answered %d", code)
+				}
+				return token
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "access token of the user",
+			link: func(t *testing.T, h *handlerV1, mails *mailbox) string {
+				access, _, err := tokens.JWTHandler{Sub: testPendingEmail, Role: RoleUser, Keys: h.jwtKeys, Log: h.log, Timeout: 15, RefreshTimeout: 1}.GenerateAuthJWT()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return access
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "garbage",
+			link:     func(t *testing.T, h *handlerV1, mails *mailbox) string { return "not-a-token" },
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, services, mails := newUserTestHandler(t)
+			savePending(t, h, 0, time.Now().Add(-time.Hour), false)
+			if code, _ := resend(h); code != http.StatusOK {
+				t.Fatalf("resend answered %d", code)
+			}
+			token := tt.link(t, h, mails)
+
+			c, recorder := newRequestContext(http.MethodGet, "/v1/user/verify/link?token="+url.QueryEscape(token), nil, "", "")
+			h.VerifyLink(c)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+
+			_, created := services.users.get(testPendingId)
+			if created != tt.wantCreated {
+				t.Fatalf("user created = %v, want %v", created, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestVerifyCodeAfterResend(t *testing.T) {
+	h, _, mails := newUserTestHandler(t)
+	savePending(t, h, 0, time.Now().Add(-time.Hour), false)
+	verify := func(code string) int {
+		c, recorder := newRequestContext(http.MethodGet, "/v1/user/verify/"+testPendingEmail+"/"+code, nil, "", "", gin.Param{Key: "email", Value: testPendingEmail}, gin.Param{Key: "code", Value: code})
+		h.Verify(c)
+		return recorder.Code
+	}
+
+	resend(h)
+	first := mailedCode(t, mails, testPendingEmail)
+	passCooldown(t, h)
+	resend(h)
+	second := mailedCode(t, mails, testPendingEmail)
+
+	if first != second {
+		if code := verify(first); code != http.StatusBadRequest {
+			t.Fatalf("the replaced code answered %d", code)
+		}
+	}
+	if code := verify(second); code != http.StatusOK {
+		t.Fatalf("the latest code answered %d", code)
+	}
+}
+
+func TestRegisterKeepsNoPassword(t *testing.T) {
+	h, _, _ := newUserTestHandler(t)
+	storage := h.inMemoryStorage.(*memoryStorage)
+
+	c, recorder := newRequestContext(http.MethodPost, "/v1/user/register", models.UserRequest{
+		FirstName: "New",
+		LastName:  "Userov",
+		Age:       20,
+		Email:     "new@example.com",
+		Password:  "Secret-pass1",
+	}, "", "")
+	h.Register(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("register answered %d: %s", recorder.Code, recorder.Body)
+	}
+
+	stored, ok := storage.get(registrationKey("new@example.com"))
+	if !ok {
+		t.Fatal("no pending registration")
+	}
+	if strings.Contains(stored, "Secret-pass1") || !strings.Contains(stored, `"password_hash":"$2a$`) {
+		t.Fatalf("pending registration = %s", stored)
+	}
+}
@@ -1,24 +1,21 @@
 package api
 
 import (
-	// casb "exam/api-gateway/api/casbin"
+	casb "exam/api-gateway/api/casbin"
 	_ "exam/api-gateway/api/docs"
 	v1 "exam/api-gateway/api/handlers/v1"
 	"exam/api-gateway/api/handlers/v1/tokens"
 	"exam/api-gateway/config"
+	"exam/api-gateway/email"
 	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/pkg/oidc"
 	"exam/api-gateway/services"
 	"exam/api-gateway/storage/repo"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
-	"fmt"
-	gormadapter "github.com/casbin/gorm-adapter/v3"
-
 	admin "exam/api-gateway/storage/postgresrepo"
 
-	"github.com/casbin/casbin/v2"
-	"github.com/casbin/casbin/v2/util"
 	"github.com/gin-gonic/gin"
 	//swaggerFiles "github.com/swaggo/files"
 	//ginSwagger "github.com/swaggo/gin-swagger"
@@ -31,6 +28,17 @@ type Option struct {
 	Logger         logger.Logger
 	ServiceManager services.IServiceManager
 	Postgres       admin.AdminStorageI
+	Sessions       admin.SessionStorageI
+	MFA            admin.MFAStorageI
+	Roles          admin.RoleStorageI
+	Policies       admin.PolicyStorageI
+	APIKeys        admin.APIKeyStorageI
+	// Watcher syncs the policy with the other gateways, the enforcer is not watched when it is nil
+	Watcher *casb.Watcher
+	Mailer  email.Mailer
+	// OIDCProviders are added to the OpenID Connect providers of Cfg,
+	// identity providers that do not speak OpenID Connect plug in here
+	OIDCProviders map[string]oidc.Provider
 }
 
 // New -> constructor
@@ -41,40 +49,49 @@ type Option struct {
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func New(option Option) *gin.Engine {
-	psqlString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		option.Cfg.PostgresHost,
-		option.Cfg.PostgresPort,
-		option.Cfg.PostgresUser,
-		option.Cfg.PostgresPassword,
-		option.Cfg.PostgresDatabase)
-
-	adapter, err := gormadapter.NewAdapter("postgres", psqlString, true)
+	casbinEnforcer, err := casb.NewEnforcer(option.Cfg)
 	if err != nil {
-		option.Logger.Fatal("error while updating new adapter", logger.Error(err))
+		option.Logger.Fatal("cannot create a new enforcer", logger.Error(err))
 	}
 
-	casbinEnforcer, err := casbin.NewEnforcer(option.Cfg.AuthConfigPath, adapter)
-	if err != nil {
-		option.Logger.Error("cannot create a new enforcer", logger.Error(err))
+	if option.Watcher != nil {
+		if err := casb.WatchPolicy(casbinEnforcer, option.Watcher); err != nil {
+			option.Logger.Fatal("cannot watch casbin policy", logger.Error(err))
+		}
 	}
 
-	err = casbinEnforcer.LoadPolicy()
+	router, err := newEngine(option.Cfg)
 	if err != nil {
-		panic(err)
+		option.Logger.Fatal("cannot set trusted proxies", logger.Error(err))
 	}
 
-	casbinEnforcer.GetRoleManager().AddMatchingFunc("keyMatch", util.KeyMatch)
-	casbinEnforcer.GetRoleManager().AddMatchingFunc("keyMatch3", util.KeyMatch3)
-
-	router := gin.New()
-
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	jwtKeys, err := tokens.LoadKeys(option.Cfg)
+	if err != nil {
+		option.Logger.Fatal("cannot load jwt keys", logger.Error(err))
+	}
 
 	jwtHandle := tokens.JWTHandler{
-		SignInKey: option.Cfg.SignInKey,
-		Log:       option.Logger,
+		Keys: jwtKeys,
+		Log:  option.Logger,
+	}
+
+	oidcProviders := make(map[string]oidc.Provider)
+	for _, provider := range option.Cfg.OIDCProviders {
+		oidcProviders[provider.Name] = oidc.NewProvider(oidc.Config{
+			Name:         provider.Name,
+			Issuer:       provider.Issuer,
+			ClientId:     provider.ClientId,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  option.Cfg.PublicURL + "/v1/oauth/" + provider.Name + "/callback",
+			Scopes:       provider.Scopes,
+		})
+	}
+	for name, provider := range option.OIDCProviders {
+		oidcProviders[name] = provider
 	}
 
 	handlerV1 := v1.New(&v1.HandlerV1Config{
@@ -83,30 +100,76 @@ func New(option Option) *gin.Engine {
 		ServiceManager:  option.ServiceManager,
 		Cfg:             option.Cfg,
 		JWTHandler:      jwtHandle,
+		JWTKeys:         jwtKeys,
 		Postgres:        option.Postgres,
+		Sessions:        option.Sessions,
+		MFA:             option.MFA,
+		Roles:           option.Roles,
+		Policies:        option.Policies,
+		APIKeys:         option.APIKeys,
+		OIDCProviders:   oidcProviders,
+		Routes:          router.Routes,
+		Mailer:          option.Mailer,
 		Casbin:          casbinEnforcer,
 	})
 
+	router.GET("/.well-known/jwks.json", handlerV1.GetJWKS)
+
 	api := router.Group("/v1")
 
-	// api.Use(casb.NewAuth(casbinEnforcer, option.Cfg))
+	api.Use(casb.NewAuth(casbinEnforcer, option.Cfg, option.InMemory, jwtKeys, option.APIKeys))
 
 	//rbac
-	api.GET("/rbac/roles", handlerV1.ListAllRoles)              //superadmin
-	api.GET("/rbac/policies/:role", handlerV1.ListRolePolicies) //superadmin
-	api.POST("/rbac/add/policy", handlerV1.AddPolicyToRole)     //superadmin
-	api.DELETE("/rbac/delete/policy", handlerV1.DeletePolicy)   //superadmin
+	api.GET("/rbac/roles", handlerV1.ListAllRoles)                        //superadmin
+	api.POST("/rbac/roles", handlerV1.CreateRole)                         //superadmin
+	api.GET("/rbac/roles/:role", handlerV1.GetRole)                       //superadmin
+	api.PUT("/rbac/roles/:role", handlerV1.UpdateRole)                    //superadmin
+	api.DELETE("/rbac/roles/:role", handlerV1.DeleteRole)                 //superadmin
+	api.GET("/rbac/policies/:role", handlerV1.ListRolePolicies)           //superadmin
+	api.POST("/rbac/add/policy", handlerV1.AddPolicyToRole)               //superadmin
+	api.DELETE("/rbac/delete/policy", handlerV1.DeletePolicy)             //superadmin
+	api.GET("/rbac/policy/export", handlerV1.ExportPolicy)                //superadmin
+	api.POST("/rbac/policy/import", handlerV1.ImportPolicy)               //superadmin
+	api.GET("/rbac/policy/validate", handlerV1.ValidatePolicy)            //superadmin
+	api.POST("/rbac/check", handlerV1.CheckAuthorization)                 //superadmin
+	api.GET("/rbac/inheritance", handlerV1.ListRoleInheritance)           //superadmin
+	api.POST("/rbac/inheritance", handlerV1.AddRoleInheritance)           //superadmin
+	api.DELETE("/rbac/inheritance", handlerV1.DeleteRoleInheritance)      //superadmin
+	api.GET("/rbac/subjects/:sub/role", handlerV1.GetSubjectRole)         //superadmin
+	api.PUT("/rbac/subjects/:sub/role", handlerV1.AssignSubjectRole)      //superadmin
+	api.DELETE("/rbac/subjects/:sub/role", handlerV1.UnassignSubjectRole) //superadmin
 
 	//users
-	api.POST("/user/create", handlerV1.CreateUser)         //admin
-	api.POST("/user/register", handlerV1.Register)         //unauthorized
-	api.GET("/user/:id", handlerV1.GetUserById)            //user
-	api.PUT("/user/update/:id", handlerV1.UpdateUser)      //user
-	api.DELETE("/user/delete/:id", handlerV1.DeleteUser)   //admin
-	api.GET("/users/:page/:limit", handlerV1.GetAllUsers)  //admin
-	api.GET("/user/verify/:email/:code", handlerV1.Verify) //unauthorized
-	api.POST("/user/login", handlerV1.Login)               //unauthorized
-	api.POST("/user/refresh", handlerV1.UpdateRefreshToken)
+	api.POST("/user/create", handlerV1.CreateUser)                 //admin
+	api.POST("/user/register", handlerV1.Register)                 //unauthorized
+	api.GET("/user/:id", handlerV1.GetUserById)                    //user
+	api.PUT("/user/update/:id", handlerV1.UpdateUser)              //user
+	api.DELETE("/user/delete/:id", handlerV1.DeleteUser)           //admin
+	api.POST("/user/restore/:id", handlerV1.RestoreUser)           //admin
+	api.GET("/users/:page/:limit", handlerV1.GetAllUsers)          //admin
+	api.GET("/user/verify/:email/:code", handlerV1.Verify)         //unauthorized
+	api.POST("/user/verify/resend", handlerV1.ResendVerification)  //unauthorized
+	api.GET("/user/verify/link", handlerV1.VerifyLink)             //unauthorized
+	api.POST("/user/login", handlerV1.Login)                       //unauthorized
+	api.POST("/user/refresh", handlerV1.UpdateRefreshToken)        //unauthorized
+	api.POST("/user/password/forgot", handlerV1.ForgotPassword)    //unauthorized
+	api.POST("/user/password/reset", handlerV1.ResetPassword)      //unauthorized
+	api.POST("/user/password/change", handlerV1.ChangePassword)    //user
+	api.POST("/user/email/change", handlerV1.ChangeEmail)          //user
+	api.POST("/user/email/verify", handlerV1.VerifyEmailChange)    //user
+	api.POST("/user/logout", handlerV1.LogoutUser)                 //user
+	api.DELETE("/user/sessions/:id", handlerV1.RevokeUserSessions) //admin
+
+	//oauth
+	api.GET("/oauth/providers", handlerV1.ListOIDCProviders)     //unauthorized
+	api.GET("/oauth/:provider/login", handlerV1.OIDCLogin)       //unauthorized
+	api.GET("/oauth/:provider/callback", handlerV1.OIDCCallback) //unauthorized
+
+	//mfa
+	api.POST("/mfa/setup", handlerV1.SetupMFA)       //unauthorized
+	api.POST("/mfa/activate", handlerV1.ActivateMFA) //unauthorized
+	api.POST("/mfa/verify", handlerV1.VerifyMFA)     //unauthorized
+	api.POST("/mfa/disable", handlerV1.DisableMFA)   //user
 
 	//product
 	api.POST("/product/create", handlerV1.CreateProduct)                 //admin
@@ -120,11 +183,40 @@ func New(option Option) *gin.Engine {
 	api.POST("/product/decrease", handlerV1.DecreaseAmountOfProduct)     //admin
 
 	//admin
-	api.POST("/auth/create", handlerV1.CreateAdmin)   //superadmin
-	api.DELETE("/auth/delete", handlerV1.DeleteAdmin) //superadmin
-	api.POST("/auth/login", handlerV1.LoginAdmin)     //admin
+	api.POST("/auth/create", handlerV1.CreateAdmin)                           //superadmin
+	api.DELETE("/auth/delete", handlerV1.DeleteAdmin)                         //superadmin
+	api.POST("/auth/login", handlerV1.LoginAdmin)                             //admin
+	api.POST("/auth/logout", handlerV1.LogoutAdmin)                           //admin
+	api.POST("/auth/password/change", handlerV1.ChangeAdminPassword)          //unauthorized
+	api.GET("/auth/admins", handlerV1.ListAdmins)                             //superadmin
+	api.GET("/auth/admins/:id", handlerV1.GetAdmin)                           //superadmin
+	api.PUT("/auth/admins/:id", handlerV1.UpdateAdmin)                        //superadmin
+	api.POST("/auth/admins/:id/disable", handlerV1.DisableAdmin)              //superadmin
+	api.POST("/auth/admins/:id/enable", handlerV1.EnableAdmin)                //superadmin
+	api.POST("/auth/admins/:id/password/reset", handlerV1.ResetAdminPassword) //superadmin
+	api.GET("/auth/api-keys", handlerV1.ListAPIKeys)                          //superadmin
+	api.POST("/auth/api-keys", handlerV1.CreateAPIKey)                        //superadmin
+	api.GET("/auth/api-keys/:id", handlerV1.GetAPIKey)                        //superadmin
+	api.POST("/auth/api-keys/:id/revoke", handlerV1.RevokeAPIKey)             //superadmin
+	api.POST("/auth/api-keys/:id/rotate", handlerV1.RotateAPIKey)             //superadmin
 
 	url := ginSwagger.URL("swagger/doc.json")
 	api.GET("swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 	return router
 }
+
+// newEngine returns the engine the routes are added to. Failed logins are
+// counted per client ip, so X-Forwarded-For is only believed when it comes
+// from a trusted proxy, anybody else could pick a new ip for every attempt
+func newEngine(cfg config.Config) (*gin.Engine, error) {
+	router := gin.New()
+
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+
+	return router, nil
+}
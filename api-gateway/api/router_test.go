@@ -0,0 +1,78 @@
+package api
+
+import (
+	"exam/api-gateway/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestNewEngineClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		peer    string
+		want    map[string]int
+	}{
+		{
+			name: "no trusted proxy",
+			peer: "192.0.2.1",
+			want: map[string]int{"192.0.2.1": 3},
+		},
+		{
+			name:    "untrusted peer",
+			proxies: []string{"10.0.0.0/8"},
+			peer:    "192.0.2.1",
+			want:    map[string]int{"192.0.2.1": 3},
+		},
+		{
+			name:    "trusted proxy",
+			proxies: []string{"10.0.0.0/8"},
+			peer:    "10.0.0.2",
+			want:    map[string]int{"198.51.100.1": 1, "198.51.100.2": 1, "198.51.100.3": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := newEngine(config.Config{TrustedProxies: tt.proxies})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// failed logins are counted per c.ClientIP()
+			counters := map[string]int{}
+			router.POST("/v1/user/login", func(c *gin.Context) {
+				counters[c.ClientIP()]++
+			})
+
+			for _, forwarded := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+				req := httptest.NewRequest(http.MethodPost, "/v1/user/login", nil)
+				req.RemoteAddr = tt.peer + ":1234"
+				req.Header.Set("X-Forwarded-For", forwarded)
+				router.ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			if len(counters) != len(tt.want) {
+				t.Fatalf("counters = %v, want %v", counters, tt.want)
+			}
+			for ip, count := range tt.want {
+				if counters[ip] != count {
+					t.Fatalf("counters = %v, want %v", counters, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewEngineInvalidProxy(t *testing.T) {
+	if _, err := newEngine(config.Config{TrustedProxies: []string{"not-an-ip"}}); err == nil {
+		t.Fatal("an invalid trusted proxy was accepted")
+	}
+}
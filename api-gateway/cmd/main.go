@@ -1,32 +1,34 @@
 package main
 
 import (
+	"context"
 	"exam/api-gateway/api"
+	casb "exam/api-gateway/api/casbin"
 	"exam/api-gateway/config"
+	"exam/api-gateway/email"
 	"exam/api-gateway/pkg/db"
-	"exam/api-gateway/pkg/etc"
 	"exam/api-gateway/pkg/logger"
 	"exam/api-gateway/services"
 	admin "exam/api-gateway/storage/postgres"
 	"exam/api-gateway/storage/redis"
 	"fmt"
+	"time"
 
 	rds "github.com/gomodule/redigo/redis"
 )
 
 func main() {
-	//login superadmin -> username = 'a' -> password = 'b'
-	fmt.Println(etc.HashPassword("admin"))
-				
 	cfg := config.Load()
 	log := logger.New(cfg.LogLevel, "api_gateway")
 
-	serviceManager, err := services.NewServiceManager(&cfg)
-	if err != nil {
-		log.Error("gRPC dial error", logger.Error(err))
+	if cfg.UserServiceTokenKey == "" || cfg.ProductServiceTokenKey == "" {
+		log.Fatal("a service token key is required for every service")
 	}
-	fmt.Println(etc.HashPassword("b"))
 
+	serviceManager, err := services.NewServiceManager(&cfg, log)
+	if err != nil {
+		log.Fatal("gRPC dial error", logger.Error(err))
+	}
 
 	redisPool := rds.Pool{
 		MaxIdle:   80,
@@ -46,12 +48,36 @@ func main() {
 		panic(err)
 	}
 
+	mailer, err := email.NewMailer(cfg)
+	if err != nil {
+		log.Fatal("cannot create mailer", logger.Error(err))
+	}
+
+	// handlers only queue emails, the sender delivers them in the background
+	outbox := admin.NewOutboxRepo(db)
+	sender := email.NewSender(outbox, mailer, log, cfg.MailMaxAttempts, time.Second*time.Duration(cfg.MailSendInterval))
+	go sender.Run(context.Background())
+
+	// every gateway applies the policy changes made by the others
+	watcher, err := casb.NewWatcher(cfg, db, log)
+	if err != nil {
+		log.Fatal("cannot create casbin policy watcher", logger.Error(err))
+	}
+	defer watcher.Close()
+
 	server := api.New(api.Option{
 		InMemory:       redis.NewRedisRepo(&redisPool),
 		Cfg:            cfg,
 		Logger:         log,
 		ServiceManager: serviceManager,
 		Postgres:       admin.NewAdminRepo(db),
+		Sessions:       admin.NewSessionRepo(db),
+		MFA:            admin.NewMFARepo(db),
+		Roles:          admin.NewRoleRepo(db),
+		Policies:       admin.NewPolicyRepo(db),
+		APIKeys:        admin.NewAPIKeyRepo(db),
+		Watcher:        watcher,
+		Mailer:         email.NewOutboxMailer(outbox),
 	})
 
 	if err := server.Run(cfg.HTTPPort); err != nil {
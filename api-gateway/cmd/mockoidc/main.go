@@ -0,0 +1,44 @@
+// Command mockoidc is an OpenID Connect provider for trying the identity
+// provider login offline. It signs everybody in without asking, as the
+// user given by the flags or by the login_hint of the authorization request:
+//
+//	mockoidc [-addr :9090] [-issuer http://localhost:9090] [-client-id exam]
+//	         [-email user@example.com] [-given-name Test] [-family-name User]
+//	         [-email-verified=true]
+//
+// Point the gateway at it with
+//
+//	OIDC_PROVIDERS=local OIDC_LOCAL_ISSUER=http://localhost:9090 OIDC_LOCAL_CLIENT_ID=exam
+//
+// and open /v1/oauth/local/login in a browser.
+package main
+
+import (
+	"exam/api-gateway/pkg/oidc/oidctest"
+	"flag"
+	"log"
+	"net/http"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	issuer := flag.String("issuer", "http://localhost:9090", "issuer the gateway is configured with")
+	clientId := flag.String("client-id", "exam", "client id the gateway is configured with")
+	email := flag.String("email", "user@example.com", "email of the user signing in without login_hint")
+	givenName := flag.String("given-name", "Test", "given name of the user")
+	familyName := flag.String("family-name", "User", "family name of the user")
+	emailVerified := flag.Bool("email-verified", true, "whether the email is reported as verified")
+	flag.Parse()
+
+	p, err := oidctest.New(*issuer, *clientId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p.Email = *email
+	p.GivenName = *givenName
+	p.FamilyName = *familyName
+	p.EmailVerified = *emailVerified
+
+	log.Printf("mock oidc provider %s listening on %s", p.Issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, p.Handler()))
+}
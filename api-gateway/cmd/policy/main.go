@@ -0,0 +1,158 @@
+// Command policy exports, validates and imports the casbin policy of the
+// gateway. It reads the same environment as the gateway and checks every
+// rule against the routes registered in api.New, so policy drift is caught
+// before a deploy:
+//
+//	policy export [-format csv|json] [-out file]
+//	policy validate [-format csv|json] [-file file]
+//	policy import [-format csv|json] [-dry-run] [-force] -file file
+//
+// validate checks the live policy when no file is given. Running gateways
+// reload the policy as soon as an import commits.
+package main
+
+import (
+	"encoding/json"
+	"exam/api-gateway/api"
+	casb "exam/api-gateway/api/casbin"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/config"
+	"exam/api-gateway/pkg/db"
+	"exam/api-gateway/pkg/logger"
+	admin "exam/api-gateway/storage/postgres"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const usage = `usage:
+  policy export [-format csv|json] [-out file]
+  policy validate [-format csv|json] [-file file]
+  policy import [-format csv|json] [-dry-run] [-force] -file file`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	format := flags.String("format", casb.PolicyFormatCSV, "policy file format, csv or json")
+	out := flags.String("out", "", "export: file to write, stdout by default")
+	file := flags.String("file", "", "validate, import: policy file to read")
+	dryRun := flags.Bool("dry-run", false, "import: only validate the file")
+	force := flags.Bool("force", false, "import: import even if rules match no route or belong to undefined roles")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[2:])
+
+	cfg := config.Load()
+	log := logger.New(cfg.LogLevel, "api_gateway_policy")
+
+	conn, cleanUp, err := db.ConnectToDB(cfg)
+	if err != nil {
+		log.Fatal("cannot connect to postgres", logger.Error(err))
+	}
+	defer cleanUp()
+
+	enforcer, err := casb.NewEnforcer(cfg)
+	if err != nil {
+		log.Fatal("cannot create a new enforcer", logger.Error(err))
+	}
+
+	switch os.Args[1] {
+	case "export":
+		w := io.Writer(os.Stdout)
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				log.Fatal("cannot create the policy file", logger.Error(err))
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := casb.WritePolicy(w, *format, casb.ExportPolicy(enforcer)); err != nil {
+			log.Fatal("cannot export policy", logger.Error(err))
+		}
+	case "validate":
+		rules := casb.ExportPolicy(enforcer)
+		if *file != "" {
+			rules = readPolicy(log, *file, *format)
+		}
+		if report := validate(log, cfg, conn, rules); !report.Valid {
+			os.Exit(1)
+		}
+	case "import":
+		if *file == "" {
+			flags.Usage()
+			os.Exit(2)
+		}
+		rules := readPolicy(log, *file, *format)
+		report := validate(log, cfg, conn, rules)
+		if len(report.Invalid) > 0 || (!report.Valid && !*force) {
+			log.Error("the policy file is refused, fix it or import it with -force")
+			os.Exit(1)
+		}
+		if *dryRun {
+			return
+		}
+		if err := admin.NewPolicyRepo(conn).Replace(rules); err != nil {
+			log.Fatal("cannot import policy", logger.Error(err))
+		}
+		log.Info("policy imported", logger.Int("rules", len(rules)))
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
+
+func readPolicy(log logger.Logger, path, format string) []models.CasbinRule {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal("cannot open the policy file", logger.Error(err))
+	}
+	defer f.Close()
+
+	rules, err := casb.ReadPolicy(f, format)
+	if err != nil {
+		log.Fatal("cannot read the policy file", logger.Error(err))
+	}
+
+	return rules
+}
+
+// validate prints the report of the rules to stdout
+func validate(log logger.Logger, cfg config.Config, conn *sqlx.DB, rules []models.CasbinRule) *models.PolicyReport {
+	roles, err := admin.NewRoleRepo(conn).List()
+	if err != nil {
+		log.Fatal("cannot list roles", logger.Error(err))
+	}
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+
+	// the routes come from the same router the gateway serves,
+	// handlers are never called so they get no storages
+	gin.SetMode(gin.ReleaseMode)
+	router := api.New(api.Option{
+		Cfg:    cfg,
+		Logger: log,
+	})
+
+	report := casb.ValidatePolicy(rules, names, router.Routes())
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal("cannot print the report", logger.Error(err))
+	}
+
+	return report
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 
 	"github.com/spf13/cast"
 )
@@ -18,6 +19,12 @@ type Config struct {
 	ProductServiceHost string
 	ProductServicePort int
 
+	//grpc is only dialed in plaintext when TLSDisabled is set
+	TLSDisabled bool
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string //verifies the certificates of the services
+
 	PostgresHost     string
 	PostgresPort     int
 	PostgresDatabase string
@@ -27,15 +34,54 @@ type Config struct {
 	//context timeout in seconds
 	CtxTimeOut int
 
-	LogLevel string
-	HTTPPort string
+	LogLevel  string
+	HTTPPort  string
+	PublicURL string //where clients reach the gateway, used in emailed links
+
+	//proxies whose X-Forwarded-For is believed, the peer address is the client ip otherwise
+	TrustedProxies []string
 
 	AccessTokenTimeout  int //minutes
 	RefreshTokenTimeout int //hours
 	AuthConfigPath      string
 	AuthCSVPath         string
 
-	SignInKey string
+	SignInKey string //HS256 secret, required when no signing key file is used
+
+	//sign the caller identity forwarded to each service, every service has its own key
+	UserServiceTokenKey    string
+	ProductServiceTokenKey string
+
+	SigningAlgorithm    string //HS256, RS256, EdDSA
+	SigningKeyPath      string
+	SigningKeyId        string
+	VerificationKeysDir string //<kid>.pem public keys accepted during a rotation
+	JWTIssuer           string
+	JWTAudience         string
+
+	MFAIssuer string //name authenticator apps show next to the code
+
+	OIDCProviders []OIDCProvider //identity providers users can log in with
+
+	MailDriver    string //smtp, file
+	MailFrom      string
+	MailOutboxDir string //where the file driver writes messages
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+
+	MailMaxAttempts  int //sending attempts before an email is dead-lettered
+	MailSendInterval int //seconds between outbox polls
+}
+
+// OIDCProvider is an OpenID Connect issuer, its callback is PublicURL/v1/oauth/<name>/callback
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	Scopes       []string
 }
 
 // Load loads environment vars and inflates Config
@@ -46,6 +92,10 @@ func Load() Config {
 
 	c.LogLevel = cast.ToString(getOrReturnDefault("LOG_LEVEL", "debug"))
 	c.HTTPPort = cast.ToString(getOrReturnDefault("HTTP_PORT", ":4040"))
+	c.PublicURL = cast.ToString(getOrReturnDefault("PUBLIC_URL", "http://localhost:4040"))
+	c.TrustedProxies = strings.FieldsFunc(cast.ToString(getOrReturnDefault("TRUSTED_PROXIES", "")), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
 
 	c.RedisHost = cast.ToString(getOrReturnDefault("REDIS_HOST", "localhost"))
 	c.RedisPort = cast.ToInt(getOrReturnDefault("REDIS_PORT", 6379))
@@ -62,23 +112,75 @@ func Load() Config {
 	c.ProductServiceHost = cast.ToString(getOrReturnDefault("PRODUCT_SERVICE_HOST", "productservice"))
 	c.ProductServicePort = cast.ToInt(getOrReturnDefault("PRODUCT_SERVICE_PORT", 5050))
 
-	c.AccessTokenTimeout = cast.ToInt(getOrReturnDefault("ACCESS_TOKEN_TIMEOUT", 1000))
-	c.RefreshTokenTimeout = cast.ToInt(getOrReturnDefault("REFRESH_TOKEN_TIMEOUT", 1000))
+	c.TLSDisabled = cast.ToBool(getOrReturnDefault("TLS_DISABLED", false))
+	c.TLSCertFile = cast.ToString(getOrReturnDefault("GRPC_TLS_CERT", ""))
+	c.TLSKeyFile = cast.ToString(getOrReturnDefault("GRPC_TLS_KEY", ""))
+	c.TLSCAFile = cast.ToString(getOrReturnDefault("GRPC_TLS_CA", ""))
+
+	c.AccessTokenTimeout = cast.ToInt(getOrReturnDefault("ACCESS_TOKEN_TIMEOUT", 15))
+	c.RefreshTokenTimeout = cast.ToInt(getOrReturnDefault("REFRESH_TOKEN_TIMEOUT", 720))
 
 	c.AuthConfigPath = cast.ToString(getOrReturnDefault("AUTH_CONFIG_PATH", "./config/auth.conf"))
 	c.AuthCSVPath = cast.ToString(getOrReturnDefault("AUTH_CSV_PATH", "./config/auth.csv"))
 
 	c.CtxTimeOut = cast.ToInt(getOrReturnDefault("CTX_TIMEOUT", 7))
 
-	c.SignInKey = cast.ToString(getOrReturnDefault("SIGN_IN_KEY", "golang-exam-3d-month"))
+	c.SignInKey = cast.ToString(getOrReturnDefault("SIGN_IN_KEY", ""))
+	c.UserServiceTokenKey = cast.ToString(getOrReturnDefault("USER_SERVICE_TOKEN_KEY", ""))
+	c.ProductServiceTokenKey = cast.ToString(getOrReturnDefault("PRODUCT_SERVICE_TOKEN_KEY", ""))
+
+	c.SigningAlgorithm = cast.ToString(getOrReturnDefault("SIGNING_ALGORITHM", "HS256"))
+	c.SigningKeyPath = cast.ToString(getOrReturnDefault("SIGNING_KEY_PATH", ""))
+	c.SigningKeyId = cast.ToString(getOrReturnDefault("SIGNING_KEY_ID", ""))
+	c.VerificationKeysDir = cast.ToString(getOrReturnDefault("VERIFICATION_KEYS_DIR", ""))
+	c.JWTIssuer = cast.ToString(getOrReturnDefault("JWT_ISSUER", "exam-api-gateway"))
+	c.JWTAudience = cast.ToString(getOrReturnDefault("JWT_AUDIENCE", "exam"))
+
+	c.MFAIssuer = cast.ToString(getOrReturnDefault("MFA_ISSUER", "exam"))
+
+	c.OIDCProviders = loadOIDCProviders()
+
+	c.MailDriver = cast.ToString(getOrReturnDefault("MAIL_DRIVER", "file"))
+	c.MailFrom = cast.ToString(getOrReturnDefault("MAIL_FROM", "no-reply@exam.local"))
+	c.MailOutboxDir = cast.ToString(getOrReturnDefault("MAIL_OUTBOX_DIR", "./outbox"))
+	c.SMTPHost = cast.ToString(getOrReturnDefault("SMTP_HOST", "smtp.gmail.com"))
+	c.SMTPPort = cast.ToInt(getOrReturnDefault("SMTP_PORT", 587))
+	c.SMTPUsername = cast.ToString(getOrReturnDefault("SMTP_USERNAME", ""))
+	c.SMTPPassword = cast.ToString(getOrReturnDefault("SMTP_PASSWORD", ""))
+	c.MailMaxAttempts = cast.ToInt(getOrReturnDefault("MAIL_MAX_ATTEMPTS", 8))
+	c.MailSendInterval = cast.ToInt(getOrReturnDefault("MAIL_SEND_INTERVAL", 5))
 
 	return c
 }
 
+// loadOIDCProviders reads the providers named in OIDC_PROVIDERS, "google,local"
+// is configured with OIDC_GOOGLE_ISSUER, OIDC_GOOGLE_CLIENT_ID, OIDC_GOOGLE_CLIENT_SECRET,
+// OIDC_GOOGLE_SCOPES and the same variables of OIDC_LOCAL
+func loadOIDCProviders() []OIDCProvider {
+	providers := []OIDCProvider{}
+	for _, name := range strings.Split(cast.ToString(getOrReturnDefault("OIDC_PROVIDERS", "")), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			Issuer:       cast.ToString(getOrReturnDefault(prefix+"ISSUER", "")),
+			ClientId:     cast.ToString(getOrReturnDefault(prefix+"CLIENT_ID", "")),
+			ClientSecret: cast.ToString(getOrReturnDefault(prefix+"CLIENT_SECRET", "")),
+			Scopes:       strings.Fields(cast.ToString(getOrReturnDefault(prefix+"SCOPES", ""))),
+		})
+	}
+
+	return providers
+}
+
 func getOrReturnDefault(key string, defaultValue interface{}) interface{} {
-	_, exists := os.LookupEnv(key)
+	value, exists := os.LookupEnv(key)
 	if exists {
-		return defaultValue
+		return value
 	}
 
 	return defaultValue
@@ -1,55 +1,75 @@
 package email
 
 import (
-	"html/template"
-	"log"
-	"net/smtp"
-	"os"
+	"exam/api-gateway/config"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
 	"strings"
+	"time"
 )
 
-type Params struct {
-	From     string
-	Password string
-	To       string
-	Message  string
-	Code     string
-	UserName string
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+// Message is a rendered email ready to be delivered,
+// Text is the plain part for clients that do not show HTML
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
 }
 
-// Sending OTP to user via an email (registration)
-func SendVerificationCode(params Params) error {
-	htmlFile, err := os.ReadFile("email/format.html")
-	if err != nil {
-		log.Println("Cannot read html file", err.Error())
-		return err
-	}
-	temp, err := template.New("email").Parse(string(htmlFile))
-	if err != nil {
-		log.Println("Cannot parse html file")
-		return err
-	}
+// Mailer delivers messages, SMTP in production and
+// a directory of .eml files in development and tests
+type Mailer interface {
+	Send(message Message) error
+}
 
-	var Builder strings.Builder
-	err = temp.Execute(&Builder, params)
-	if err != nil {
-		log.Println("Cannot execute HTML", err.Error())
-		return err
+// NewMailer returns the mailer chosen by cfg.MailDriver
+func NewMailer(cfg config.Config) (Mailer, error) {
+	switch cfg.MailDriver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg), nil
+	case DriverFile, "":
+		return NewFileMailer(cfg.MailFrom, cfg.MailOutboxDir)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.MailDriver)
 	}
+}
 
-	message := "From: " + params.From + "\n" + "To: " + params.To + "\n" +
-		"Message: " + params.Message + "\n" + "MIME-Version: 1.0\n" +
-		"Content-type: text/html; charset=\"UTF-8\"\n" + "\n" + Builder.String()
-
-	err = smtp.SendMail("smtp.gmail.com:587",
-		smtp.PlainAuth("smtp", params.From, params.Password, "smtp.gmail.com"),
-		params.From, []string{params.To}, []byte(message),
-	)
+// buildMessage encodes the message as multipart/alternative,
+// the plain part goes first so clients prefer the HTML one
+func buildMessage(from string, message Message) []byte {
+	var body strings.Builder
+	parts := multipart.NewWriter(&body)
 
-	if err != nil {
-		log.Println("Could not send an email", err.Error())
-		return err
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain", message.Text},
+		{"text/html", message.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {part.contentType + `; charset="UTF-8"`},
+		})
+		if err != nil {
+			continue
+		}
+		w.Write([]byte(part.content))
 	}
+	parts.Close()
 
-	return nil
+	return []byte("From: " + from + "\r\n" +
+		"To: " + message.To + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("UTF-8", message.Subject) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"" + parts.Boundary() + "\"\r\n" +
+		"\r\n" + body.String())
 }
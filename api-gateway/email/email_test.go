@@ -0,0 +1,125 @@
+package email
+
+import (
+	"exam/api-gateway/config"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewMailer(t *testing.T) {
+	tests := []struct {
+		driver  string
+		want    interface{}
+		wantErr bool
+	}{
+		{driver: DriverSMTP, want: &smtpMailer{}},
+		{driver: DriverFile, want: &fileMailer{}},
+		{driver: "", want: &fileMailer{}},
+		{driver: "sendgrid", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			mailer, err := NewMailer(config.Config{
+				MailDriver:    tt.driver,
+				MailFrom:      "no-reply@example.com",
+				MailOutboxDir: t.TempDir(),
+				SMTPHost:      "smtp.example.com",
+				SMTPPort:      587,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			switch tt.want.(type) {
+			case *smtpMailer:
+				if m, ok := mailer.(*smtpMailer); !ok || m.addr != "smtp.example.com:587" || m.from != "no-reply@example.com" {
+					t.Fatalf("mailer = %#v", mailer)
+				}
+			case *fileMailer:
+				if _, ok := mailer.(*fileMailer); !ok {
+					t.Fatalf("mailer = %#v", mailer)
+				}
+			}
+		})
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer, err := NewFileMailer("no-reply@example.com", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := Message{
+		To:      "../user@example.com",
+		Subject: "Код подтверждения",
+		Text:    "code: 123456\n",
+		HTML:    "<p>code: <b>123456</b></p>",
+	}
+	if err := mailer.Send(sent); err != nil {
+		t.Fatal(err)
+	}
+
+	// the recipient cannot move the file out of the directory
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || !strings.HasSuffix(files[0].Name(), "-.._user_at_example.com.eml") {
+		t.Fatalf("files = %v", files)
+	}
+
+	file, err := os.Open(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	message, err := mail.ReadMessage(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Header.Get("From") != "no-reply@example.com" || message.Header.Get("To") != sent.To || subject != sent.Subject {
+		t.Fatalf("header = %v", message.Header)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q, %v", mediaType, err)
+	}
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	for _, want := range []struct{ contentType, content string }{
+		{"text/plain", sent.Text},
+		{"text/html", sent.HTML},
+	} {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(part.Header.Get("Content-Type"), want.contentType) || string(content) != want.content {
+			t.Fatalf("part %s = %q", part.Header.Get("Content-Type"), content)
+		}
+	}
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Fatalf("more parts than text and html: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package email
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type fileMailer struct {
+	from string
+	dir  string
+}
+
+// NewFileMailer writes every message as an .eml file to dir instead of sending it
+func NewFileMailer(from, dir string) (*fileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &fileMailer{from: from, dir: dir}, nil
+}
+
+func (m *fileMailer) Send(message Message) error {
+	to := strings.NewReplacer("/", "_", "\\", "_", "@", "_at_").Replace(message.To)
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), to)
+
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, message), 0o644)
+}
@@ -0,0 +1,174 @@
+package email
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Names of the notification templates, every locale directory under
+// templates has a <name>.txt with a "subject" block and a <name>.html
+const (
+	TemplateVerification    = "verification"
+	TemplateWelcome         = "welcome"
+	TemplatePurchaseReceipt = "purchase_receipt"
+	TemplatePasswordChanged = "password_changed"
+	TemplateAdminCreated    = "admin_created"
+)
+
+// DefaultLocale is used when the locale of a user has no templates
+const DefaultLocale = "en"
+
+// Purposes of a verification code, the template words the email after them
+const (
+	PurposeRegister      = "register"
+	PurposePasswordReset = "password_reset"
+	PurposeEmailChange   = "email_change"
+)
+
+//go:embed templates
+var templates embed.FS
+
+type notification struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// notifications are keyed by locale and then by template name
+var notifications = mustLoadNotifications()
+
+var funcs = map[string]interface{}{
+	"money": func(amount float32) string { return fmt.Sprintf("%.2f", amount) },
+	"date":  func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+}
+
+func mustLoadNotifications() map[string]map[string]*notification {
+	loaded := map[string]map[string]*notification{}
+
+	files, err := fs.Glob(templates, "templates/*/*.txt")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, file := range files {
+		locale := path.Base(path.Dir(file))
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+
+		text := texttemplate.Must(texttemplate.New(path.Base(file)).Funcs(funcs).ParseFS(templates, file))
+		if text.Lookup("subject") == nil {
+			panic(fmt.Sprintf("email template %s has no subject", file))
+		}
+		html := htmltemplate.Must(htmltemplate.New(name+".html").Funcs(funcs).ParseFS(templates, strings.TrimSuffix(file, ".txt")+".html"))
+
+		if loaded[locale] == nil {
+			loaded[locale] = map[string]*notification{}
+		}
+		loaded[locale][name] = &notification{text: text, html: html}
+	}
+
+	for name := range loaded[DefaultLocale] {
+		for locale := range loaded {
+			if loaded[locale][name] == nil {
+				panic(fmt.Sprintf("email template %s has no %s variant", name, locale))
+			}
+		}
+	}
+
+	return loaded
+}
+
+// Locale returns the supported locale closest to the one of a user,
+// "ru-RU" falls back to "ru" and unknown ones to DefaultLocale
+func Locale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if _, ok := notifications[locale]; ok {
+		return locale
+	}
+
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		if _, ok := notifications[locale[:i]]; ok {
+			return locale[:i]
+		}
+	}
+
+	return DefaultLocale
+}
+
+// Render builds the message of the named template in the locale of the recipient
+func Render(name, locale, to string, data interface{}) (Message, error) {
+	n, ok := notifications[Locale(locale)][name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html strings.Builder
+	if err := n.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := n.text.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := n.html.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// Notify renders the named template and hands it to the mailer
+func Notify(mailer Mailer, name, locale, to string, data interface{}) error {
+	message, err := Render(name, locale, to, data)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(message)
+}
+
+// Params of the verification code email (registration, password reset, email change)
+type Params struct {
+	To       string
+	Locale   string
+	Purpose  string
+	Code     string
+	Link     string //optional magic link doing the same as the code
+	UserName string
+}
+
+// Sending OTP to user via an email
+func SendVerificationCode(mailer Mailer, params Params) error {
+	return Notify(mailer, TemplateVerification, params.Locale, params.To, params)
+}
+
+type WelcomeData struct {
+	UserName string
+}
+
+type PurchaseReceiptData struct {
+	UserName    string
+	ProductName string
+	Quantity    int32
+	Price       float32
+	Total       float32
+	PurchasedAt time.Time
+}
+
+type PasswordChangedData struct {
+	UserName  string
+	ChangedAt time.Time
+}
+
+type AdminCreatedData struct {
+	UserName string
+	Email    string
+}
@@ -0,0 +1,129 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "ru", want: "ru"},
+		{locale: " UZ ", want: "uz"},
+		{locale: "ru-RU", want: "ru"},
+		{locale: "uz_Latn", want: "uz"},
+		{locale: "de", want: DefaultLocale},
+		{locale: "de-RU", want: DefaultLocale},
+		{locale: "", want: DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		if got := Locale(tt.locale); got != tt.want {
+			t.Errorf("Locale(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	purchasedAt := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	data := map[string]interface{}{
+		TemplateVerification:    Params{UserName: "Aziz", Code: "123456", Purpose: PurposeRegister},
+		TemplateWelcome:         WelcomeData{UserName: "Aziz"},
+		TemplatePasswordChanged: PasswordChangedData{UserName: "Aziz", ChangedAt: purchasedAt},
+		TemplateAdminCreated:    AdminCreatedData{UserName: "Aziz", Email: "aziz@example.com"},
+		TemplatePurchaseReceipt: PurchaseReceiptData{
+			UserName:    "Aziz",
+			ProductName: "Notebook",
+			Quantity:    2,
+			Price:       12.5,
+			Total:       25,
+			PurchasedAt: purchasedAt,
+		},
+	}
+
+	for locale, named := range notifications {
+		if len(named) != len(data) {
+			t.Errorf("locale %s has %d templates, want %d", locale, len(named), len(data))
+		}
+		for name := range named {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				message, err := Render(name, locale, "aziz@example.com", data[name])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if message.To != "aziz@example.com" || message.Subject == "" || strings.Contains(message.Subject, "\n") {
+					t.Fatalf("message to %q with subject %q", message.To, message.Subject)
+				}
+				for part, content := range map[string]string{"text": message.Text, "html": message.HTML} {
+					if !strings.Contains(content, "Aziz") || strings.Contains(content, "<no value>") {
+						t.Fatalf("%s part = %q", part, content)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRenderPurchaseReceipt(t *testing.T) {
+	message, err := Render(TemplatePurchaseReceipt, "en", "aziz@example.com", PurchaseReceiptData{
+		UserName:    "Aziz",
+		ProductName: "Notebook",
+		Quantity:    2,
+		Price:       12.5,
+		Total:       25,
+		PurchasedAt: time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"Notebook", "2", "12.50", "25.00", "2026-01-02 15:04 UTC"} {
+		if !strings.Contains(message.Text, want) || !strings.Contains(message.HTML, want) {
+			t.Errorf("receipt has no %q:\n%s\n%s", want, message.Text, message.HTML)
+		}
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	message, err := Render(TemplateWelcome, "en", "aziz@example.com", WelcomeData{UserName: "<script>alert(1)</script>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(message.HTML, "<script>") || !strings.Contains(message.HTML, "&lt;script&gt;") {
+		t.Fatalf("html part = %q", message.HTML)
+	}
+	if !strings.Contains(message.Text, "<script>") {
+		t.Fatalf("text part = %q", message.Text)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := Render("newsletter", "en", "aziz@example.com", nil); err == nil {
+		t.Fatal("an unknown template was rendered")
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var sent []Message
+	mailer := mailerFunc(func(message Message) error {
+		sent = append(sent, message)
+		return nil
+	})
+
+	if err := Notify(mailer, TemplatePasswordChanged, "ru-RU", "aziz@example.com", PasswordChangedData{UserName: "Aziz"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent) != 1 || sent[0].Subject != "Пароль изменён" {
+		t.Fatalf("sent %+v", sent)
+	}
+}
+
+type mailerFunc func(message Message) error
+
+func (f mailerFunc) Send(message Message) error { return f(message) }
@@ -0,0 +1,140 @@
+package email
+
+import (
+	"context"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/logger"
+	"exam/api-gateway/storage/postgresrepo"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// emails claimed by one poll, and how long they are hidden
+	// from other senders while being delivered
+	outboxBatch = 20
+	outboxLease = time.Minute
+
+	// a failed email is retried after retryBase, then twice as late every time
+	retryBase = time.Second * 30
+	retryMax  = time.Hour * 6
+)
+
+type outboxMailer struct {
+	outbox postgresrepo.OutboxStorageI
+}
+
+// NewOutboxMailer returns a mailer that only queues messages in the outbox,
+// they are delivered later by the Sender, so handlers never wait for SMTP
+func NewOutboxMailer(outbox postgresrepo.OutboxStorageI) Mailer {
+	return &outboxMailer{outbox: outbox}
+}
+
+func (m *outboxMailer) Send(message Message) error {
+	return m.outbox.Enqueue(&models.OutboxEmail{
+		Id:        uuid.NewString(),
+		Recipient: message.To,
+		Subject:   message.Subject,
+		Text:      message.Text,
+		HTML:      message.HTML,
+	})
+}
+
+// Sender delivers the queued emails with a real mailer, retrying
+// failures with backoff until maxAttempts and dead-lettering them after
+type Sender struct {
+	outbox      postgresrepo.OutboxStorageI
+	mailer      Mailer
+	log         logger.Logger
+	maxAttempts int
+	interval    time.Duration
+}
+
+func NewSender(outbox postgresrepo.OutboxStorageI, mailer Mailer, log logger.Logger, maxAttempts int, interval time.Duration) *Sender {
+	return &Sender{
+		outbox:      outbox,
+		mailer:      mailer,
+		log:         log,
+		maxAttempts: maxAttempts,
+		interval:    interval,
+	}
+}
+
+// Run polls the outbox until ctx is done
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		// a full batch means more emails may be waiting, do not sleep
+		for s.sendDue() == outboxBatch {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sender) sendDue() int {
+	emails, err := s.outbox.ClaimDue(outboxBatch, outboxLease)
+	if err != nil {
+		s.log.Error("cannot claim emails from the outbox", logger.Error(err))
+		return 0
+	}
+
+	for _, e := range emails {
+		s.send(e)
+	}
+
+	return len(emails)
+}
+
+func (s *Sender) send(e *models.OutboxEmail) {
+	err := s.mailer.Send(Message{
+		To:      e.Recipient,
+		Subject: e.Subject,
+		Text:    e.Text,
+		HTML:    e.HTML,
+	})
+	if err == nil {
+		if err := s.outbox.MarkSent(e.Id); err != nil {
+			s.log.Error("cannot mark email as sent", logger.String("id", e.Id), logger.Error(err))
+		}
+		return
+	}
+
+	attempts := e.Attempts + 1
+	dead := attempts >= s.maxAttempts
+	if err := s.outbox.MarkFailed(e.Id, err.Error(), time.Now().Add(retryDelay(attempts)), dead); err != nil {
+		s.log.Error("cannot mark email as failed", logger.String("id", e.Id), logger.Error(err))
+	}
+
+	if dead {
+		s.log.Error("email is dead-lettered",
+			logger.String("id", e.Id),
+			logger.Int("attempts", attempts),
+			logger.Error(err))
+		return
+	}
+
+	s.log.Warn("cannot send email, will retry",
+		logger.String("id", e.Id),
+		logger.Int("attempts", attempts),
+		logger.Error(err))
+}
+
+func retryDelay(attempts int) time.Duration {
+	if attempts > 16 {
+		return retryMax
+	}
+
+	delay := retryBase << (attempts - 1)
+	if delay > retryMax {
+		return retryMax
+	}
+	return delay
+}
@@ -0,0 +1,195 @@
+package email
+
+import (
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/pkg/logger"
+	"sync"
+	"testing"
+	"time"
+)
+
+// outboxStorage keeps the outbox in memory like the outbox table,
+// claimed emails are hidden until their lease ends
+type outboxStorage struct {
+	mu     sync.Mutex
+	emails []*models.OutboxEmail
+}
+
+func (o *outboxStorage) Enqueue(email *models.OutboxEmail) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queued := *email
+	queued.Status = models.OutboxStatusPending
+	queued.NextAttemptAt = time.Now()
+	o.emails = append(o.emails, &queued)
+	return nil
+}
+
+func (o *outboxStorage) ClaimDue(limit int, lease time.Duration) ([]*models.OutboxEmail, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var claimed []*models.OutboxEmail
+	for _, e := range o.emails {
+		if len(claimed) == limit {
+			break
+		}
+		if e.Status != models.OutboxStatusPending || e.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		e.NextAttemptAt = time.Now().Add(lease)
+		copied := *e
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (o *outboxStorage) MarkSent(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	sentAt := time.Now()
+	e := o.find(id)
+	e.Status, e.SentAt = models.OutboxStatusSent, &sentAt
+	return nil
+}
+
+func (o *outboxStorage) MarkFailed(id, lastError string, nextAttemptAt time.Time, dead bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	e := o.find(id)
+	e.Attempts++
+	e.LastError, e.NextAttemptAt = lastError, nextAttemptAt
+	if dead {
+		e.Status = models.OutboxStatusDead
+	}
+	return nil
+}
+
+func (o *outboxStorage) find(id string) *models.OutboxEmail {
+	for _, e := range o.emails {
+		if e.Id == id {
+			return e
+		}
+	}
+	return &models.OutboxEmail{}
+}
+
+// due makes every pending email due as if its retry delay has passed
+func (o *outboxStorage) due() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, e := range o.emails {
+		e.NextAttemptAt = time.Now().Add(-time.Second)
+	}
+}
+
+// flakyMailer fails the first failures messages and keeps the rest
+type flakyMailer struct {
+	failures int
+	sent     []Message
+}
+
+func (m *flakyMailer) Send(message Message) error {
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("smtp server is not available")
+	}
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+func TestOutboxMailer(t *testing.T) {
+	outbox := &outboxStorage{}
+	message := Message{To: "user@example.com", Subject: "Welcome", Text: "hi", HTML: "<p>hi</p>"}
+
+	if err := NewOutboxMailer(outbox).Send(message); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outbox.emails) != 1 {
+		t.Fatalf("queued %d emails", len(outbox.emails))
+	}
+	queued := outbox.emails[0]
+	if queued.Id == "" || queued.Recipient != message.To || queued.Subject != message.Subject ||
+		queued.Text != message.Text || queued.HTML != message.HTML || queued.Status != models.OutboxStatusPending {
+		t.Fatalf("queued %+v", queued)
+	}
+}
+
+func TestSender(t *testing.T) {
+	const maxAttempts = 3
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantStatus   string
+		wantAttempts int
+		wantSent     int
+	}{
+		{name: "sent at once", failures: 0, wantStatus: models.OutboxStatusSent, wantAttempts: 0, wantSent: 1},
+		{name: "sent after retries", failures: maxAttempts - 1, wantStatus: models.OutboxStatusSent, wantAttempts: maxAttempts - 1, wantSent: 1},
+		{name: "dead-lettered", failures: maxAttempts, wantStatus: models.OutboxStatusDead, wantAttempts: maxAttempts, wantSent: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &outboxStorage{}
+			mailer := &flakyMailer{failures: tt.failures}
+			sender := NewSender(outbox, mailer, logger.New("error", "test"), maxAttempts, time.Second)
+
+			if err := NewOutboxMailer(outbox).Send(Message{To: "user@example.com", Subject: "Welcome"}); err != nil {
+				t.Fatal(err)
+			}
+
+			for poll := 0; poll <= maxAttempts; poll++ {
+				sender.sendDue()
+
+				// a failed email waits for its retry, the next poll does not take it
+				if e := outbox.emails[0]; e.Status == models.OutboxStatusPending {
+					if sender.sendDue() != 0 {
+						t.Fatalf("poll %d retried at once", poll)
+					}
+					if wait := time.Until(e.NextAttemptAt); wait < retryDelay(e.Attempts)-time.Second {
+						t.Fatalf("attempt %d is retried in %s", e.Attempts, wait)
+					}
+				}
+				outbox.due()
+			}
+
+			e := outbox.emails[0]
+			if e.Status != tt.wantStatus || e.Attempts != tt.wantAttempts || len(mailer.sent) != tt.wantSent {
+				t.Fatalf("status %q after %d attempts with %d sent, want %q after %d with %d",
+					e.Status, e.Attempts, len(mailer.sent), tt.wantStatus, tt.wantAttempts, tt.wantSent)
+			}
+			if tt.failures > 0 && e.LastError == "" {
+				t.Fatal("the error of the failed attempt is not kept")
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: retryBase},
+		{attempts: 2, want: retryBase * 2},
+		{attempts: 5, want: retryBase * 16},
+		{attempts: 10, want: retryBase * 512},
+		{attempts: 11, want: retryMax},
+		{attempts: 17, want: retryMax},
+		{attempts: 64, want: retryMax},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package email
+
+import (
+	"exam/api-gateway/config"
+	"fmt"
+	"net/smtp"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer sends mail through the SMTP server of the config,
+// servers without authentication are used when no username is set
+func NewSMTPMailer(cfg config.Config) *smtpMailer {
+	mailer := &smtpMailer{
+		addr: fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort),
+		from: cfg.MailFrom,
+	}
+	if cfg.SMTPUsername != "" {
+		mailer.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+
+	return mailer
+}
+
+func (m *smtpMailer) Send(message Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{message.To}, buildMessage(m.from, message))
+}
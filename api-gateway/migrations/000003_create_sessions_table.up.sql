@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY NOT NULL,
+    user_id VARCHAR(200) NOT NULL,
+    role VARCHAR(100) NOT NULL,
+    device_id VARCHAR(200) NOT NULL,
+    user_agent TEXT,
+    refresh_token_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+    );
+
+CREATE UNIQUE INDEX idx_unique_session_device ON sessions(user_id, device_id) WHERE revoked_at IS NULL;
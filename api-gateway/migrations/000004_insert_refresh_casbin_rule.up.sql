@@ -0,0 +1,4 @@
+DELETE FROM casbin_rule WHERE ptype = 'p' AND v0 = 'user' AND v1 = '/v1/user/refresh' AND v2 = 'POST';
+
+INSERT INTO casbin_rule (ptype, v0, v1, v2) VALUES
+                                                ('p', 'unauthorized', '/v1/user/refresh', 'POST');
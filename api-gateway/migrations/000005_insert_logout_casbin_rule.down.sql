@@ -0,0 +1,4 @@
+DELETE FROM casbin_rule WHERE ptype = 'p' AND (v0, v1, v2) IN (
+                                                ('user', '/v1/user/logout', 'POST'),
+                                                ('admin', '/v1/auth/logout', 'POST'),
+                                                ('admin', '/v1/user/sessions/{id}', 'DELETE'));
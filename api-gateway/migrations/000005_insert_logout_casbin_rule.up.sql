@@ -0,0 +1,4 @@
+INSERT INTO casbin_rule (ptype, v0, v1, v2) VALUES
+                                                ('p', 'user', '/v1/user/logout', 'POST'),
+                                                ('p', 'admin', '/v1/auth/logout', 'POST'),
+                                                ('p', 'admin', '/v1/user/sessions/{id}', 'DELETE');
@@ -0,0 +1,3 @@
+DELETE FROM casbin_rule WHERE ptype = 'p' AND (v0, v1, v2) IN (
+                                                ('unauthorized', '/v1/user/password/forgot', 'POST'),
+                                                ('unauthorized', '/v1/user/password/reset', 'POST'));
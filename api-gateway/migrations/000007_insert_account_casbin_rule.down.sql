@@ -0,0 +1,4 @@
+DELETE FROM casbin_rule WHERE ptype = 'p' AND (v0, v1, v2) IN (
+                                                ('user', '/v1/user/password/change', 'POST'),
+                                                ('user', '/v1/user/email/change', 'POST'),
+                                                ('user', '/v1/user/email/verify', 'POST'));
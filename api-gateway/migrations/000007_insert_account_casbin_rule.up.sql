@@ -0,0 +1,4 @@
+INSERT INTO casbin_rule (ptype, v0, v1, v2) VALUES
+                                                ('p', 'user', '/v1/user/password/change', 'POST'),
+                                                ('p', 'user', '/v1/user/email/change', 'POST'),
+                                                ('p', 'user', '/v1/user/email/verify', 'POST');
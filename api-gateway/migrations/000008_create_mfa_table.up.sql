@@ -0,0 +1,9 @@
+CREATE TABLE mfa (
+    subject TEXT PRIMARY KEY NOT NULL,
+    secret TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    recovery_codes TEXT[] NOT NULL DEFAULT '{}',
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    enabled_at TIMESTAMP
+    );
@@ -0,0 +1,5 @@
+DELETE FROM casbin_rule WHERE ptype = 'p' AND (v0, v1, v2) IN (
+                                                ('unauthorized', '/v1/mfa/setup', 'POST'),
+                                                ('unauthorized', '/v1/mfa/activate', 'POST'),
+                                                ('unauthorized', '/v1/mfa/verify', 'POST'),
+                                                ('user', '/v1/mfa/disable', 'POST'));
@@ -0,0 +1,5 @@
+INSERT INTO casbin_rule (ptype, v0, v1, v2) VALUES
+                                                ('p', 'unauthorized', '/v1/mfa/setup', 'POST'),
+                                                ('p', 'unauthorized', '/v1/mfa/activate', 'POST'),
+                                                ('p', 'unauthorized', '/v1/mfa/verify', 'POST'),
+                                                ('p', 'user', '/v1/mfa/disable', 'POST');
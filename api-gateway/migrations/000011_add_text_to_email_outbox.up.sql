@@ -0,0 +1 @@
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS text TEXT NOT NULL DEFAULT '';
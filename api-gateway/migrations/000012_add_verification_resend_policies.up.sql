@@ -0,0 +1,3 @@
+INSERT INTO casbin_rule (ptype, v0, v1, v2) VALUES
+                                                ('p', 'unauthorized', '/v1/user/verify/resend', 'POST'),
+                                                ('p', 'unauthorized', '/v1/user/verify/link', 'GET');
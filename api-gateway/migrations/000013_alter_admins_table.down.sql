@@ -0,0 +1,9 @@
+DELETE FROM casbin_rule WHERE ptype = 'p' AND (v0, v1, v2) IN (
+                                                ('unauthorized', '/v1/auth/password/change', 'POST'));
+
+ALTER TABLE admins
+    DROP COLUMN IF EXISTS disabled,
+    DROP COLUMN IF EXISTS must_change_password,
+    DROP COLUMN IF EXISTS last_login_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at;
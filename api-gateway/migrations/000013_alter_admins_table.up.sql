@@ -0,0 +1,9 @@
+ALTER TABLE admins
+    ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+
+INSERT INTO casbin_rule (ptype, v0, v1, v2) VALUES
+                                                ('p', 'unauthorized', '/v1/auth/password/change', 'POST');
@@ -0,0 +1,13 @@
+CREATE TABLE roles (
+    name VARCHAR(50) PRIMARY KEY NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP
+    );
+
+INSERT INTO roles (name, description, built_in) VALUES
+                                                ('unauthorized', 'requests without an access token', TRUE),
+                                                ('user', 'registered users', TRUE),
+                                                ('admin', 'admins managing users and products', TRUE),
+                                                ('superadmin', 'admins managing admins and roles', TRUE);
@@ -0,0 +1,5 @@
+UPDATE casbin_rule SET v2 = '*'
+WHERE ptype = 'g' AND (v0, v1) IN (('user', 'unauthorized'), ('admin', 'user'), ('superadmin', 'admin'));
+
+UPDATE casbin_rule SET v1 = '/v1/users/:page/:limit'
+WHERE ptype = 'p' AND v0 = 'admin' AND v1 = '/v1/users/{page}/{limit}' AND v2 = 'GET';
@@ -0,0 +1,6 @@
+-- keyMatch3 only understands {param}, :page was matched literally
+UPDATE casbin_rule SET v1 = '/v1/users/{page}/{limit}'
+WHERE ptype = 'p' AND v0 = 'admin' AND v1 = '/v1/users/:page/:limit' AND v2 = 'GET';
+
+-- the model has no domains, grouping rules take only a role and a parent
+UPDATE casbin_rule SET v2 = '' WHERE ptype = 'g' AND v2 = '*';
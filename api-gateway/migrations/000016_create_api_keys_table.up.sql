@@ -0,0 +1,17 @@
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    previous_key_hash VARCHAR(64),
+    previous_key_expires_at TIMESTAMP,
+    scopes TEXT[] NOT NULL,
+    created_by VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP,
+    revoked_at TIMESTAMP
+    );
+
+CREATE INDEX api_keys_previous_key_hash_idx ON api_keys (previous_key_hash) WHERE previous_key_hash IS NOT NULL;
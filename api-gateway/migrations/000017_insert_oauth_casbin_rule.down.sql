@@ -0,0 +1,4 @@
+DELETE FROM casbin_rule WHERE ptype = 'p' AND (v0, v1, v2) IN (
+                                                ('unauthorized', '/v1/oauth/providers', 'GET'),
+                                                ('unauthorized', '/v1/oauth/{provider}/login', 'GET'),
+                                                ('unauthorized', '/v1/oauth/{provider}/callback', 'GET'));
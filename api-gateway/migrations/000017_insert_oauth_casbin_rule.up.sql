@@ -0,0 +1,4 @@
+INSERT INTO casbin_rule (ptype, v0, v1, v2) VALUES
+                                                ('p', 'unauthorized', '/v1/oauth/providers', 'GET'),
+                                                ('p', 'unauthorized', '/v1/oauth/{provider}/login', 'GET'),
+                                                ('p', 'unauthorized', '/v1/oauth/{provider}/callback', 'GET');
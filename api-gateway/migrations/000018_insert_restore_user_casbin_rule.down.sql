@@ -0,0 +1,2 @@
+DELETE FROM casbin_rule WHERE ptype = 'p' AND (v0, v1, v2) IN (
+                                                ('admin', '/v1/user/restore/{id}', 'POST'));
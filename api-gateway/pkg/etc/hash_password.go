@@ -1,6 +1,9 @@
 package etc
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -13,3 +16,10 @@ func CompareHashPassword(hashed string, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password))
 	return err == nil
 }
+
+// HashToken hashes long random values like refresh tokens,
+// bcrypt is not used because it ignores everything after 72 bytes
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,268 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Provider signs users in with an external identity provider, NewProvider
+// speaks OpenID Connect, providers that do not can plug in by implementing it
+type Provider interface {
+	Name() string
+	// AuthCodeURL is where the browser is sent to sign in,
+	// the code challenge is the S256 one of the PKCE verifier
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems the authorization code and returns the verified identity
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+// Identity is the user as the identity provider knows them
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Name          string
+}
+
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// DefaultScopes are asked for when a provider is configured without scopes
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// keysRefreshInterval limits how often the keys are fetched again for a token
+// signed with an unknown key, providers publish new keys before using them
+const keysRefreshInterval = time.Minute
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *discovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewProvider returns an OpenID Connect provider, its endpoints are discovered
+// from the issuer on first use so the gateway starts while the provider is down
+func NewProvider(cfg Config) Provider {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = DefaultScopes
+	}
+
+	return &provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (p *provider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientId},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+type tokenResponse struct {
+	IdToken          string `json:"id_token"`
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (p *provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientId},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientId), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token tokenResponse
+	status, err := p.doJSON(req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("code exchange failed with %d: %s %s", status, token.Error, token.ErrorDescription)
+	}
+	if token.IdToken == "" {
+		return nil, errors.New("the provider returned no id token")
+	}
+
+	return p.verify(ctx, token.IdToken, nonce)
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string      `json:"nonce"`
+	AuthorizedBy  string      `json:"azp"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	GivenName     string      `json:"given_name"`
+	FamilyName    string      `json:"family_name"`
+	Name          string      `json:"name"`
+}
+
+// verify checks the signature, issuer, audience, expiry and nonce of the id token
+func (p *provider) verify(ctx context.Context, raw, nonce string) (*Identity, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != p.cfg.ClientId {
+		return nil, errors.New("id token was issued to another client")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	// some providers send the flag as a string
+	verified := false
+	switch value := claims.EmailVerified.(type) {
+	case bool:
+		verified = value
+	case string:
+		verified = value == "true"
+	}
+
+	return &Identity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: verified,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var d discovery
+	status, err := p.doJSON(req, &d)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery of %s failed with %d", p.cfg.Issuer, status)
+	}
+	// tokens of another issuer would be accepted otherwise
+	if strings.TrimSuffix(d.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery document is of issuer %s, not %s", d.Issuer, p.cfg.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %s is incomplete", p.cfg.Issuer)
+	}
+	p.discovery = &d
+
+	return p.discovery, nil
+}
+
+func (p *provider) doJSON(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, err
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,252 @@
+package oidc
+
+import (
+	"context"
+	"exam/api-gateway/pkg/oidc/oidctest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientId    = "exam"
+	testRedirectURL = "http://gateway.test/v1/oauth/local/callback"
+)
+
+func newMockProvider(t *testing.T) (*oidctest.Provider, *provider) {
+	t.Helper()
+
+	mock, err := oidctest.New("", testClientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(mock.Handler())
+	t.Cleanup(server.Close)
+	mock.Issuer = server.URL
+
+	p := NewProvider(Config{
+		Name:        "local",
+		Issuer:      server.URL,
+		ClientId:    testClientId,
+		RedirectURL: testRedirectURL,
+	}).(*provider)
+
+	return mock, p
+}
+
+// authorize follows the authorization url and returns the code the
+// provider redirects back with
+func authorize(t *testing.T, p *provider, state, nonce, verifier string) string {
+	t.Helper()
+
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, CodeChallenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization answered %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Query().Get("state") != state {
+		t.Fatalf("state = %q, want %q", location.Query().Get("state"), state)
+	}
+
+	return location.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	mock, p := newMockProvider(t)
+
+	tests := []struct {
+		name     string
+		verifier string
+		nonce    string
+		reuse    bool
+		wantErr  bool
+	}{
+		{name: "valid", verifier: "verifier", nonce: "nonce"},
+		{name: "other verifier", verifier: "other-verifier", nonce: "nonce", wantErr: true},
+		{name: "other nonce", verifier: "verifier", nonce: "other-nonce", wantErr: true},
+		{name: "code redeemed twice", verifier: "verifier", nonce: "nonce", reuse: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := authorize(t, p, "state", "nonce", "verifier")
+			if tt.reuse {
+				if _, err := p.Exchange(context.Background(), code, "verifier", "nonce"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			identity, err := p.Exchange(context.Background(), code, tt.verifier, tt.nonce)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			want := Identity{
+				Provider:      "local",
+				Subject:       identity.Subject,
+				Email:         mock.Email,
+				EmailVerified: true,
+				GivenName:     mock.GivenName,
+				FamilyName:    mock.FamilyName,
+				Name:          mock.GivenName + " " + mock.FamilyName,
+			}
+			if identity.Subject == "" || *identity != want {
+				t.Fatalf("identity = %+v, want %+v", *identity, want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	mock, p := newMockProvider(t)
+	other, err := oidctest.New(mock.Issuer, testClientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(change func(jwt.MapClaims)) jwt.MapClaims {
+		now := time.Now()
+		c := jwt.MapClaims{
+			"iss":            mock.Issuer,
+			"sub":            "user-1",
+			"aud":            testClientId,
+			"iat":            now.Unix(),
+			"exp":            now.Add(time.Minute * 5).Unix(),
+			"nonce":          "nonce",
+			"email":          " User@Example.com ",
+			"email_verified": true,
+		}
+		if change != nil {
+			change(c)
+		}
+		return c
+	}
+	sign := func(signer *oidctest.Provider, c jwt.MapClaims) string {
+		token, err := signer.SignIDToken(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		verified bool
+		wantErr  bool
+	}{
+		{name: "valid", token: sign(mock, claims(nil)), verified: true},
+		{
+			name:  "email not verified",
+			token: sign(mock, claims(func(c jwt.MapClaims) { c["email_verified"] = false })),
+		},
+		{
+			name:     "email verified as string",
+			token:    sign(mock, claims(func(c jwt.MapClaims) { c["email_verified"] = "true" })),
+			verified: true,
+		},
+		{
+			name:     "expired within leeway",
+			token:    sign(mock, claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Second * 30).Unix() })),
+			verified: true,
+		},
+		{
+			name:    "expired",
+			token:   sign(mock, claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })),
+			wantErr: true,
+		},
+		{
+			name:    "without expiry",
+			token:   sign(mock, claims(func(c jwt.MapClaims) { delete(c, "exp") })),
+			wantErr: true,
+		},
+		{
+			name:    "other issuer",
+			token:   sign(mock, claims(func(c jwt.MapClaims) { c["iss"] = "https://accounts.example.com" })),
+			wantErr: true,
+		},
+		{
+			name:    "other audience",
+			token:   sign(mock, claims(func(c jwt.MapClaims) { c["aud"] = "other-client" })),
+			wantErr: true,
+		},
+		{
+			name:    "several audiences without azp",
+			token:   sign(mock, claims(func(c jwt.MapClaims) { c["aud"] = []string{testClientId, "other-client"} })),
+			wantErr: true,
+		},
+		{
+			name: "several audiences authorized by us",
+			token: sign(mock, claims(func(c jwt.MapClaims) {
+				c["aud"] = []string{testClientId, "other-client"}
+				c["azp"] = testClientId
+			})),
+			verified: true,
+		},
+		{
+			name:    "other nonce",
+			token:   sign(mock, claims(func(c jwt.MapClaims) { c["nonce"] = "other-nonce" })),
+			wantErr: true,
+		},
+		{
+			name:    "without subject",
+			token:   sign(mock, claims(func(c jwt.MapClaims) { delete(c, "sub") })),
+			wantErr: true,
+		},
+		{name: "signed with another key", token: sign(other, claims(nil)), wantErr: true},
+		{name: "alg none", token: unsigned, wantErr: true},
+		{name: "garbage", token: "not-a-token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := p.verify(context.Background(), tt.token, "nonce")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if identity.Subject != "user-1" || identity.Email != "user@example.com" || identity.EmailVerified != tt.verified {
+				t.Fatalf("identity = %+v", *identity)
+			}
+		})
+	}
+}
+
+func TestDiscoveryOfAnotherIssuer(t *testing.T) {
+	mock, p := newMockProvider(t)
+	mock.Issuer = "https://accounts.example.com"
+
+	if _, err := p.AuthCodeURL(context.Background(), "state", "nonce", CodeChallenge("verifier")); err == nil {
+		t.Fatal("the discovery document of another issuer was accepted")
+	}
+}
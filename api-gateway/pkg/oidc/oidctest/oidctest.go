@@ -0,0 +1,245 @@
+// Package oidctest is an OpenID Connect provider that signs everybody in
+// without asking, as the configured user or the login_hint of the
+// authorization request. cmd/mockoidc serves it for trying the identity
+// provider login offline, the tests of the oidc package run against it.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"exam/api-gateway/api/handlers/v1/tokens"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyId is the kid of the key the id tokens are signed with
+const KeyId = "mockoidc"
+
+// codeLifetime is how long an authorization code can be redeemed
+const codeLifetime = time.Minute
+
+type authorization struct {
+	clientId      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	email         string
+	expiresAt     time.Time
+}
+
+// Provider is the identity provider, the user fields can be changed
+// between sign ins
+type Provider struct {
+	Issuer        string
+	ClientId      string
+	Email         string
+	GivenName     string
+	FamilyName    string
+	EmailVerified bool
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*authorization
+}
+
+// New returns a provider signing with a new RSA key
+func New(issuer, clientId string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		Issuer:        strings.TrimSuffix(issuer, "/"),
+		ClientId:      clientId,
+		Email:         "user@example.com",
+		GivenName:     "Test",
+		FamilyName:    "User",
+		EmailVerified: true,
+		key:           key,
+		codes:         make(map[string]*authorization),
+	}, nil
+}
+
+// Handler serves discovery, the authorization and token endpoints and the keys
+func (p *Provider) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/jwks", p.jwks)
+
+	return mux
+}
+
+// SignIDToken signs the claims with the key of the provider
+func (p *Provider) SignIDToken(claims jwt.MapClaims) (string, error) {
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = KeyId
+
+	return idToken.SignedString(p.key)
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{tokens.AlgorithmRS256},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize signs the user in at once and redirects back with a code
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("client_id") != p.ClientId || query.Get("response_type") != "code" {
+		http.Error(w, "unknown client or unsupported response type", http.StatusBadRequest)
+		return
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "pkce with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	email := query.Get("login_hint")
+	if email == "" {
+		email = p.Email
+	}
+
+	code := randomToken()
+	p.mu.Lock()
+	p.codes[code] = &authorization{
+		clientId:      p.ClientId,
+		redirectURI:   redirectURI.String(),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		email:         email,
+		expiresAt:     time.Now().Add(codeLifetime),
+	}
+	p.mu.Unlock()
+
+	callback := redirectURI.Query()
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	redirectURI.RawQuery = callback.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	// codes are redeemed once
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code":
+		tokenError(w, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	case !ok || time.Now().After(auth.expiresAt):
+		tokenError(w, "invalid_grant", "unknown or expired code")
+		return
+	case r.PostForm.Get("redirect_uri") != auth.redirectURI:
+		tokenError(w, "invalid_grant", "redirect_uri does not match")
+		return
+	case !verifyChallenge(r.PostForm.Get("code_verifier"), auth.codeChallenge):
+		tokenError(w, "invalid_grant", "code_verifier does not match the challenge")
+		return
+	}
+
+	now := time.Now()
+	signed, err := p.SignIDToken(jwt.MapClaims{
+		"iss":            p.Issuer,
+		"sub":            base64.RawURLEncoding.EncodeToString([]byte(auth.email)),
+		"aud":            auth.clientId,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute * 5).Unix(),
+		"nonce":          auth.nonce,
+		"email":          auth.email,
+		"email_verified": p.EmailVerified,
+		"given_name":     p.GivenName,
+		"family_name":    p.FamilyName,
+		"name":           p.GivenName + " " + p.FamilyName,
+	})
+	if err != nil {
+		tokenError(w, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomToken(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, tokens.JWKS{Keys: []tokens.JWK{{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: tokens.AlgorithmRS256,
+		Kid: KeyId,
+		N:   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+func verifyChallenge(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier != "" && base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+}
+
+func randomToken() string {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		log.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
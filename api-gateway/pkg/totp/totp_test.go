@@ -0,0 +1,76 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// the RFC 6238 SHA1 secret "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       int64
+		wantStep int64
+		valid    bool
+	}{
+		// the last six digits of the RFC 6238 appendix B test vectors
+		{name: "rfc 59", secret: rfcSecret, code: "287082", at: 59, wantStep: 1, valid: true},
+		{name: "rfc 1111111109", secret: rfcSecret, code: "081804", at: 1111111109, wantStep: 37037036, valid: true},
+		{name: "rfc 1111111111", secret: rfcSecret, code: "050471", at: 1111111111, wantStep: 37037037, valid: true},
+		{name: "rfc 1234567890", secret: rfcSecret, code: "005924", at: 1234567890, wantStep: 41152263, valid: true},
+		{name: "rfc 2000000000", secret: rfcSecret, code: "279037", at: 2000000000, wantStep: 66666666, valid: true},
+		{name: "lower case secret", secret: strings.ToLower(rfcSecret), code: "287082", at: 59, wantStep: 1, valid: true},
+		{name: "previous period", secret: rfcSecret, code: "287082", at: 59 + Period, wantStep: 1, valid: true},
+		{name: "next period", secret: rfcSecret, code: "081804", at: 1111111109 - Period, wantStep: 37037036, valid: true},
+		{name: "two periods late", secret: rfcSecret, code: "287082", at: 59 + 2*Period, valid: false},
+		{name: "wrong code", secret: rfcSecret, code: "287083", at: 59, valid: false},
+		{name: "short code", secret: rfcSecret, code: "28708", at: 59, valid: false},
+		{name: "long code", secret: rfcSecret, code: "2870820", at: 59, valid: false},
+		{name: "empty code", secret: rfcSecret, code: "", at: 59, valid: false},
+		{name: "invalid secret", secret: "not base32!", code: "287082", at: 59, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, valid := Validate(tt.secret, tt.code, time.Unix(tt.at, 0))
+			if valid != tt.valid {
+				t.Fatalf("Validate() valid = %v, want %v", valid, tt.valid)
+			}
+			if valid && step != tt.wantStep {
+				t.Fatalf("Validate() step = %d, want %d", step, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != secretSize {
+		t.Fatalf("secret %q decodes to %d bytes, %v", secret, len(key), err)
+	}
+
+	now := time.Now()
+	if _, ok := Validate(secret, generate(key, now.Unix()/Period), now); !ok {
+		t.Fatal("the current code of a generated secret is refused")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("exam", "user@exam.local", rfcSecret)
+
+	for _, part := range []string{"otpauth://totp/exam:user@exam.local?", "secret=" + rfcSecret, "issuer=exam", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("%q does not contain %q", uri, part)
+		}
+	}
+}
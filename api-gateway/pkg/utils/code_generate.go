@@ -1,13 +1,53 @@
 package utils
 
-import "math/rand"
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+)
 
-func GenerateCode(max int) string {
-	chars := "0123456789"
+// GenerateSecureCode returns a numeric code of the given length
+// read from crypto/rand, use it for codes that grant access
+func GenerateSecureCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+
+	return string(code), nil
+}
+
+const passwordAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateSecurePassword returns a random password of letters and digits
+// without the look-alike ones, for temporary passwords handed to people
+func GenerateSecurePassword(length int) (string, error) {
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+
+	return string(password), nil
+}
 
-	code := ""
-	for i := 0; i <= max; i++ {
-		code += string(chars[rand.Int()%len(chars)])
+// APIKeyPrefix starts every api key so a leaked one is easy to recognize
+const APIKeyPrefix = "exk_"
+
+// GenerateAPIKey returns a new api key with 256 random bits,
+// only its hash is stored so it is shown to the superadmin once
+func GenerateAPIKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
 	}
-	return code
+
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
 }
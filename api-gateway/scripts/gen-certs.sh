@@ -0,0 +1,30 @@
+#!/bin/bash
+# Generates a local CA and the grpc certificates of the gateway and the services.
+# Certificates are renewed in place, running services pick them up without a restart.
+set -e
+
+CERTS_DIR=${1:-$(pwd)/certs}
+DAYS=${DAYS:-365}
+
+mkdir -p $CERTS_DIR
+cd $CERTS_DIR
+
+if [[ ! -f ca.pem ]]; then
+  openssl req -x509 -newkey rsa:4096 -nodes -days 3650 \
+          -subj "/CN=exam local ca" \
+          -keyout ca-key.pem -out ca.pem
+fi
+
+# the services dial each other too, so every certificate is good for both ends
+for name in api-gateway userservice productservice; do
+  openssl req -newkey rsa:2048 -nodes \
+          -subj "/CN=$name" \
+          -keyout $name-key.pem -out $name.csr
+  printf "subjectAltName=DNS:%s,DNS:localhost,IP:127.0.0.1\nextendedKeyUsage=serverAuth,clientAuth\n" $name > $name.ext
+  openssl x509 -req -in $name.csr -days $DAYS \
+          -CA ca.pem -CAkey ca-key.pem -CAcreateserial \
+          -extfile $name.ext -out $name.pem
+  rm $name.csr $name.ext
+done;
+
+echo "certificates are in $CERTS_DIR, point GRPC_TLS_CERT, GRPC_TLS_KEY and GRPC_TLS_CA at them"
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"exam/api-gateway/config"
+	pbp "exam/api-gateway/genproto/product-service"
+	pbu "exam/api-gateway/genproto/user-service"
+	"exam/api-gateway/pkg/logger"
+	"exam/pkg/auth"
+	"exam/pkg/tlsconfig"
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+type IServiceManager interface {
+	UserService() pbu.UserServiceClient
+	ProductService() pbp.ProductServiceClient
+}
+
+type serviceManager struct {
+	userService    pbu.UserServiceClient
+	productService pbp.ProductServiceClient
+}
+
+func (s *serviceManager) UserService() pbu.UserServiceClient {
+	return s.userService
+}
+
+func (s *serviceManager) ProductService() pbp.ProductServiceClient {
+	return s.productService
+}
+
+// NewServiceManager dials the services, with mutual tls when the
+// certificate files are configured. The caller of every call is signed
+// with the key of the service it goes to
+func NewServiceManager(conf *config.Config, log logger.Logger) (IServiceManager, error) {
+	userSigner := auth.NewSigner(conf.UserServiceTokenKey, "user-service")
+	productSigner := auth.NewSigner(conf.ProductServiceTokenKey, "product-service")
+
+	creds, err := tlsconfig.ClientCredentials(context.Background(), tlsconfig.Files{
+		CertFile: conf.TLSCertFile,
+		KeyFile:  conf.TLSKeyFile,
+		CAFile:   conf.TLSCAFile,
+		Disabled: conf.TLSDisabled,
+	}, log)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load tls files: %w", err)
+	}
+
+	connUser, err := grpc.Dial(
+		fmt.Sprintf("%s:%d", conf.UserServiceHost, conf.UserServicePort),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(userSigner.UnaryInterceptor()),
+		grpc.WithChainStreamInterceptor(userSigner.StreamInterceptor()))
+	if err != nil {
+		return nil, err
+	}
+
+	connProduct, err := grpc.Dial(
+		fmt.Sprintf("%s:%d", conf.ProductServiceHost, conf.ProductServicePort),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(productSigner.UnaryInterceptor()),
+		grpc.WithChainStreamInterceptor(productSigner.StreamInterceptor()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &serviceManager{
+		userService:    pbu.NewUserServiceClient(connUser),
+		productService: pbp.NewProductServiceClient(connProduct),
+	}, nil
+}
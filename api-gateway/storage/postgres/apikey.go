@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"database/sql"
+	"exam/api-gateway/api/handlers/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type apiKeyRepo struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepo(db *sqlx.DB) *apiKeyRepo {
+	return &apiKeyRepo{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, previous_key_hash, previous_key_expires_at,
+	scopes, created_by, expires_at, last_used_at, created_at, updated_at, revoked_at`
+
+// lastUsedPrecision is how stale last_used_at may get,
+// keys used by every request of a script are not written every time
+const lastUsedPrecision = time.Minute
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var (
+		key             models.APIKey
+		previousKeyHash sql.NullString
+	)
+	err := row.Scan(
+		&key.Id,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&previousKeyHash,
+		&key.PreviousKeyExpiresAt,
+		pq.Array(&key.Scopes),
+		&key.CreatedBy,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.PreviousKeyHash = previousKeyHash.String
+
+	return &key, nil
+}
+
+func (r *apiKeyRepo) Create(key *models.APIKey) error {
+	query := `INSERT INTO api_keys(id, name, prefix, key_hash, scopes, created_by, expires_at)
+								VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING created_at`
+
+	return r.db.QueryRow(query, key.Id,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(key.Scopes),
+		key.CreatedBy,
+		key.ExpiresAt).Scan(&key.CreatedAt)
+}
+
+func (r *apiKeyRepo) Get(id string) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+}
+
+func (r *apiKeyRepo) GetByHash(hash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1 OR previous_key_hash = $1`
+
+	return scanAPIKey(r.db.QueryRow(query, hash))
+}
+
+func (r *apiKeyRepo) List() ([]*models.APIKey, error) {
+	rows, err := r.db.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepo) Rotate(id, hash, prefix string, expiresAt, previousExpiresAt time.Time) (*models.APIKey, error) {
+	query := `UPDATE api_keys SET previous_key_hash = key_hash, previous_key_expires_at = $5,
+	key_hash = $2, prefix = $3, expires_at = $4, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND revoked_at IS NULL
+	RETURNING ` + apiKeyColumns
+
+	return scanAPIKey(r.db.QueryRow(query, id, hash, prefix, expiresAt, previousExpiresAt))
+}
+
+func (r *apiKeyRepo) Revoke(id string) error {
+	query := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepo) Touch(id string) error {
+	query := `UPDATE api_keys SET last_used_at = $2
+	WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)`
+	now := time.Now()
+	_, err := r.db.Exec(query, id, now, now.Add(-lastUsedPrecision))
+	return err
+}
+
+func (r *apiKeyRepo) CountByScope(scope string) (int64, error) {
+	query := `SELECT COUNT(*) FROM api_keys WHERE revoked_at IS NULL AND $1 = ANY(scopes)`
+
+	var count int64
+	err := r.db.QueryRow(query, scope).Scan(&count)
+	return count, err
+}
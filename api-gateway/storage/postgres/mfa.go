@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"database/sql"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/storage/postgresrepo"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type mfaRepo struct {
+	db *sqlx.DB
+}
+
+func NewMFARepo(db *sqlx.DB) *mfaRepo {
+	return &mfaRepo{db: db}
+}
+
+func (r *mfaRepo) Get(subject string) (*models.MFA, error) {
+	query := `SELECT subject, secret, enabled, recovery_codes, last_used_step, created_at, enabled_at
+	FROM mfa WHERE subject = $1`
+
+	var (
+		mfa       models.MFA
+		enabledAt sql.NullTime
+	)
+	err := r.db.QueryRow(query, subject).Scan(
+		&mfa.Subject,
+		&mfa.Secret,
+		&mfa.Enabled,
+		pq.Array(&mfa.RecoveryCodes),
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+		&enabledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if enabledAt.Valid {
+		mfa.EnabledAt = &enabledAt.Time
+	}
+
+	return &mfa, nil
+}
+
+// Save starts a new enrolment, an enabled one is never overwritten
+func (r *mfaRepo) Save(mfa *models.MFA) error {
+	query := `INSERT INTO mfa(subject, secret) VALUES($1, $2)
+	ON CONFLICT (subject) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+	WHERE mfa.enabled = FALSE
+	RETURNING created_at`
+
+	err := r.db.QueryRow(query, mfa.Subject, mfa.Secret).Scan(&mfa.CreatedAt)
+	if err == sql.ErrNoRows {
+		return postgresrepo.ErrMFAEnabled
+	}
+
+	return err
+}
+
+func (r *mfaRepo) Enable(subject string, recoveryCodes []string) error {
+	query := `UPDATE mfa SET enabled = TRUE, recovery_codes = $2, enabled_at = CURRENT_TIMESTAMP
+	WHERE subject = $1`
+	_, err := r.db.Exec(query, subject, pq.Array(recoveryCodes))
+	return err
+}
+
+// UseStep remembers the time step of an accepted code,
+// false means the code of this or a later step was already used
+func (r *mfaRepo) UseStep(subject string, step int64) (bool, error) {
+	query := `UPDATE mfa SET last_used_step = $2 WHERE subject = $1 AND last_used_step < $2`
+	result, err := r.db.Exec(query, subject, step)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// UseRecoveryCode removes the recovery code, false means it is unknown or used
+func (r *mfaRepo) UseRecoveryCode(subject, codeHash string) (bool, error) {
+	query := `UPDATE mfa SET recovery_codes = array_remove(recovery_codes, $2)
+	WHERE subject = $1 AND enabled = TRUE AND $2 = ANY(recovery_codes)`
+	result, err := r.db.Exec(query, subject, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *mfaRepo) Delete(subject string) error {
+	query := `DELETE FROM mfa WHERE subject = $1`
+	_, err := r.db.Exec(query, subject)
+	return err
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"database/sql"
+	"exam/api-gateway/api/handlers/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type outboxRepo struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepo(db *sqlx.DB) *outboxRepo {
+	return &outboxRepo{db: db}
+}
+
+func (r *outboxRepo) Enqueue(email *models.OutboxEmail) error {
+	query := `INSERT INTO email_outbox(id, recipient, subject, text, html)
+								VALUES($1, $2, $3, $4, $5) RETURNING status, next_attempt_at, created_at`
+	return r.db.QueryRow(query, email.Id,
+		email.Recipient,
+		email.Subject,
+		email.Text,
+		email.HTML).Scan(&email.Status, &email.NextAttemptAt, &email.CreatedAt)
+}
+
+// ClaimDue takes the pending emails whose time has come and hides them from
+// other senders for the lease, so a crashed sender does not lose them for good
+func (r *outboxRepo) ClaimDue(limit int, lease time.Duration) ([]*models.OutboxEmail, error) {
+	query := `UPDATE email_outbox SET next_attempt_at = $2
+	WHERE id IN (
+		SELECT id FROM email_outbox
+		WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+		ORDER BY next_attempt_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, recipient, subject, text, html, status, attempts, last_error, next_attempt_at, created_at`
+
+	rows, err := r.db.Query(query, limit, time.Now().Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emails []*models.OutboxEmail
+	for rows.Next() {
+		var (
+			email     models.OutboxEmail
+			lastError sql.NullString
+		)
+		err := rows.Scan(
+			&email.Id,
+			&email.Recipient,
+			&email.Subject,
+			&email.Text,
+			&email.HTML,
+			&email.Status,
+			&email.Attempts,
+			&lastError,
+			&email.NextAttemptAt,
+			&email.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		email.LastError = lastError.String
+
+		emails = append(emails, &email)
+	}
+
+	return emails, rows.Err()
+}
+
+func (r *outboxRepo) MarkSent(id string) error {
+	query := `UPDATE email_outbox SET status = 'sent', attempts = attempts + 1, sent_at = CURRENT_TIMESTAMP
+	WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// MarkFailed schedules the next attempt, dead emails are kept for inspection but never retried
+func (r *outboxRepo) MarkFailed(id, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := models.OutboxStatusPending
+	if dead {
+		status = models.OutboxStatusDead
+	}
+
+	query := `UPDATE email_outbox SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4
+	WHERE id = $1`
+	_, err := r.db.Exec(query, id, status, lastError, nextAttemptAt)
+	return err
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"encoding/json"
+	"exam/api-gateway/api/handlers/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type policyRepo struct {
+	db *sqlx.DB
+}
+
+func NewPolicyRepo(db *sqlx.DB) *policyRepo {
+	return &policyRepo{db: db}
+}
+
+func (r *policyRepo) Replace(rules []models.CasbinRule) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM casbin_rule WHERE NOT (ptype = 'g' AND v0 LIKE 'sub:%')`
+	if _, err := tx.Exec(query); err != nil {
+		return err
+	}
+
+	// unused fields are empty strings, the way the casbin adapter saves them
+	query = `INSERT INTO casbin_rule(ptype, v0, v1, v2, v3, v4, v5) VALUES($1, $2, $3, $4, '', '', '')`
+	for _, rule := range rules {
+		if _, err := tx.Exec(query, rule.PType, rule.V0, rule.V1, rule.V2); err != nil {
+			return err
+		}
+	}
+
+	// the gateways reload the whole policy when the transaction commits
+	reload, err := json.Marshal(models.PolicyUpdate{Op: models.PolicyUpdateReload})
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`SELECT pg_notify($1, $2)`, models.PolicyChannel, string(reload)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
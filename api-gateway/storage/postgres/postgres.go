@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"database/sql"
 	"errors"
 	"exam/api-gateway/api/handlers/models"
 	"fmt"
@@ -67,3 +68,113 @@ func (r *adminRepo) Get(userName string) (string, string, bool, error) {
 
 	return role, password, status == 1, nil
 }
+
+const adminColumns = `id, full_name, COALESCE(age, 0), email, username, password, role,
+	disabled, must_change_password, last_login_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAdmin(row rowScanner) (*models.Admin, error) {
+	var admin models.Admin
+	err := row.Scan(
+		&admin.Id,
+		&admin.FullName,
+		&admin.Age,
+		&admin.Email,
+		&admin.UserName,
+		&admin.Password,
+		&admin.Role,
+		&admin.Disabled,
+		&admin.MustChangePassword,
+		&admin.LastLoginAt,
+		&admin.CreatedAt,
+		&admin.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &admin, nil
+}
+
+func (r *adminRepo) GetById(id string) (*models.Admin, error) {
+	query := `SELECT ` + adminColumns + ` FROM admins WHERE id = $1`
+	return scanAdmin(r.db.QueryRow(query, id))
+}
+
+func (r *adminRepo) GetByUsername(userName string) (*models.Admin, error) {
+	query := `SELECT ` + adminColumns + ` FROM admins WHERE username = $1`
+	return scanAdmin(r.db.QueryRow(query, userName))
+}
+
+func (r *adminRepo) List(page, limit int) (*models.AdminList, error) {
+	list := models.AdminList{Admins: []*models.Admin{}}
+
+	if err := r.db.QueryRow(`SELECT COUNT(1) FROM admins`).Scan(&list.Count); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + adminColumns + ` FROM admins
+	ORDER BY created_at, username
+	LIMIT $1 OFFSET $2`
+	rows, err := r.db.Query(query, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		admin, err := scanAdmin(rows)
+		if err != nil {
+			return nil, err
+		}
+		list.Admins = append(list.Admins, admin)
+	}
+
+	return &list, rows.Err()
+}
+
+func (r *adminRepo) Update(id string, update *models.AdminUpdateReq) (*models.Admin, error) {
+	query := `UPDATE admins SET full_name = $2, email = $3, role = $4, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1
+	RETURNING ` + adminColumns
+	return scanAdmin(r.db.QueryRow(query, id, update.FullName, update.Email, update.Role))
+}
+
+func (r *adminRepo) SetDisabled(id string, disabled bool) error {
+	query := `UPDATE admins SET disabled = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	return r.execOne(query, id, disabled)
+}
+
+// SetPassword replaces the password hash, mustChange makes the next login fail
+// until the admin picks a password of their own
+func (r *adminRepo) SetPassword(id, password string, mustChange bool) error {
+	query := `UPDATE admins SET password = $2, must_change_password = $3, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1`
+	return r.execOne(query, id, password, mustChange)
+}
+
+func (r *adminRepo) UpdateLastLogin(userName string) error {
+	query := `UPDATE admins SET last_login_at = CURRENT_TIMESTAMP WHERE username = $1`
+	return r.execOne(query, userName)
+}
+
+// execOne runs an update of a single admin, sql.ErrNoRows tells it did not exist
+func (r *adminRepo) execOne(query string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+	"exam/api-gateway/storage/postgresrepo"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type roleRepo struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepo(db *sqlx.DB) *roleRepo {
+	return &roleRepo{db: db}
+}
+
+const roleColumns = `name, description, built_in, created_at, updated_at`
+
+func scanRole(row rowScanner) (*models.Role, error) {
+	var role models.Role
+	err := row.Scan(
+		&role.Name,
+		&role.Description,
+		&role.BuiltIn,
+		&role.CreatedAt,
+		&role.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *roleRepo) List() ([]*models.Role, error) {
+	rows, err := r.db.Query(`SELECT ` + roleColumns + ` FROM roles ORDER BY created_at, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []*models.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *roleRepo) Get(name string) (*models.Role, error) {
+	return scanRole(r.db.QueryRow(`SELECT `+roleColumns+` FROM roles WHERE name = $1`, name))
+}
+
+func (r *roleRepo) Create(role *models.Role) error {
+	query := `INSERT INTO roles(name, description) VALUES($1, $2) RETURNING created_at`
+
+	err := r.db.QueryRow(query, role.Name, role.Description).Scan(&role.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return postgresrepo.ErrRoleExists
+	}
+
+	return err
+}
+
+func (r *roleRepo) Update(role *models.Role) error {
+	query := `UPDATE roles SET description = $2, updated_at = CURRENT_TIMESTAMP
+	WHERE name = $1
+	RETURNING ` + roleColumns
+
+	updated, err := scanRole(r.db.QueryRow(query, role.Name, role.Description))
+	if err != nil {
+		return err
+	}
+	*role = *updated
+
+	return nil
+}
+
+// Delete removes a role that is not built in
+func (r *roleRepo) Delete(name string) error {
+	result, err := r.db.Exec(`DELETE FROM roles WHERE name = $1 AND NOT built_in`, name)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"database/sql"
+	"exam/api-gateway/api/handlers/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type sessionRepo struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepo(db *sqlx.DB) *sessionRepo {
+	return &sessionRepo{db: db}
+}
+
+// Create revokes the active session of the device and starts a new one
+func (r *sessionRepo) Create(session *models.Session) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP
+	WHERE user_id = $1 AND device_id = $2 AND revoked_at IS NULL`
+	if _, err := tx.Exec(query, session.UserId, session.DeviceId); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO sessions(id, user_id, role, device_id, user_agent, refresh_token_hash, expires_at)
+								VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING created_at`
+	err = tx.QueryRow(query, session.Id,
+		session.UserId,
+		session.Role,
+		session.DeviceId,
+		session.UserAgent,
+		session.RefreshTokenHash,
+		session.ExpiresAt).Scan(&session.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *sessionRepo) Get(id string) (*models.Session, error) {
+	query := `SELECT id, user_id, role, device_id, user_agent, refresh_token_hash, created_at, expires_at, revoked_at
+	FROM sessions WHERE id = $1`
+
+	var (
+		session   models.Session
+		userAgent sql.NullString
+		revokedAt sql.NullTime
+	)
+	err := r.db.QueryRow(query, id).Scan(
+		&session.Id,
+		&session.UserId,
+		&session.Role,
+		&session.DeviceId,
+		&userAgent,
+		&session.RefreshTokenHash,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	session.UserAgent = userAgent.String
+	session.Revoked = revokedAt.Valid
+
+	return &session, nil
+}
+
+// Rotate replaces the refresh token hash only if oldHash is still the current one,
+// false means the presented token was already used
+func (r *sessionRepo) Rotate(id, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	query := `UPDATE sessions SET refresh_token_hash = $3, expires_at = $4, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND refresh_token_hash = $2 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, id, oldHash, newHash, expiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *sessionRepo) Revoke(id string) error {
+	query := `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *sessionRepo) RevokeAll(userId string) (int64, error) {
+	query := `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
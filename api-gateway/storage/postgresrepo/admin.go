@@ -0,0 +1,18 @@
+package postgresrepo
+
+import "exam/api-gateway/api/handlers/models"
+
+// AdminStorageI keeps the admin and superadmin accounts,
+// lookups of a missing admin return sql.ErrNoRows
+type AdminStorageI interface {
+	Create(admin *models.AdminResp) error
+	Delete(userName, password string) error
+	Get(userName string) (string, string, bool, error)
+	GetById(id string) (*models.Admin, error)
+	GetByUsername(userName string) (*models.Admin, error)
+	List(page, limit int) (*models.AdminList, error)
+	Update(id string, update *models.AdminUpdateReq) (*models.Admin, error)
+	SetDisabled(id string, disabled bool) error
+	SetPassword(id, password string, mustChange bool) error
+	UpdateLastLogin(userName string) error
+}
@@ -0,0 +1,22 @@
+package postgresrepo
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	"time"
+)
+
+// APIKeyStorageI keeps the api keys of machine clients,
+// lookups of a missing key return sql.ErrNoRows
+type APIKeyStorageI interface {
+	Create(key *models.APIKey) error
+	Get(id string) (*models.APIKey, error)
+	// GetByHash finds the key by its current hash or the one it was rotated from
+	GetByHash(hash string) (*models.APIKey, error)
+	List() ([]*models.APIKey, error)
+	// Rotate replaces the hash, the old one is accepted until previousExpiresAt
+	Rotate(id, hash, prefix string, expiresAt, previousExpiresAt time.Time) (*models.APIKey, error)
+	Revoke(id string) error
+	Touch(id string) error
+	// CountByScope counts the keys that are not revoked and have the scope
+	CountByScope(scope string) (int64, error)
+}
@@ -0,0 +1,19 @@
+package postgresrepo
+
+import (
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+)
+
+// ErrMFAEnabled is returned by Save when the subject already has active 2FA
+var ErrMFAEnabled = errors.New("two-factor authentication is already enabled")
+
+// MFAStorageI keeps the TOTP enrolments of users and admins
+type MFAStorageI interface {
+	Get(subject string) (*models.MFA, error)
+	Save(mfa *models.MFA) error
+	Enable(subject string, recoveryCodes []string) error
+	UseStep(subject string, step int64) (bool, error)
+	UseRecoveryCode(subject, codeHash string) (bool, error)
+	Delete(subject string) error
+}
@@ -0,0 +1,14 @@
+package postgresrepo
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	"time"
+)
+
+// OutboxStorageI queues emails until the background sender delivers them
+type OutboxStorageI interface {
+	Enqueue(email *models.OutboxEmail) error
+	ClaimDue(limit int, lease time.Duration) ([]*models.OutboxEmail, error)
+	MarkSent(id string) error
+	MarkFailed(id, lastError string, nextAttemptAt time.Time, dead bool) error
+}
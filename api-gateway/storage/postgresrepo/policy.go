@@ -0,0 +1,10 @@
+package postgresrepo
+
+import "exam/api-gateway/api/handlers/models"
+
+// PolicyStorageI writes the casbin_rule table the enforcer loads its policy from
+type PolicyStorageI interface {
+	// Replace swaps every role policy and grouping rule in one transaction,
+	// the role assignments of subjects are kept and every gateway is told to reload
+	Replace(rules []models.CasbinRule) error
+}
@@ -0,0 +1,19 @@
+package postgresrepo
+
+import (
+	"errors"
+	"exam/api-gateway/api/handlers/models"
+)
+
+// ErrRoleExists is returned by Create when a role with the name is already defined
+var ErrRoleExists = errors.New("role already exists")
+
+// RoleStorageI keeps the roles that can be used in casbin rules,
+// lookups of a missing role return sql.ErrNoRows
+type RoleStorageI interface {
+	List() ([]*models.Role, error)
+	Get(name string) (*models.Role, error)
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	Delete(name string) error
+}
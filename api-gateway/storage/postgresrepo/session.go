@@ -0,0 +1,15 @@
+package postgresrepo
+
+import (
+	"exam/api-gateway/api/handlers/models"
+	"time"
+)
+
+// SessionStorageI keeps one refresh token session per user device
+type SessionStorageI interface {
+	Create(session *models.Session) error
+	Get(id string) (*models.Session, error)
+	Rotate(id, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	Revoke(id string) error
+	RevokeAll(userId string) (int64, error)
+}
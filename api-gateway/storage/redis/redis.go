@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"exam/api-gateway/storage/repo"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// incrScript increments and expires the counter atomically,
+// parallel requests never see or overwrite each other's count
+var incrScript = redis.NewScript(1, `
+local count = redis.call("INCR", KEYS[1])
+redis.call("EXPIRE", KEYS[1], ARGV[1])
+return count
+`)
+
+// getDelScript takes the value and deletes it atomically like GETDEL,
+// which older redis servers do not have
+var getDelScript = redis.NewScript(1, `
+local value = redis.call("GET", KEYS[1])
+redis.call("DEL", KEYS[1])
+return value
+`)
+
+type redisRepo struct {
+	rds *redis.Pool
+}
+
+func NewRedisRepo(rds *redis.Pool) repo.InMemoryStorageI {
+	return &redisRepo{rds: rds}
+}
+
+func (r *redisRepo) Set(key, value string) error {
+	conn := r.rds.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SET", key, value)
+	return err
+}
+
+func (r *redisRepo) SetWithTTL(key, value string, seconds int) error {
+	conn := r.rds.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SETEX", key, seconds, value)
+	return err
+}
+
+func (r *redisRepo) Get(key string) (interface{}, error) {
+	conn := r.rds.Get()
+	defer conn.Close()
+
+	return conn.Do("GET", key)
+}
+
+func (r *redisRepo) GetDel(key string) (interface{}, error) {
+	conn := r.rds.Get()
+	defer conn.Close()
+
+	return getDelScript.Do(conn, key)
+}
+
+func (r *redisRepo) Incr(key string, seconds int) (int64, error) {
+	conn := r.rds.Get()
+	defer conn.Close()
+
+	return redis.Int64(incrScript.Do(conn, key, seconds))
+}
+
+func (r *redisRepo) Del(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	conn := r.rds.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", redis.Args{}.AddFlat(keys)...)
+	return err
+}
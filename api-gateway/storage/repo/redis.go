@@ -0,0 +1,14 @@
+package repo
+
+type InMemoryStorageI interface {
+	Set(key, value string) error
+	SetWithTTL(key, value string, seconds int) error
+	Get(key string) (interface{}, error)
+	// GetDel returns the value under key and deletes it in one step,
+	// of parallel callers only one gets the value
+	GetDel(key string) (interface{}, error)
+	// Incr adds one to the counter under key and returns the new value in one step,
+	// the counter is forgotten seconds after its last increment
+	Incr(key string, seconds int) (int64, error)
+	Del(keys ...string) error
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Issuer is the gateway, the only client allowed to sign caller tokens
+	Issuer = "api-gateway"
+
+	// TypeService tells caller tokens apart from the tokens of users
+	TypeService = "service"
+
+	// RoleGateway is the role of calls the gateway makes on its own behalf
+	RoleGateway = "gateway"
+
+	// TokenLifetime covers a single call to a service
+	TokenLifetime = time.Minute
+
+	metadataKey = "authorization"
+)
+
+// Logger is the part of the loggers of the services the interceptors use
+type Logger interface {
+	Warn(msg string, fields ...zap.Field)
+}
+
+// Claims identify the caller of a request the gateway forwards,
+// Roles holds the role of the caller and every role it inherits
+type Claims struct {
+	Roles []string `json:"roles"`
+	Type  string   `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+type callerKey struct{}
+
+// Caller returns the claims of the caller verified by the interceptors
+func Caller(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(callerKey{}).(*Claims)
+	return claims, ok
+}
+
+// Authorizer verifies the caller token in the grpc metadata and checks
+// the roles every rpc requires, rpcs missing from the rules are denied
+type Authorizer struct {
+	key      []byte
+	audience string
+	rules    map[string][]string
+	log      Logger
+}
+
+// NewAuthorizer verifies the tokens signed with key for audience, the key
+// belongs to this service alone so no other service can forge its callers
+func NewAuthorizer(key, audience string, rules map[string][]string, log Logger) *Authorizer {
+	return &Authorizer{
+		key:      []byte(key),
+		audience: audience,
+		rules:    rules,
+		log:      log,
+	}
+}
+
+func (a *Authorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		claims, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(context.WithValue(ctx, callerKey{}, claims), req)
+	}
+}
+
+func (a *Authorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		claims, err := a.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &callerStream{
+			ServerStream: stream,
+			ctx:          context.WithValue(stream.Context(), callerKey{}, claims),
+		})
+	}
+}
+
+func (a *Authorizer) authorize(ctx context.Context, method string) (*Claims, error) {
+	claims, err := a.verify(ctx)
+	if err != nil {
+		a.log.Warn("unauthenticated rpc", zap.String("method", method), zap.Error(err))
+		return nil, status.Error(codes.Unauthenticated, "a valid caller token is required")
+	}
+
+	for _, required := range a.rules[method] {
+		for _, role := range claims.Roles {
+			if role == required {
+				return claims, nil
+			}
+		}
+	}
+
+	a.log.Warn("rpc denied",
+		zap.String("method", method),
+		zap.String("sub", claims.Subject),
+		zap.Any("roles", claims.Roles))
+	return nil, status.Error(codes.PermissionDenied, "the caller is not allowed to call "+method)
+}
+
+func (a *Authorizer) verify(ctx context.Context) (*Claims, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(metadataKey)) == 0 {
+		return nil, errors.New("no caller token")
+	}
+	token := strings.TrimPrefix(md.Get(metadataKey)[0], "Bearer ")
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return a.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithAudience(a.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != TypeService {
+		return nil, errors.New("not a caller token")
+	}
+
+	return &claims, nil
+}
+
+// callerStream hands the context with the caller to stream handlers
+type callerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *callerStream) Context() context.Context {
+	return s.ctx
+}
+
+// caller is who the gateway calls a service for
+type caller struct {
+	sub   string
+	roles []string
+}
+
+type outgoingKey struct{}
+
+// WithCaller sets who the calls made with ctx are made for, Roles holds
+// the role of the caller followed by every role it inherits
+func WithCaller(ctx context.Context, sub string, roles []string) context.Context {
+	return context.WithValue(ctx, outgoingKey{}, caller{sub: sub, roles: roles})
+}
+
+// Sign signs the caller for a single service with the key of that service
+func Sign(key, audience, sub string, roles []string) (string, error) {
+	now := time.Now()
+
+	claims := Claims{
+		Roles: roles,
+		Type:  TypeService,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   sub,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TokenLifetime)),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+}
+
+// Signer adds the caller set with WithCaller to the calls of a client as a
+// token only the service it dials accepts, calls without a caller go out
+// without a token and are refused by the service
+type Signer struct {
+	key      string
+	audience string
+}
+
+func NewSigner(key, audience string) *Signer {
+	return &Signer{key: key, audience: audience}
+}
+
+func (s *Signer) UnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := s.sign(ctx)
+		if err != nil {
+			return err
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (s *Signer) StreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := s.sign(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func (s *Signer) sign(ctx context.Context) (context.Context, error) {
+	c, ok := ctx.Value(outgoingKey{}).(caller)
+	if !ok {
+		return ctx, nil
+	}
+
+	token, err := Sign(s.key, s.audience, c.sub, c.roles)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "cannot sign the caller token")
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, metadataKey, "Bearer "+token), nil
+}
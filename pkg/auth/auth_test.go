@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testKey      = "user-service-key"
+	testAudience = "user-service"
+)
+
+var testRules = map[string][]string{
+	"/user.UserService/CreateUser":  {RoleGateway},
+	"/user.UserService/GetUserById": {"user", RoleGateway},
+	"/user.UserService/CheckField":  {"unauthorized"},
+	"/user.UserService/DeleteUser":  {"admin"},
+}
+
+func signClaims(t *testing.T, method jwt.SigningMethod, key interface{}, claims Claims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func callerClaims(audience string, roles ...string) Claims {
+	now := time.Now()
+	return Claims{
+		Roles: roles,
+		Type:  TypeService,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TokenLifetime)),
+		},
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	sign := func(key, audience, sub string, roles ...string) string {
+		token, err := Sign(key, audience, sub, roles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	expired := callerClaims(testAudience, "admin")
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	withoutExpiry := callerClaims(testAudience, "admin")
+	withoutExpiry.ExpiresAt = nil
+	otherIssuer := callerClaims(testAudience, "admin")
+	otherIssuer.Issuer = "user-service"
+	userToken := callerClaims(testAudience, "admin")
+	userToken.Type = "access"
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		code   codes.Code
+	}{
+		{
+			name:   "role allowed",
+			method: "/user.UserService/GetUserById",
+			token:  sign(testKey, testAudience, "user-1", "user", "unauthorized"),
+			code:   codes.OK,
+		},
+		{
+			name:   "inherited role allowed",
+			method: "/user.UserService/CheckField",
+			token:  sign(testKey, testAudience, "user-1", "user", "unauthorized"),
+			code:   codes.OK,
+		},
+		{
+			name:   "gateway allowed",
+			method: "/user.UserService/CreateUser",
+			token:  sign(testKey, testAudience, "", RoleGateway),
+			code:   codes.OK,
+		},
+		{
+			name:   "unauthorized caller on a gateway rpc",
+			method: "/user.UserService/CreateUser",
+			token:  sign(testKey, testAudience, "", "unauthorized"),
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "admin on a gateway rpc",
+			method: "/user.UserService/CreateUser",
+			token:  sign(testKey, testAudience, "admin-1", "admin", "user", "unauthorized"),
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "role missing",
+			method: "/user.UserService/DeleteUser",
+			token:  sign(testKey, testAudience, "user-1", "user", "unauthorized"),
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "rpc without rule",
+			method: "/user.UserService/Unknown",
+			token:  sign(testKey, testAudience, "", RoleGateway),
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "no token",
+			method: "/user.UserService/CheckField",
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "key of another service",
+			method: "/user.UserService/CheckField",
+			token:  sign("product-service-key", testAudience, "user-1", "unauthorized"),
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "token of another service",
+			method: "/user.UserService/CheckField",
+			token:  sign(testKey, "product-service", "user-1", "unauthorized"),
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "expired",
+			method: "/user.UserService/DeleteUser",
+			token:  signClaims(t, jwt.SigningMethodHS256, []byte(testKey), expired),
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "without expiry",
+			method: "/user.UserService/DeleteUser",
+			token:  signClaims(t, jwt.SigningMethodHS256, []byte(testKey), withoutExpiry),
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "other issuer",
+			method: "/user.UserService/DeleteUser",
+			token:  signClaims(t, jwt.SigningMethodHS256, []byte(testKey), otherIssuer),
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "not a caller token",
+			method: "/user.UserService/DeleteUser",
+			token:  signClaims(t, jwt.SigningMethodHS256, []byte(testKey), userToken),
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "alg none",
+			method: "/user.UserService/DeleteUser",
+			token:  signClaims(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, callerClaims(testAudience, "admin")),
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "garbage",
+			method: "/user.UserService/CheckField",
+			token:  "not-a-token",
+			code:   codes.Unauthenticated,
+		},
+	}
+
+	interceptor := NewAuthorizer(testKey, testAudience, testRules, zap.NewNop()).UnaryInterceptor()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(metadataKey, "Bearer "+tt.token))
+			}
+
+			var caller *Claims
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				caller, _ = Caller(ctx)
+				return nil, nil
+			})
+
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %v, want %v: %v", code, tt.code, err)
+			}
+			if tt.code == codes.OK && caller == nil {
+				t.Fatal("the handler did not get the caller")
+			}
+			if tt.code != codes.OK && caller != nil {
+				t.Fatal("the handler was called")
+			}
+		})
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	interceptor := NewAuthorizer(testKey, testAudience, testRules, zap.NewNop()).StreamInterceptor()
+
+	token, err := Sign(testKey, testAudience, "admin-1", []string{"admin", "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(metadataKey, "Bearer "+token))
+
+	tests := []struct {
+		name   string
+		method string
+		code   codes.Code
+	}{
+		{name: "role allowed", method: "/user.UserService/DeleteUser", code: codes.OK},
+		{name: "gateway rpc", method: "/user.UserService/CreateUser", code: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var caller *Claims
+			err := interceptor(nil, &testStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method}, func(srv interface{}, stream grpc.ServerStream) error {
+				caller, _ = Caller(stream.Context())
+				return nil
+			})
+
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %v, want %v: %v", code, tt.code, err)
+			}
+			if (caller != nil) != (tt.code == codes.OK) {
+				t.Fatalf("caller = %v with code %v", caller, tt.code)
+			}
+		})
+	}
+}
+
+func TestSigner(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		signer *Signer
+		code   codes.Code
+	}{
+		{
+			name:   "caller",
+			ctx:    WithCaller(context.Background(), "user-1", []string{"user", "unauthorized"}),
+			signer: NewSigner(testKey, testAudience),
+			code:   codes.OK,
+		},
+		{
+			name:   "without caller",
+			ctx:    context.Background(),
+			signer: NewSigner(testKey, testAudience),
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "signed for another service",
+			ctx:    WithCaller(context.Background(), "user-1", []string{"user", "unauthorized"}),
+			signer: NewSigner("product-service-key", "product-service"),
+			code:   codes.Unauthenticated,
+		},
+	}
+
+	server := NewAuthorizer(testKey, testAudience, testRules, zap.NewNop()).UnaryInterceptor()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signer.UnaryInterceptor()(tt.ctx, "/user.UserService/GetUserById", nil, nil, nil,
+				func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+					md, _ := metadata.FromOutgoingContext(ctx)
+					incoming := metadata.NewIncomingContext(context.Background(), md)
+
+					_, err := server(incoming, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+						caller, ok := Caller(ctx)
+						if !ok || caller.Subject != "user-1" {
+							t.Errorf("caller = %v", caller)
+						}
+						return nil, nil
+					})
+					return err
+				})
+
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %v, want %v: %v", code, tt.code, err)
+			}
+		})
+	}
+}
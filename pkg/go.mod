@@ -0,0 +1,19 @@
+module exam/pkg
+
+go 1.20
+
+require (
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	go.uber.org/zap v1.27.0
+	google.golang.org/grpc v1.62.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+)
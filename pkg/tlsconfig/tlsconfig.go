@@ -0,0 +1,247 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ReloadInterval is how often the files are checked for a new certificate
+const ReloadInterval = time.Second * 30
+
+// Logger is the part of the loggers of the services the reloader uses
+type Logger interface {
+	Info(msg string, fields ...zap.Field)
+	Warn(msg string, fields ...zap.Field)
+	Error(msg string, fields ...zap.Field)
+}
+
+// Files are the PEM files of the certificate, its key and the CA
+// the certificates of the other side are verified with. Plaintext is
+// only used when Disabled is set, missing files are an error otherwise
+type Files struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	Disabled bool
+}
+
+// plaintext tells whether TLS was turned off, files set along with it
+// are refused rather than silently ignored
+func (f Files) plaintext() (bool, error) {
+	if !f.Disabled {
+		return false, nil
+	}
+	if f.CertFile != "" || f.KeyFile != "" || f.CAFile != "" {
+		return false, errors.New("tls is disabled but tls files are set")
+	}
+
+	return true, nil
+}
+
+// Reloader serves the latest certificate and CA found in the files,
+// handshakes pick up a renewed certificate without a restart
+type Reloader struct {
+	files Files
+	log   Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+// New loads the files, every one of them is required for mutual TLS
+func New(files Files, log Logger) (*Reloader, error) {
+	if files.CertFile == "" || files.KeyFile == "" || files.CAFile == "" {
+		return nil, errors.New("mutual tls needs a certificate, a key and a ca file, set TLS_DISABLED=true to run without tls")
+	}
+
+	r := &Reloader{files: files, log: log}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Watch reloads the files when any of them changes, until ctx is done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTime, err := r.lastModified()
+			if err != nil {
+				r.log.Error("cannot stat tls files", zap.Error(err))
+				continue
+			}
+
+			r.mu.RLock()
+			changed := modTime.After(r.modTime)
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+
+			// a half written renewal fails here and is retried on the next tick
+			if err := r.reload(); err != nil {
+				r.log.Error("cannot reload tls files, the previous certificate is kept", zap.Error(err))
+				continue
+			}
+			r.log.Info("tls certificate reloaded", zap.String("cert", r.files.CertFile))
+		}
+	}
+}
+
+func (r *Reloader) lastModified() (time.Time, error) {
+	var last time.Time
+	for _, file := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+
+	return last, nil
+}
+
+func (r *Reloader) reload() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	caPEM, err := os.ReadFile(r.files.CAFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("no certificate found in %s", r.files.CAFile)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.pool = pool
+	r.modTime = modTime
+
+	return nil
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, r.pool
+}
+
+// ServerConfig requires clients to present a certificate signed by the CA
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    pool,
+			}, nil
+		},
+	}
+}
+
+// ClientConfig presents the certificate and verifies the server against the CA
+func (r *Reloader) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+		// the chain is verified in VerifyConnection, RootCAs
+		// would keep the CA the config was created with
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("the server presented no certificate")
+			}
+
+			_, pool := r.current()
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         pool,
+				Intermediates: intermediates,
+				DNSName:       state.ServerName,
+			})
+			return err
+		},
+	}
+}
+
+// ServerCredentials returns plaintext credentials when TLS is disabled
+func ServerCredentials(ctx context.Context, files Files, log Logger) (credentials.TransportCredentials, error) {
+	plaintext, err := files.plaintext()
+	if err != nil {
+		return nil, err
+	}
+	if plaintext {
+		log.Warn("grpc server runs without tls")
+		return insecure.NewCredentials(), nil
+	}
+
+	r, err := New(files, log)
+	if err != nil {
+		return nil, err
+	}
+	go r.Watch(ctx, ReloadInterval)
+
+	return credentials.NewTLS(r.ServerConfig()), nil
+}
+
+// ClientCredentials returns plaintext credentials when TLS is disabled
+func ClientCredentials(ctx context.Context, files Files, log Logger) (credentials.TransportCredentials, error) {
+	plaintext, err := files.plaintext()
+	if err != nil {
+		return nil, err
+	}
+	if plaintext {
+		log.Warn("grpc clients dial without tls")
+		return insecure.NewCredentials(), nil
+	}
+
+	r, err := New(files, log)
+	if err != nil {
+		return nil, err
+	}
+	go r.Watch(ctx, ReloadInterval)
+
+	return credentials.NewTLS(r.ClientConfig()), nil
+}
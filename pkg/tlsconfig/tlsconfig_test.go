@@ -0,0 +1,114 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// writeCertificate writes a self-signed certificate that is its own CA
+func writeCertificate(t *testing.T) Files {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "user-service"},
+		DNSNames:              []string{"user-service"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := Files{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	for file, content := range map[string][]byte{
+		files.CertFile: certPEM,
+		files.KeyFile:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		files.CAFile:   certPEM,
+	} {
+		if err := os.WriteFile(file, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return files
+}
+
+func TestCredentials(t *testing.T) {
+	files := writeCertificate(t)
+	withoutCA := files
+	withoutCA.CAFile = ""
+	disabledWithFiles := files
+	disabledWithFiles.Disabled = true
+
+	tests := []struct {
+		name     string
+		files    Files
+		protocol string
+		wantErr  bool
+	}{
+		{name: "mutual tls", files: files, protocol: "tls"},
+		{name: "disabled", files: Files{Disabled: true}, protocol: "insecure"},
+		{name: "no files", files: Files{}, wantErr: true},
+		{name: "missing ca", files: withoutCA, wantErr: true},
+		{name: "disabled with files", files: disabledWithFiles, wantErr: true},
+		{name: "unreadable files", files: Files{CertFile: "missing.pem", KeyFile: "missing.pem", CAFile: "missing.pem"}, wantErr: true},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := ServerCredentials(ctx, tt.files, zap.NewNop())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("server credentials error = %v, want error %v", err, tt.wantErr)
+			}
+			client, clientErr := ClientCredentials(ctx, tt.files, zap.NewNop())
+			if (clientErr != nil) != tt.wantErr {
+				t.Fatalf("client credentials error = %v, want error %v", clientErr, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if protocol := server.Info().SecurityProtocol; protocol != tt.protocol {
+				t.Fatalf("server protocol = %q, want %q", protocol, tt.protocol)
+			}
+			if protocol := client.Info().SecurityProtocol; protocol != tt.protocol {
+				t.Fatalf("client protocol = %q, want %q", protocol, tt.protocol)
+			}
+		})
+	}
+}
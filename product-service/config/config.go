@@ -16,6 +16,11 @@ type Config struct {
 	PostgresPassword string
 	LogLevel         string
 	RPCPort          string
+	ServiceTokenKey  string //verifies the caller tokens the gateway signs for this service alone
+	TLSDisabled      bool   //grpc only runs in plaintext when set
+	TLSCertFile      string
+	TLSKeyFile       string
+	TLSCAFile        string //verifies the certificates of the clients
 	// PostServiceHost  string
 	// PostServicePort  int
 }
@@ -35,13 +40,20 @@ func Load() *Config {
 
 	c.RPCPort = cast.ToString(getOrReturnDefault("RPC_PORT", ":5050"))
 
+	c.ServiceTokenKey = cast.ToString(getOrReturnDefault("SERVICE_TOKEN_KEY", ""))
+
+	c.TLSDisabled = cast.ToBool(getOrReturnDefault("TLS_DISABLED", false))
+	c.TLSCertFile = cast.ToString(getOrReturnDefault("GRPC_TLS_CERT", ""))
+	c.TLSKeyFile = cast.ToString(getOrReturnDefault("GRPC_TLS_KEY", ""))
+	c.TLSCAFile = cast.ToString(getOrReturnDefault("GRPC_TLS_CA", ""))
+
 	return &c
 }
 
 func getOrReturnDefault(key string, defaultValue interface{}) interface{} {
-	_, exists := os.LookupEnv(key)
+	value, exists := os.LookupEnv(key)
 	if exists {
-		return defaultValue
+		return value
 	}
 
 	return defaultValue
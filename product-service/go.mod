@@ -5,8 +5,10 @@ go 1.21
 toolchain go1.22.1
 
 require (
+	exam/pkg v0.0.0
 	github.com/Masterminds/squirrel v1.5.4
 	github.com/brianvoe/gofakeit/v6 v6.28.0
+	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/golang/protobuf v1.5.3
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
@@ -39,3 +41,5 @@ require (
 	google.golang.org/protobuf v1.32.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace exam/pkg => ../pkg
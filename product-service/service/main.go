@@ -5,6 +5,8 @@ import (
 	"exam/product-service/config"
 	pb "exam/product-service/genproto/product-service"
 	// "exam/product-service/pkg/db"
+	"exam/pkg/auth"
+	"exam/pkg/tlsconfig"
 	"exam/product-service/pkg/logger"
 	grpcClient2 "exam/product-service/service/grpc_client"
 	"exam/product-service/service/service"
@@ -44,8 +46,47 @@ func New(cfg *config.Config, log logger.Logger) (*Service, error) {
 	return &Service{ProductService: service.NewProductService(storage, log, grpcClient)}, nil
 }
 
+// rpcRoles are the roles the caller needs for each rpc, roles inherit the
+// ones below them on the gateway, so "unauthorized" lets in every caller
+// the gateway vouches for. Users change the stock only by buying, the
+// gateway checks and takes it on its own behalf
+var rpcRoles = map[string][]string{
+	"/product.ProductService/CreateProduct":                {"admin"},
+	"/product.ProductService/GetProductById":               {"user"},
+	"/product.ProductService/UpdateProduct":                {"admin"},
+	"/product.ProductService/DeleteProduct":                {"admin"},
+	"/product.ProductService/ListProducts":                 {"unauthorized"},
+	"/product.ProductService/IncreaseProductAmount":        {"admin"},
+	"/product.ProductService/DecreaseProductAmount":        {"admin", auth.RoleGateway},
+	"/product.ProductService/CheckAmount":                  {"admin", auth.RoleGateway},
+	"/product.ProductService/BuyProduct":                   {"user"},
+	"/product.ProductService/GetPurchasedProductsByUserId": {"user"},
+}
+
 func (s *Service) Run(log logger.Logger, cfg *config.Config) {
-	server := grpc.NewServer()
+	if cfg.ServiceTokenKey == "" {
+		log.Fatal("service token key is required")
+		return
+	}
+
+	// only the gateway can sign caller tokens, reaching the port is not enough
+	authorizer := auth.NewAuthorizer(cfg.ServiceTokenKey, "product-service", rpcRoles, log)
+	creds, err := tlsconfig.ServerCredentials(context.Background(), tlsconfig.Files{
+		CertFile: cfg.TLSCertFile,
+		KeyFile:  cfg.TLSKeyFile,
+		CAFile:   cfg.TLSCAFile,
+		Disabled: cfg.TLSDisabled,
+	}, log)
+	if err != nil {
+		log.Fatal("cannot load tls files", logger.Error(err))
+		return
+	}
+
+	server := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(authorizer.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(authorizer.StreamInterceptor()),
+	)
 
 	pb.RegisterProductServiceServer(server, s.ProductService)
 
@@ -8,16 +8,23 @@ import (
 
 // Config ...
 type Config struct {
-	Environment        string // develop, staging, production
-	PostgresHost       string
-	PostgresPort       int
-	PostgresDatabase   string
-	PostgresUser       string
-	PostgresPassword   string
-	LogLevel           string
-	RPCPort            string
-	ProductServiceHost string
-	ProductServicePort int
+	Environment          string // develop, staging, production
+	PostgresHost         string
+	PostgresPort         int
+	PostgresDatabase     string
+	PostgresUser         string
+	PostgresPassword     string
+	LogLevel             string
+	RPCPort              string
+	ServiceTokenKey      string //verifies the caller tokens the gateway signs for this service alone
+	TLSDisabled          bool   //grpc only runs in plaintext when set
+	TLSCertFile          string
+	TLSKeyFile           string
+	TLSCAFile            string //verifies the certificates of the clients and of the product service
+	ProductServiceHost   string
+	ProductServicePort   int
+	DeletedUserRetention int //days deleted users can be restored before they are purged, 0 keeps them
+	PurgeInterval        int //minutes
 }
 
 func Load() *Config {
@@ -36,15 +43,25 @@ func Load() *Config {
 	c.RPCPort = cast.ToString(getOrReturnDefault("RPC_PORT", ":8081"))
 
 	c.ProductServiceHost = cast.ToString(getOrReturnDefault("POST_SERVICE_HOST", "productservice"))
-	c.ProductServicePort = cast.ToInt(getOrReturnDefault("POST_SERVICE_HOST", "5050"))
+	c.ProductServicePort = cast.ToInt(getOrReturnDefault("POST_SERVICE_PORT", "5050"))
+
+	c.ServiceTokenKey = cast.ToString(getOrReturnDefault("SERVICE_TOKEN_KEY", ""))
+
+	c.DeletedUserRetention = cast.ToInt(getOrReturnDefault("DELETED_USER_RETENTION_DAYS", 30))
+	c.PurgeInterval = cast.ToInt(getOrReturnDefault("PURGE_INTERVAL", 60))
+
+	c.TLSDisabled = cast.ToBool(getOrReturnDefault("TLS_DISABLED", false))
+	c.TLSCertFile = cast.ToString(getOrReturnDefault("GRPC_TLS_CERT", ""))
+	c.TLSKeyFile = cast.ToString(getOrReturnDefault("GRPC_TLS_KEY", ""))
+	c.TLSCAFile = cast.ToString(getOrReturnDefault("GRPC_TLS_CA", ""))
 
 	return &c
 }
 
 func getOrReturnDefault(key string, defaultValue interface{}) interface{} {
-	_, exists := os.LookupEnv(key)
+	value, exists := os.LookupEnv(key)
 	if exists {
-		return defaultValue
+		return value
 	}
 
 	return defaultValue
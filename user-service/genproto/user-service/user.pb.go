@@ -37,6 +37,7 @@ type User struct {
 	CreatedAt            string   `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at"`
 	UpdatedAt            string   `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at"`
 	DeletedAt            string   `protobuf:"bytes,10,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at"`
+	Locale               string   `protobuf:"bytes,11,opt,name=locale,proto3" json:"locale"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -145,6 +146,13 @@ func (m *User) GetDeletedAt() string {
 	return ""
 }
 
+func (m *User) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
 type GetUserId struct {
 	UserId               string   `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -195,6 +203,7 @@ func (m *GetUserId) GetUserId() string {
 type GetListRequest struct {
 	Page                 int32    `protobuf:"varint,1,opt,name=page,proto3" json:"page"`
 	Limit                int32    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit"`
+	IncludeDeleted       bool     `protobuf:"varint,3,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -247,6 +256,13 @@ func (m *GetListRequest) GetLimit() int32 {
 	return 0
 }
 
+func (m *GetListRequest) GetIncludeDeleted() bool {
+	if m != nil {
+		return m.IncludeDeleted
+	}
+	return false
+}
+
 type GetListResponse struct {
 	Count                int64    `protobuf:"varint,1,opt,name=count,proto3" json:"count"`
 	Users                []*User  `protobuf:"bytes,2,rep,name=users,proto3" json:"users"`
@@ -553,6 +569,179 @@ func (m *UpdateRefreshTokenReq) GetRefreshToken() string {
 	return ""
 }
 
+type UpdatePasswordReq struct {
+	UserId               string   `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id"`
+	Password             string   `protobuf:"bytes,2,opt,name=password,proto3" json:"password"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdatePasswordReq) Reset()         { *m = UpdatePasswordReq{} }
+func (m *UpdatePasswordReq) String() string { return proto.CompactTextString(m) }
+func (*UpdatePasswordReq) ProtoMessage()    {}
+func (*UpdatePasswordReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5fe9d1857265efb6, []int{9}
+}
+func (m *UpdatePasswordReq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UpdatePasswordReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UpdatePasswordReq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *UpdatePasswordReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdatePasswordReq.Merge(m, src)
+}
+func (m *UpdatePasswordReq) XXX_Size() int {
+	return m.Size()
+}
+func (m *UpdatePasswordReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdatePasswordReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdatePasswordReq proto.InternalMessageInfo
+
+func (m *UpdatePasswordReq) GetUserId() string {
+	if m != nil {
+		return m.UserId
+	}
+	return ""
+}
+
+func (m *UpdatePasswordReq) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+type ChangePasswordReq struct {
+	UserId               string   `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id"`
+	CurrentPassword      string   `protobuf:"bytes,2,opt,name=current_password,json=currentPassword,proto3" json:"current_password"`
+	NewPassword          string   `protobuf:"bytes,3,opt,name=new_password,json=newPassword,proto3" json:"new_password"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChangePasswordReq) Reset()         { *m = ChangePasswordReq{} }
+func (m *ChangePasswordReq) String() string { return proto.CompactTextString(m) }
+func (*ChangePasswordReq) ProtoMessage()    {}
+func (*ChangePasswordReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5fe9d1857265efb6, []int{10}
+}
+func (m *ChangePasswordReq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ChangePasswordReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ChangePasswordReq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ChangePasswordReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChangePasswordReq.Merge(m, src)
+}
+func (m *ChangePasswordReq) XXX_Size() int {
+	return m.Size()
+}
+func (m *ChangePasswordReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChangePasswordReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChangePasswordReq proto.InternalMessageInfo
+
+func (m *ChangePasswordReq) GetUserId() string {
+	if m != nil {
+		return m.UserId
+	}
+	return ""
+}
+
+func (m *ChangePasswordReq) GetCurrentPassword() string {
+	if m != nil {
+		return m.CurrentPassword
+	}
+	return ""
+}
+
+func (m *ChangePasswordReq) GetNewPassword() string {
+	if m != nil {
+		return m.NewPassword
+	}
+	return ""
+}
+
+type UpdateEmailReq struct {
+	UserId               string   `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id"`
+	Email                string   `protobuf:"bytes,2,opt,name=email,proto3" json:"email"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateEmailReq) Reset()         { *m = UpdateEmailReq{} }
+func (m *UpdateEmailReq) String() string { return proto.CompactTextString(m) }
+func (*UpdateEmailReq) ProtoMessage()    {}
+func (*UpdateEmailReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5fe9d1857265efb6, []int{11}
+}
+func (m *UpdateEmailReq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UpdateEmailReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UpdateEmailReq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *UpdateEmailReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateEmailReq.Merge(m, src)
+}
+func (m *UpdateEmailReq) XXX_Size() int {
+	return m.Size()
+}
+func (m *UpdateEmailReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateEmailReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateEmailReq proto.InternalMessageInfo
+
+func (m *UpdateEmailReq) GetUserId() string {
+	if m != nil {
+		return m.UserId
+	}
+	return ""
+}
+
+func (m *UpdateEmailReq) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*User)(nil), "user.User")
 	proto.RegisterType((*GetUserId)(nil), "user.GetUserId")
@@ -563,49 +752,61 @@ func init() {
 	proto.RegisterType((*Status)(nil), "user.Status")
 	proto.RegisterType((*IfExists)(nil), "user.IfExists")
 	proto.RegisterType((*UpdateRefreshTokenReq)(nil), "user.UpdateRefreshTokenReq")
+	proto.RegisterType((*UpdatePasswordReq)(nil), "user.UpdatePasswordReq")
+	proto.RegisterType((*ChangePasswordReq)(nil), "user.ChangePasswordReq")
+	proto.RegisterType((*UpdateEmailReq)(nil), "user.UpdateEmailReq")
 }
 
 func init() { proto.RegisterFile("user-service/user.proto", fileDescriptor_5fe9d1857265efb6) }
 
 var fileDescriptor_5fe9d1857265efb6 = []byte{
-	// 582 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x54, 0xcd, 0x6e, 0xd3, 0x5c,
-	0x10, 0x8d, 0xed, 0x38, 0x8d, 0x27, 0xfd, 0xd2, 0x7e, 0xa3, 0x94, 0x58, 0xa9, 0x88, 0xa2, 0x0b,
-	0x54, 0x59, 0xb4, 0x45, 0x2a, 0xbb, 0x4a, 0x2c, 0xd2, 0x02, 0x55, 0x24, 0xc4, 0xc2, 0x25, 0xeb,
-	0xc8, 0xd8, 0x13, 0x6a, 0x35, 0x89, 0x53, 0xdf, 0x6b, 0x7e, 0xde, 0xa4, 0x7b, 0x5e, 0x86, 0x25,
-	0x8f, 0x80, 0xc2, 0x8b, 0xa0, 0xfb, 0xe3, 0xd4, 0x8a, 0x41, 0xec, 0x66, 0xce, 0x39, 0x9e, 0xcc,
-	0x9d, 0x73, 0x14, 0xe8, 0xe6, 0x9c, 0xb2, 0x13, 0x4e, 0xd9, 0xa7, 0x24, 0xa2, 0xe7, 0xb2, 0x39,
-	0x5d, 0x65, 0xa9, 0x48, 0xb1, 0x2e, 0x6b, 0x76, 0x6f, 0x43, 0x7d, 0xc2, 0x29, 0xc3, 0x36, 0xd8,
-	0x49, 0xec, 0x5b, 0x03, 0x6b, 0xe8, 0x05, 0x76, 0x12, 0xe3, 0x63, 0x80, 0x59, 0x92, 0x71, 0x31,
-	0x5d, 0x86, 0x0b, 0xf2, 0x6d, 0x85, 0x7b, 0x0a, 0x79, 0x17, 0x2e, 0x08, 0x0f, 0xc1, 0x9b, 0x87,
-	0x05, 0xeb, 0x28, 0xb6, 0x29, 0x01, 0x45, 0xee, 0x83, 0x13, 0x7e, 0x24, 0xbf, 0x3e, 0xb0, 0x86,
-	0x4e, 0x20, 0x4b, 0xec, 0x80, 0x4b, 0x8b, 0x30, 0x99, 0xfb, 0xae, 0x92, 0xea, 0x06, 0x7b, 0xd0,
-	0x5c, 0x85, 0x9c, 0x7f, 0x4e, 0xb3, 0xd8, 0x6f, 0xe8, 0x19, 0x45, 0x8f, 0x4f, 0xe0, 0xbf, 0x8c,
-	0x66, 0x19, 0xf1, 0x9b, 0xa9, 0x48, 0x6f, 0x69, 0xe9, 0xef, 0x28, 0xc1, 0xae, 0x01, 0xdf, 0x4b,
-	0x4c, 0x2e, 0x19, 0x65, 0x14, 0x0a, 0x8a, 0xa7, 0xa1, 0xf0, 0x9b, 0x7a, 0x49, 0x83, 0x8c, 0x84,
-	0xa4, 0xf3, 0x55, 0x5c, 0xd0, 0x9e, 0xa6, 0x0d, 0xa2, 0xe9, 0x98, 0xe6, 0x64, 0x68, 0xd0, 0xb4,
-	0x41, 0x46, 0x82, 0x3d, 0x05, 0xef, 0x8a, 0x84, 0x3c, 0xce, 0x38, 0xc6, 0x2e, 0xec, 0xc8, 0x7b,
-	0x4d, 0x37, 0x37, 0x6a, 0xe4, 0x8a, 0x60, 0xe7, 0xd0, 0xbe, 0x22, 0xf1, 0x36, 0xe1, 0x22, 0xa0,
-	0xbb, 0x9c, 0xb8, 0x40, 0x84, 0xfa, 0x4a, 0x3e, 0x5f, 0xea, 0xdc, 0x40, 0xd5, 0xf2, 0xfd, 0xf3,
-	0x64, 0x91, 0x08, 0x75, 0x48, 0x37, 0xd0, 0x0d, 0x1b, 0xc3, 0xde, 0xe6, 0x5b, 0xbe, 0x4a, 0x97,
-	0x5c, 0x09, 0xa3, 0x34, 0x5f, 0x0a, 0xf5, 0xb5, 0x13, 0xe8, 0x06, 0x07, 0xe0, 0xca, 0x9f, 0xe3,
-	0xbe, 0x3d, 0x70, 0x86, 0xad, 0x33, 0x38, 0x55, 0x3e, 0xca, 0xd5, 0x02, 0x4d, 0xb0, 0x97, 0xf0,
-	0xff, 0xe5, 0x0d, 0x45, 0xb7, 0x6f, 0x12, 0x9a, 0xc7, 0xc5, 0x26, 0x1d, 0x70, 0x67, 0xb2, 0x37,
-	0x2b, 0xeb, 0x46, 0xee, 0x17, 0x87, 0x22, 0x34, 0x9e, 0xaa, 0x9a, 0x1d, 0x03, 0x96, 0x3f, 0x37,
-	0xcb, 0x3c, 0x82, 0x06, 0x17, 0xa1, 0xc8, 0xb9, 0x1a, 0xd0, 0x0c, 0x4c, 0xc7, 0x18, 0x34, 0xae,
-	0x55, 0x85, 0x3e, 0xec, 0xf0, 0x3c, 0x8a, 0x88, 0x17, 0x92, 0xa2, 0x65, 0x03, 0x68, 0x8e, 0x67,
-	0xaf, 0xbf, 0x24, 0x5c, 0xf0, 0x07, 0xf7, 0xad, 0x92, 0xfb, 0x6c, 0x02, 0x07, 0x13, 0xe5, 0x45,
-	0x50, 0xb2, 0x34, 0xa0, 0xbb, 0xbf, 0xde, 0xba, 0x9a, 0x09, 0xbb, 0x9a, 0x89, 0xb3, 0x6f, 0x0e,
-	0xb4, 0xe4, 0x65, 0xae, 0x75, 0xe4, 0xf1, 0x08, 0xe0, 0x52, 0x25, 0x42, 0xc5, 0xbc, 0x74, 0xba,
-	0x5e, 0xa9, 0x66, 0x35, 0x3c, 0x86, 0x96, 0xb1, 0xfb, 0xe2, 0xeb, 0x38, 0xc6, 0x3d, 0x4d, 0x6e,
-	0x12, 0xb0, 0xa5, 0x3e, 0x02, 0xd0, 0xcb, 0xff, 0x63, 0xea, 0x09, 0xc0, 0x2b, 0x95, 0x28, 0xa5,
-	0xab, 0x0c, 0xdd, 0xd5, 0x80, 0xbe, 0x26, 0xab, 0xe1, 0x39, 0x78, 0x32, 0x0e, 0x92, 0xe5, 0xd8,
-	0xd9, 0xa8, 0x4b, 0xf1, 0xea, 0x1d, 0x6c, 0xa1, 0xda, 0x2b, 0x56, 0xc3, 0x11, 0xc0, 0x83, 0x87,
-	0xd8, 0xd5, 0xb2, 0x4a, 0x28, 0x7a, 0x7e, 0x95, 0xd8, 0x8c, 0x78, 0x06, 0xae, 0xc2, 0xb1, 0xad,
-	0x45, 0x85, 0x83, 0x5b, 0x8f, 0x1a, 0x01, 0x56, 0x9d, 0xc3, 0x43, 0xa3, 0xf9, 0x93, 0xa7, 0xdb,
-	0x0f, 0xbd, 0xd8, 0xff, 0xbe, 0xee, 0x5b, 0x3f, 0xd6, 0x7d, 0xeb, 0xe7, 0xba, 0x6f, 0xdd, 0xff,
-	0xea, 0xd7, 0x3e, 0x34, 0xd4, 0xdf, 0xd2, 0x8b, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x91, 0x7a,
-	0xff, 0xa5, 0xb1, 0x04, 0x00, 0x00,
+	// 736 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xdd, 0x4e, 0xdb, 0x4a,
+	0x10, 0x4e, 0x9c, 0x1f, 0x92, 0x09, 0x27, 0x81, 0x15, 0x10, 0x2b, 0xe8, 0x44, 0x39, 0x7b, 0xce,
+	0xa1, 0x54, 0x02, 0x2a, 0xc1, 0x55, 0x2b, 0x55, 0x55, 0xa0, 0x94, 0x46, 0xaa, 0xaa, 0xca, 0x94,
+	0xeb, 0x68, 0x6b, 0x4f, 0xc0, 0xc2, 0xb1, 0x83, 0x77, 0x5d, 0xda, 0x3e, 0x49, 0x1f, 0xa3, 0x8f,
+	0xd1, 0xcb, 0x3e, 0x42, 0x45, 0x5f, 0xa3, 0x17, 0xd5, 0xfe, 0x38, 0x71, 0x62, 0xa0, 0xbd, 0xdb,
+	0xf9, 0xbe, 0xd9, 0xf1, 0xcc, 0xb7, 0xdf, 0x24, 0xd0, 0x4e, 0x38, 0xc6, 0xbb, 0x1c, 0xe3, 0xf7,
+	0xbe, 0x8b, 0x8f, 0x64, 0xb0, 0x37, 0x89, 0x23, 0x11, 0x91, 0xb2, 0x3c, 0xd3, 0x2f, 0x16, 0x94,
+	0xcf, 0x38, 0xc6, 0xa4, 0x09, 0x96, 0xef, 0xd9, 0xc5, 0x5e, 0x71, 0xbb, 0xee, 0x58, 0xbe, 0x47,
+	0xfe, 0x06, 0x18, 0xf9, 0x31, 0x17, 0xc3, 0x90, 0x8d, 0xd1, 0xb6, 0x14, 0x5e, 0x57, 0xc8, 0x6b,
+	0x36, 0x46, 0xb2, 0x09, 0xf5, 0x80, 0xa5, 0x6c, 0x49, 0xb1, 0xb5, 0x80, 0x19, 0x72, 0x05, 0x4a,
+	0xec, 0x1c, 0xed, 0x72, 0xaf, 0xb8, 0x5d, 0x72, 0xe4, 0x91, 0xac, 0x41, 0x05, 0xc7, 0xcc, 0x0f,
+	0xec, 0x8a, 0x4a, 0xd5, 0x01, 0xe9, 0x40, 0x6d, 0xc2, 0x38, 0xbf, 0x8e, 0x62, 0xcf, 0xae, 0xea,
+	0x1a, 0x69, 0x4c, 0xfe, 0x85, 0xbf, 0x62, 0x1c, 0xc5, 0xc8, 0x2f, 0x86, 0x22, 0xba, 0xc4, 0xd0,
+	0x5e, 0x52, 0x09, 0xcb, 0x06, 0x7c, 0x2b, 0x31, 0xd9, 0xa4, 0x1b, 0x23, 0x13, 0xe8, 0x0d, 0x99,
+	0xb0, 0x6b, 0xba, 0x49, 0x83, 0xf4, 0x85, 0xa4, 0x93, 0x89, 0x97, 0xd2, 0x75, 0x4d, 0x1b, 0x44,
+	0xd3, 0x1e, 0x06, 0x68, 0x68, 0xd0, 0xb4, 0x41, 0xfa, 0x82, 0x6c, 0x40, 0x35, 0x88, 0x5c, 0x16,
+	0xa0, 0xdd, 0x50, 0x94, 0x89, 0xe8, 0x7f, 0x50, 0x3f, 0x41, 0x21, 0x45, 0x1b, 0x78, 0xa4, 0x0d,
+	0x4b, 0x52, 0xc7, 0xe1, 0x54, 0xbb, 0x6a, 0xa2, 0x08, 0xea, 0x42, 0xf3, 0x04, 0xc5, 0x2b, 0x9f,
+	0x0b, 0x07, 0xaf, 0x12, 0xe4, 0x82, 0x10, 0x28, 0x4f, 0xa4, 0x2c, 0x32, 0xaf, 0xe2, 0x94, 0x27,
+	0x46, 0x97, 0xc0, 0x1f, 0xfb, 0x42, 0x09, 0x5c, 0x71, 0x74, 0x40, 0x1e, 0x40, 0xcb, 0x0f, 0xdd,
+	0x20, 0xf1, 0x70, 0x68, 0xda, 0x51, 0x12, 0xd7, 0x9c, 0xa6, 0x81, 0x9f, 0x6b, 0x94, 0x0e, 0xa0,
+	0x35, 0xfd, 0x08, 0x9f, 0x44, 0x21, 0x57, 0x15, 0xdd, 0x28, 0x09, 0x85, 0xfa, 0x4c, 0xc9, 0xd1,
+	0x01, 0xe9, 0x41, 0x45, 0xf6, 0xc5, 0x6d, 0xab, 0x57, 0xda, 0x6e, 0xec, 0xc3, 0x9e, 0x8c, 0xf6,
+	0xe4, 0x0c, 0x8e, 0x26, 0xe8, 0x53, 0x58, 0x3d, 0xba, 0x40, 0xf7, 0xf2, 0x85, 0x8f, 0x81, 0x97,
+	0xb6, 0xbc, 0x06, 0x95, 0x91, 0x8c, 0xcd, 0x6c, 0x3a, 0x90, 0x83, 0x78, 0x4c, 0x30, 0x63, 0x0a,
+	0x75, 0xa6, 0x3b, 0x40, 0xb2, 0xd7, 0x4d, 0x33, 0x1b, 0x50, 0xe5, 0x82, 0x89, 0x84, 0xab, 0x02,
+	0x35, 0xc7, 0x44, 0x94, 0x42, 0xf5, 0x54, 0x9d, 0x88, 0x0d, 0x4b, 0x3c, 0x71, 0x5d, 0xe4, 0x69,
+	0x4a, 0x1a, 0xd2, 0x1e, 0xd4, 0x06, 0xa3, 0xe3, 0x0f, 0x3e, 0x17, 0x7c, 0x66, 0x9f, 0x62, 0xc6,
+	0x3e, 0xf4, 0x0c, 0xd6, 0xcf, 0xd4, 0x63, 0x3a, 0x19, 0x4f, 0x38, 0x78, 0x75, 0xe7, 0xa3, 0xe4,
+	0x4d, 0x65, 0xe5, 0x4d, 0x45, 0x5f, 0xc2, 0xaa, 0x2e, 0xfb, 0xc6, 0x78, 0xf1, 0xde, 0x92, 0x59,
+	0x0f, 0x5b, 0xf3, 0x1e, 0xa6, 0x9f, 0xa4, 0xa6, 0x2c, 0x3c, 0xff, 0xb3, 0x4a, 0x0f, 0x61, 0xc5,
+	0x4d, 0xe2, 0x18, 0x43, 0x31, 0x5c, 0xa8, 0xd8, 0x32, 0x78, 0x5a, 0x86, 0xfc, 0x03, 0xcb, 0x21,
+	0x5e, 0xcf, 0xd2, 0xf4, 0x02, 0x36, 0x42, 0xbc, 0x4e, 0x53, 0xe8, 0x33, 0x68, 0xea, 0x29, 0x8e,
+	0xa5, 0x56, 0xf7, 0x7e, 0x78, 0xaa, 0xae, 0x95, 0x51, 0x77, 0xff, 0x67, 0x19, 0x1a, 0xd2, 0x20,
+	0xa7, 0xfa, 0xa7, 0x83, 0x6c, 0x01, 0x1c, 0xa9, 0xcd, 0x92, 0x20, 0xc9, 0x38, 0xa8, 0x93, 0x39,
+	0xd3, 0x02, 0xd9, 0x81, 0x86, 0x59, 0x8f, 0xc3, 0x8f, 0x03, 0x8f, 0xb4, 0x34, 0x39, 0xdd, 0x98,
+	0x85, 0xec, 0x2d, 0x00, 0xdd, 0xe6, 0x6f, 0xaa, 0xee, 0x02, 0x68, 0xd3, 0xab, 0xbc, 0x5c, 0xd1,
+	0x65, 0x0d, 0x68, 0x53, 0xd1, 0x02, 0x79, 0x02, 0x75, 0xb9, 0x15, 0x92, 0xe5, 0x64, 0x6d, 0x9a,
+	0x9d, 0x59, 0xc7, 0xce, 0xfa, 0x02, 0xaa, 0x2d, 0x4b, 0x0b, 0xa4, 0x0f, 0x30, 0xb3, 0x32, 0x69,
+	0xeb, 0xb4, 0xdc, 0x6e, 0x74, 0xec, 0x3c, 0x31, 0x2d, 0xf1, 0x3f, 0x54, 0x14, 0x4e, 0x9a, 0x3a,
+	0x29, 0x35, 0xf2, 0xc2, 0x50, 0x7d, 0x20, 0x79, 0x03, 0x93, 0x4d, 0x93, 0x73, 0x9b, 0xb5, 0x73,
+	0x83, 0x3e, 0x4e, 0x9f, 0x79, 0xea, 0x8d, 0x76, 0xf6, 0x7a, 0xc6, 0x78, 0xb7, 0x5d, 0x9d, 0x77,
+	0xe7, 0x6c, 0xd6, 0x05, 0xcf, 0xe6, 0xae, 0x1e, 0x40, 0x23, 0x63, 0xae, 0x54, 0xe0, 0x79, 0xbf,
+	0xe5, 0x2e, 0xed, 0x40, 0xc3, 0x41, 0x2e, 0xa2, 0xf8, 0x8e, 0x37, 0x9c, 0xd3, 0xe6, 0x70, 0xe5,
+	0xeb, 0x4d, 0xb7, 0xf8, 0xed, 0xa6, 0x5b, 0xfc, 0x7e, 0xd3, 0x2d, 0x7e, 0xfe, 0xd1, 0x2d, 0xbc,
+	0xab, 0xaa, 0xff, 0xad, 0x83, 0x5f, 0x03, 0x00, 0x26, 0x5a, 0x6d, 0x6b, 0xd2, 0x06, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -628,6 +829,10 @@ type UserServiceClient interface {
 	CheckField(ctx context.Context, in *CheckFieldRequest, opts ...grpc.CallOption) (*CheckFieldResponse, error)
 	Check(ctx context.Context, in *IfExists, opts ...grpc.CallOption) (*User, error)
 	UpdateRefreshToken(ctx context.Context, in *UpdateRefreshTokenReq, opts ...grpc.CallOption) (*Status, error)
+	UpdatePassword(ctx context.Context, in *UpdatePasswordReq, opts ...grpc.CallOption) (*Status, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordReq, opts ...grpc.CallOption) (*Status, error)
+	UpdateEmail(ctx context.Context, in *UpdateEmailReq, opts ...grpc.CallOption) (*Status, error)
+	RestoreUser(ctx context.Context, in *GetUserId, opts ...grpc.CallOption) (*User, error)
 }
 
 type userServiceClient struct {
@@ -710,6 +915,42 @@ func (c *userServiceClient) UpdateRefreshToken(ctx context.Context, in *UpdateRe
 	return out, nil
 }
 
+func (c *userServiceClient) UpdatePassword(ctx context.Context, in *UpdatePasswordReq, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, "/user.UserService/UpdatePassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ChangePassword(ctx context.Context, in *ChangePasswordReq, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, "/user.UserService/ChangePassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UpdateEmail(ctx context.Context, in *UpdateEmailReq, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, "/user.UserService/UpdateEmail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RestoreUser(ctx context.Context, in *GetUserId, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/user.UserService/RestoreUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 type UserServiceServer interface {
 	CreateUser(context.Context, *User) (*User, error)
@@ -720,6 +961,10 @@ type UserServiceServer interface {
 	CheckField(context.Context, *CheckFieldRequest) (*CheckFieldResponse, error)
 	Check(context.Context, *IfExists) (*User, error)
 	UpdateRefreshToken(context.Context, *UpdateRefreshTokenReq) (*Status, error)
+	UpdatePassword(context.Context, *UpdatePasswordReq) (*Status, error)
+	ChangePassword(context.Context, *ChangePasswordReq) (*Status, error)
+	UpdateEmail(context.Context, *UpdateEmailReq) (*Status, error)
+	RestoreUser(context.Context, *GetUserId) (*User, error)
 }
 
 // UnimplementedUserServiceServer can be embedded to have forward compatible implementations.
@@ -750,6 +995,18 @@ func (*UnimplementedUserServiceServer) Check(ctx context.Context, req *IfExists)
 func (*UnimplementedUserServiceServer) UpdateRefreshToken(ctx context.Context, req *UpdateRefreshTokenReq) (*Status, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateRefreshToken not implemented")
 }
+func (*UnimplementedUserServiceServer) UpdatePassword(ctx context.Context, req *UpdatePasswordReq) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePassword not implemented")
+}
+func (*UnimplementedUserServiceServer) ChangePassword(ctx context.Context, req *ChangePasswordReq) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (*UnimplementedUserServiceServer) UpdateEmail(ctx context.Context, req *UpdateEmailReq) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateEmail not implemented")
+}
+func (*UnimplementedUserServiceServer) RestoreUser(ctx context.Context, req *GetUserId) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreUser not implemented")
+}
 
 func RegisterUserServiceServer(s *grpc.Server, srv UserServiceServer) {
 	s.RegisterService(&_UserService_serviceDesc, srv)
@@ -899,6 +1156,78 @@ func _UserService_UpdateRefreshToken_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_UpdatePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePasswordReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UpdatePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserService/UpdatePassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UpdatePassword(ctx, req.(*UpdatePasswordReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserService/ChangePassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ChangePassword(ctx, req.(*ChangePasswordReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UpdateEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEmailReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UpdateEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserService/UpdateEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UpdateEmail(ctx, req.(*UpdateEmailReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RestoreUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RestoreUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserService/RestoreUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RestoreUser(ctx, req.(*GetUserId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _UserService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "user.UserService",
 	HandlerType: (*UserServiceServer)(nil),
@@ -935,6 +1264,22 @@ var _UserService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateRefreshToken",
 			Handler:    _UserService_UpdateRefreshToken_Handler,
 		},
+		{
+			MethodName: "UpdatePassword",
+			Handler:    _UserService_UpdatePassword_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _UserService_ChangePassword_Handler,
+		},
+		{
+			MethodName: "UpdateEmail",
+			Handler:    _UserService_UpdateEmail_Handler,
+		},
+		{
+			MethodName: "RestoreUser",
+			Handler:    _UserService_RestoreUser_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "user-service/user.proto",
@@ -964,6 +1309,13 @@ func (m *User) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.Locale) > 0 {
+		i -= len(m.Locale)
+		copy(dAtA[i:], m.Locale)
+		i = encodeVarintUser(dAtA, i, uint64(len(m.Locale)))
+		i--
+		dAtA[i] = 0x5a
+	}
 	if len(m.DeletedAt) > 0 {
 		i -= len(m.DeletedAt)
 		copy(dAtA[i:], m.DeletedAt)
@@ -1093,6 +1445,16 @@ func (m *GetListRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.IncludeDeleted {
+		i--
+		if m.IncludeDeleted {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
 	if m.Limit != 0 {
 		i = encodeVarintUser(dAtA, i, uint64(m.Limit))
 		i--
@@ -1342,26 +1704,156 @@ func (m *UpdateRefreshTokenReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintUser(dAtA []byte, offset int, v uint64) int {
-	offset -= sovUser(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *UpdatePasswordReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *User) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *UpdatePasswordReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UpdatePasswordReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Id)
-	if l > 0 {
-		n += 1 + l + sovUser(uint64(l))
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Password) > 0 {
+		i -= len(m.Password)
+		copy(dAtA[i:], m.Password)
+		i = encodeVarintUser(dAtA, i, uint64(len(m.Password)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintUser(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ChangePasswordReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ChangePasswordReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ChangePasswordReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.NewPassword) > 0 {
+		i -= len(m.NewPassword)
+		copy(dAtA[i:], m.NewPassword)
+		i = encodeVarintUser(dAtA, i, uint64(len(m.NewPassword)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.CurrentPassword) > 0 {
+		i -= len(m.CurrentPassword)
+		copy(dAtA[i:], m.CurrentPassword)
+		i = encodeVarintUser(dAtA, i, uint64(len(m.CurrentPassword)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintUser(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UpdateEmailReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UpdateEmailReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UpdateEmailReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Email) > 0 {
+		i -= len(m.Email)
+		copy(dAtA[i:], m.Email)
+		i = encodeVarintUser(dAtA, i, uint64(len(m.Email)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintUser(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintUser(dAtA []byte, offset int, v uint64) int {
+	offset -= sovUser(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *User) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Id)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
 	}
 	l = len(m.FirstName)
 	if l > 0 {
@@ -1398,6 +1890,10 @@ func (m *User) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovUser(uint64(l))
 	}
+	l = len(m.Locale)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1432,6 +1928,9 @@ func (m *GetListRequest) Size() (n int) {
 	if m.Limit != 0 {
 		n += 1 + sovUser(uint64(m.Limit))
 	}
+	if m.IncludeDeleted {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1545,6 +2044,70 @@ func (m *UpdateRefreshTokenReq) Size() (n int) {
 	return n
 }
 
+func (m *UpdatePasswordReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
+	}
+	l = len(m.Password)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ChangePasswordReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
+	}
+	l = len(m.CurrentPassword)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
+	}
+	l = len(m.NewPassword)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *UpdateEmailReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
+	}
+	l = len(m.Email)
+	if l > 0 {
+		n += 1 + l + sovUser(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovUser(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1887,6 +2450,38 @@ func (m *User) Unmarshal(dAtA []byte) error {
 			}
 			m.DeletedAt = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Locale", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthUser
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthUser
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Locale = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipUser(dAtA[iNdEx:])
@@ -2059,6 +2654,26 @@ func (m *GetListRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IncludeDeleted", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IncludeDeleted = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipUser(dAtA[iNdEx:])
@@ -2640,6 +3255,383 @@ func (m *UpdateRefreshTokenReq) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *UpdatePasswordReq) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowUser
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UpdatePasswordReq: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UpdatePasswordReq: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthUser
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthUser
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Password", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthUser
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthUser
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Password = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipUser(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthUser
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ChangePasswordReq) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowUser
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ChangePasswordReq: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ChangePasswordReq: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthUser
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthUser
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CurrentPassword", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthUser
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthUser
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CurrentPassword = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NewPassword", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthUser
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthUser
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.NewPassword = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipUser(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthUser
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *UpdateEmailReq) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowUser
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UpdateEmailReq: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UpdateEmailReq: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthUser
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthUser
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Email", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowUser
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthUser
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthUser
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Email = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipUser(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthUser
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipUser(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
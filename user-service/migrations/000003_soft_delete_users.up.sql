@@ -0,0 +1,5 @@
+-- the partial index idx_unique_email keeps emails unique among users that are not deleted,
+-- the constraint made a deleted user's email unusable until the row was purged
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -1,12 +1,13 @@
 package grpcClient
 
 import (
+	"context"
+	"exam/pkg/tlsconfig"
 	"exam/user-service/config"
 	pbp "exam/user-service/genproto/product-service"
 	"exam/user-service/pkg/logger"
 	"fmt"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"log"
 )
 
@@ -19,10 +20,21 @@ type serviceManager struct {
 	productService pbp.ProductServiceClient
 }
 
-func New(cfg config.Config) (IServiceManager, error) {
+func New(cfg config.Config, l logger.Logger) (IServiceManager, error) {
+	// the same certificate is presented to the product service as a client
+	creds, err := tlsconfig.ClientCredentials(context.Background(), tlsconfig.Files{
+		CertFile: cfg.TLSCertFile,
+		KeyFile:  cfg.TLSKeyFile,
+		CAFile:   cfg.TLSCAFile,
+		Disabled: cfg.TLSDisabled,
+	}, l)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load tls files: %w", err)
+	}
+
 	connProduct, err := grpc.Dial(
 		fmt.Sprintf("%s:%d", cfg.ProductServiceHost, cfg.ProductServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+		grpc.WithTransportCredentials(creds))
 	if err != nil {
 		log.Fatal("error while dialing to the product service", logger.Error(err))
 	}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"exam/pkg/auth"
+	"exam/pkg/tlsconfig"
 	"exam/user-service/config"
 	pb "exam/user-service/genproto/user-service"
 	"exam/user-service/pkg/logger"
@@ -10,6 +12,7 @@ import (
 	storage2 "exam/user-service/storage"
 	"fmt"
 	"net"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -34,16 +37,57 @@ func New(cfg *config.Config, log logger.Logger) (*Service, error) {
 
 	collection := client.Database("userdb").Collection("users")
 	storage := storage2.New(collection, log)
-	grpcClient, err := grpcClient2.New(*cfg)
+	grpcClient, err := grpcClient2.New(*cfg, log)
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to grpc client:%v", err.Error())
 	}
 
 	return &Service{UserService: service.NewUserService(storage, log, grpcClient)}, nil
-} 
+}
+
+// rpcRoles are the roles the caller needs for each rpc, roles inherit the
+// ones below them on the gateway, so "unauthorized" lets in every caller
+// the gateway vouches for. The rpcs writing credentials or returning the
+// password hash are only called by the gateway on its own behalf
+var rpcRoles = map[string][]string{
+	"/user.UserService/CreateUser":         {auth.RoleGateway},
+	"/user.UserService/GetUserById":        {"user", auth.RoleGateway},
+	"/user.UserService/UpdateUser":         {"user"},
+	"/user.UserService/DeleteUser":         {"admin"},
+	"/user.UserService/ListUsers":          {"admin"},
+	"/user.UserService/CheckField":         {"unauthorized"},
+	"/user.UserService/Check":              {auth.RoleGateway},
+	"/user.UserService/UpdateRefreshToken": {auth.RoleGateway},
+	"/user.UserService/UpdatePassword":     {auth.RoleGateway},
+	"/user.UserService/ChangePassword":     {"user"},
+	"/user.UserService/UpdateEmail":        {auth.RoleGateway},
+	"/user.UserService/RestoreUser":        {"admin"},
+}
 
 func (s *Service) Run(log logger.Logger, cfg *config.Config) {
-	server := grpc.NewServer()
+	if cfg.ServiceTokenKey == "" {
+		log.Fatal("service token key is required")
+		return
+	}
+
+	// only the gateway can sign caller tokens, reaching the port is not enough
+	authorizer := auth.NewAuthorizer(cfg.ServiceTokenKey, "user-service", rpcRoles, log)
+	creds, err := tlsconfig.ServerCredentials(context.Background(), tlsconfig.Files{
+		CertFile: cfg.TLSCertFile,
+		KeyFile:  cfg.TLSKeyFile,
+		CAFile:   cfg.TLSCAFile,
+		Disabled: cfg.TLSDisabled,
+	}, log)
+	if err != nil {
+		log.Fatal("cannot load tls files", logger.Error(err))
+		return
+	}
+
+	server := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(authorizer.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(authorizer.StreamInterceptor()),
+	)
 
 	pb.RegisterUserServiceServer(server, s.UserService)
 
@@ -55,6 +99,12 @@ func (s *Service) Run(log logger.Logger, cfg *config.Config) {
 
 	defer logger.Cleanup(log)
 
+	if cfg.DeletedUserRetention > 0 {
+		go s.UserService.PurgeDeletedUsers(context.Background(),
+			time.Hour*24*time.Duration(cfg.DeletedUserRetention),
+			time.Minute*time.Duration(cfg.PurgeInterval))
+	}
+
 	log.Info("main: sqlConfig",
 		logger.String("host", cfg.PostgresHost),
 		logger.Int("port", cfg.PostgresPort),
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"exam/user-service/pkg/logger"
+	"time"
+)
+
+// PurgeDeletedUsers removes the users deleted longer than retention ago
+// every interval, until ctx is done. Deleted users can be restored until then
+func (c *UserService) PurgeDeletedUsers(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := c.storage.UserService().PurgeDeletedUsers(ctx, time.Now().Add(-retention))
+		if err != nil {
+			c.log.Error("cannot purge deleted users", logger.Error(err))
+		} else if purged > 0 {
+			c.log.Info("deleted users purged", logger.Int("count", int(purged)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
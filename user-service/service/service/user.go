@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	pb "exam/user-service/genproto/user-service"
+	"exam/user-service/pkg/etc"
 	"exam/user-service/pkg/logger"
 	grpcClient "exam/user-service/service/grpc_client"
 	"exam/user-service/storage"
+	"exam/user-service/storage/repo"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UserService struct {
@@ -54,3 +60,51 @@ func (c *UserService) Check(ctx context.Context, req *pb.IfExists) (*pb.User, er
 func (c *UserService) UpdateRefreshToken(ctx context.Context, req *pb.UpdateRefreshTokenReq) (*pb.Status, error) {
 	return c.storage.UserService().UpdateRefreshToken(ctx, req)
 }
+
+func (c *UserService) UpdatePassword(ctx context.Context, req *pb.UpdatePasswordReq) (*pb.Status, error) {
+	return c.storage.UserService().UpdatePassword(ctx, req)
+}
+
+// ChangePassword sets a new password only if the current one is correct
+func (c *UserService) ChangePassword(ctx context.Context, req *pb.ChangePasswordReq) (*pb.Status, error) {
+	user, err := c.storage.UserService().GetUserById(ctx, &pb.GetUserId{UserId: req.UserId})
+	if err != nil {
+		c.log.Error("cannot get user by id", logger.Error(err))
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	if !etc.CompareHashPassword(user.Password, req.CurrentPassword) {
+		return nil, status.Error(codes.InvalidArgument, "current password is incorrect")
+	}
+
+	password, err := etc.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.storage.UserService().UpdatePassword(ctx, &pb.UpdatePasswordReq{
+		UserId:   req.UserId,
+		Password: password,
+	})
+}
+
+func (c *UserService) UpdateEmail(ctx context.Context, req *pb.UpdateEmailReq) (*pb.Status, error) {
+	return c.storage.UserService().UpdateEmail(ctx, req)
+}
+
+// RestoreUser undoes DeleteUser unless the email was registered again meanwhile
+func (c *UserService) RestoreUser(ctx context.Context, req *pb.GetUserId) (*pb.User, error) {
+	user, err := c.storage.UserService().RestoreUser(ctx, req)
+	if errors.Is(err, repo.ErrNotDeleted) {
+		return nil, status.Error(codes.NotFound, "deleted user not found")
+	}
+	if errors.Is(err, repo.ErrEmailTaken) {
+		return nil, status.Error(codes.AlreadyExists, "the email of the user is used by another user")
+	}
+	if err != nil {
+		c.log.Error("cannot restore user", logger.Error(err))
+		return nil, err
+	}
+
+	return user, nil
+}
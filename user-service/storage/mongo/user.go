@@ -2,8 +2,10 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	pb "exam/user-service/genproto/user-service"
 	"exam/user-service/pkg/logger"
+	"exam/user-service/storage/repo"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +19,16 @@ type userRepo struct {
 	log        logger.Logger
 }
 
+// deletedAtKey is where the driver keeps User.DeletedAt, users that were
+// never deleted have it empty or missing
+const deletedAtKey = "deletedat"
+
+// notDeleted adds the condition that keeps soft deleted users out of reads and updates
+func notDeleted(filter bson.M) bson.M {
+	filter[deletedAtKey] = bson.M{"$in": bson.A{"", nil}}
+	return filter
+}
+
 func NewUserRepo(collection *mongo.Collection, log logger.Logger) *userRepo {
 	return &userRepo{collection: collection, log: log}
 }
@@ -40,7 +52,7 @@ func (u *userRepo) CreateUser(ctx context.Context, user *pb.User) (*pb.User, err
 
 func (u *userRepo) GetUserById(ctx context.Context, userId *pb.GetUserId) (*pb.User, error) {
 	var response pb.User
-	filter := bson.M{"id": userId.UserId}
+	filter := notDeleted(bson.M{"id": userId.UserId})
 	err := u.collection.FindOne(ctx, filter).Decode(&response)
 	if err != nil {
 		return nil, err
@@ -52,16 +64,18 @@ func (u *userRepo) GetUserById(ctx context.Context, userId *pb.GetUserId) (*pb.U
 func (u *userRepo) UpdateUser(ctx context.Context, req *pb.User) (*pb.User, error) {
 	var response pb.User
 
-	filter := bson.M{"_id": req.Id}
+	filter := notDeleted(bson.M{"_id": req.Id})
 
-	updateReq := bson.M{
-		"$set": bson.M{
-			"first_name": req.FirstName,
-			"last_name":  req.LastName,
-			"age":        req.Age,
-			"updated_at": time.Now(),
-		},
+	set := bson.M{
+		"first_name": req.FirstName,
+		"last_name":  req.LastName,
+		"age":        req.Age,
+		"updated_at": time.Now(),
+	}
+	if req.Locale != "" {
+		set["locale"] = req.Locale
 	}
+	updateReq := bson.M{"$set": set}
 
 	err := u.collection.FindOneAndUpdate(ctx, filter, updateReq).Decode(&response)
 	if err != nil {
@@ -71,14 +85,22 @@ func (u *userRepo) UpdateUser(ctx context.Context, req *pb.User) (*pb.User, erro
 	return &response, nil
 }
 
+// DeleteUser only marks the user deleted, RestoreUser brings them back
+// until PurgeDeletedUsers removes the document
 func (u *userRepo) DeleteUser(ctx context.Context, req *pb.GetUserId) (*pb.Status, error) {
-	filter := bson.M{"id": req.UserId}
-	_, err := u.collection.DeleteOne(ctx, filter)
+	filter := notDeleted(bson.M{"id": req.UserId})
+	updateReq := bson.M{
+		"$set": bson.M{
+			deletedAtKey: time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+
+	updateResult, err := u.collection.UpdateOne(ctx, filter, updateReq)
 	if err != nil {
 		return &pb.Status{Success: false}, err
 	}
 
-	return &pb.Status{Success: true}, nil
+	return &pb.Status{Success: updateResult.MatchedCount == 1}, nil
 }
 
 func (u *userRepo) ListUsers(ctx context.Context, req *pb.GetListRequest) (*pb.GetListResponse, error) {
@@ -89,7 +111,12 @@ func (u *userRepo) ListUsers(ctx context.Context, req *pb.GetListRequest) (*pb.G
 	reqOptions.SetSkip(int64((req.Page - 1) * req.Limit))
 	reqOptions.SetLimit(int64(req.Limit))
 
-	cursor, err := u.collection.Find(ctx, bson.M{}, reqOptions)
+	filter := bson.M{}
+	if !req.IncludeDeleted {
+		filter = notDeleted(filter)
+	}
+
+	cursor, err := u.collection.Find(ctx, filter, reqOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -109,10 +136,14 @@ func (u *userRepo) ListUsers(ctx context.Context, req *pb.GetListRequest) (*pb.G
 }
 
 func (u *userRepo) CheckField(ctx context.Context, req *pb.CheckFieldRequest) (*pb.CheckFieldResponse, error) {
-	filter := bson.M{req.Field: req.Data}
-	err := u.collection.FindOne(ctx, filter)
+	// the email of a deleted user can be registered again
+	filter := notDeleted(bson.M{req.Field: req.Data})
+	err := u.collection.FindOne(ctx, filter).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &pb.CheckFieldResponse{Status: false}, nil
+	}
 	if err != nil {
-		return &pb.CheckFieldResponse{Status: false}, err.Err()
+		return &pb.CheckFieldResponse{Status: false}, err
 	}
 
 	return &pb.CheckFieldResponse{Status: true}, nil
@@ -120,7 +151,7 @@ func (u *userRepo) CheckField(ctx context.Context, req *pb.CheckFieldRequest) (*
 
 func (u *userRepo) Check(ctx context.Context, req *pb.IfExists) (*pb.User, error) {
 	var response pb.User
-	filter := bson.M{"email": req.Email}
+	filter := notDeleted(bson.M{"email": req.Email})
 	err := u.collection.FindOne(ctx, filter).Decode(&response)
 	if err != nil {
 		return nil, err
@@ -130,7 +161,7 @@ func (u *userRepo) Check(ctx context.Context, req *pb.IfExists) (*pb.User, error
 }
 
 func (u *userRepo) UpdateRefreshToken(ctx context.Context, req *pb.UpdateRefreshTokenReq) (*pb.Status, error) {
-	filter := bson.M{"id": req.UserId}
+	filter := notDeleted(bson.M{"id": req.UserId})
 	updateReq := bson.M{
 		"$set": bson.M{
 			"refresh_token": req.RefreshToken,
@@ -147,3 +178,89 @@ func (u *userRepo) UpdateRefreshToken(ctx context.Context, req *pb.UpdateRefresh
 
 	return &pb.Status{Success: true}, nil
 }
+
+// UpdatePassword replaces only the password hash of the user
+func (u *userRepo) UpdatePassword(ctx context.Context, req *pb.UpdatePasswordReq) (*pb.Status, error) {
+	filter := notDeleted(bson.M{"id": req.UserId})
+	updateReq := bson.M{
+		"$set": bson.M{
+			"password":   req.Password,
+			"updated_at": time.Now(),
+		},
+	}
+
+	updateResult, err := u.collection.UpdateOne(ctx, filter, updateReq)
+	if err != nil {
+		return nil, err
+	}
+	if updateResult.MatchedCount == 0 {
+		return &pb.Status{Success: false}, nil
+	}
+
+	return &pb.Status{Success: true}, nil
+}
+
+func (u *userRepo) UpdateEmail(ctx context.Context, req *pb.UpdateEmailReq) (*pb.Status, error) {
+	filter := notDeleted(bson.M{"id": req.UserId})
+	updateReq := bson.M{
+		"$set": bson.M{
+			"email":      req.Email,
+			"updated_at": time.Now(),
+		},
+	}
+
+	updateResult, err := u.collection.UpdateOne(ctx, filter, updateReq)
+	if err != nil {
+		return nil, err
+	}
+	if updateResult.MatchedCount == 0 {
+		return &pb.Status{Success: false}, nil
+	}
+
+	return &pb.Status{Success: true}, nil
+}
+
+func (u *userRepo) RestoreUser(ctx context.Context, req *pb.GetUserId) (*pb.User, error) {
+	var deleted pb.User
+	filter := bson.M{"id": req.UserId, deletedAtKey: bson.M{"$gt": ""}}
+	err := u.collection.FindOne(ctx, filter).Decode(&deleted)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repo.ErrNotDeleted
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	taken, err := u.CheckField(ctx, &pb.CheckFieldRequest{Field: "email", Data: deleted.Email})
+	if err != nil {
+		return nil, err
+	}
+	if taken.Status {
+		return nil, repo.ErrEmailTaken
+	}
+
+	updateReq := bson.M{
+		"$set": bson.M{
+			deletedAtKey: "",
+			"updated_at": time.Now(),
+		},
+	}
+	if _, err := u.collection.UpdateOne(ctx, filter, updateReq); err != nil {
+		return nil, err
+	}
+
+	return u.GetUserById(ctx, req)
+}
+
+// PurgeDeletedUsers removes the users deleted before deletedBefore for good,
+// deletion times are RFC 3339 in UTC so they compare as strings
+func (u *userRepo) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	filter := bson.M{deletedAtKey: bson.M{"$gt": "", "$lt": deletedBefore.UTC().Format(time.RFC3339)}}
+
+	result, err := u.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
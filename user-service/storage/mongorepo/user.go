@@ -3,6 +3,7 @@ package mongorepo
 import (
 	"context"
 	pb "exam/user-service/genproto/user-service"
+	"time"
 )
 
 // UserService interface
@@ -15,4 +16,9 @@ type UserServiceI interface {
 	CheckField(ctx context.Context, req *pb.CheckFieldRequest) (*pb.CheckFieldResponse, error)
 	Check(ctx context.Context, req *pb.IfExists) (*pb.User, error)
 	UpdateRefreshToken(ctx context.Context, req *pb.UpdateRefreshTokenReq) (*pb.Status, error)
+	UpdatePassword(ctx context.Context, req *pb.UpdatePasswordReq) (*pb.Status, error)
+	UpdateEmail(ctx context.Context, req *pb.UpdateEmailReq) (*pb.Status, error)
+	// RestoreUser undoes DeleteUser, deleted users are kept until PurgeDeletedUsers
+	RestoreUser(ctx context.Context, req *pb.GetUserId) (*pb.User, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
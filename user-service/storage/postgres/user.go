@@ -2,11 +2,14 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	pb "exam/user-service/genproto/user-service"
 	"exam/user-service/pkg/db"
 	"exam/user-service/pkg/logger"
 	"exam/user-service/storage/repo"
 	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"time"
 )
 
@@ -15,6 +18,12 @@ type userRepo struct {
 	log logger.Logger
 }
 
+// notDeleted keeps soft deleted users out of reads and updates
+var notDeleted = squirrel.Eq{"deleted_at": nil}
+
+// uniqueViolation is the postgres error code of a unique index refusing a row
+const uniqueViolation = "23505"
+
 // Constructor
 func NewUserRepo(db *db.Postgres, log logger.Logger) repo.UserServiceI {
 	return &userRepo{
@@ -26,12 +35,12 @@ func NewUserRepo(db *db.Postgres, log logger.Logger) repo.UserServiceI {
 func (u *userRepo) CreateUser(ctx context.Context, req *pb.User) (*pb.User, error) {
 	query := u.db.Builder.Insert("users").
 		Columns(`
-		id, first_name, last_name, age, email, password, refresh_token
+		id, first_name, last_name, age, email, password, refresh_token, locale
 		`).
 		Values(
 			req.Id, req.FirstName, req.LastName,
 			req.Age, req.Email, req.Password,
-			req.RefreshToken,
+			req.RefreshToken, req.Locale,
 		).
 		Suffix("RETURNING created_at")
 
@@ -47,8 +56,8 @@ func (u *userRepo) GetUserById(ctx context.Context, req *pb.GetUserId) (*pb.User
 	respUser := &pb.User{}
 
 	query := u.db.Builder.Select(`
-		id, first_name, last_name, age, email, password, refresh_token, created_at
-	`).From("users").Where(squirrel.Eq{"id": req.UserId})
+		id, first_name, last_name, age, email, password, refresh_token, locale, created_at
+	`).From("users").Where(squirrel.Eq{"id": req.UserId}).Where(notDeleted)
 
 	err := query.RunWith(u.db.DB).QueryRow().Scan(
 		&respUser.Id,
@@ -58,6 +67,7 @@ func (u *userRepo) GetUserById(ctx context.Context, req *pb.GetUserId) (*pb.User
 		&respUser.Email,
 		&respUser.Password,
 		&respUser.RefreshToken,
+		&respUser.Locale,
 		&respUser.CreatedAt,
 	)
 	if err != nil {
@@ -70,12 +80,15 @@ func (u *userRepo) GetUserById(ctx context.Context, req *pb.GetUserId) (*pb.User
 func (u *userRepo) UpdateUser(ctx context.Context, req *pb.User) (*pb.User, error) {
 	var (
 		updateMap = make(map[string]interface{})
-		where     = squirrel.And{squirrel.Eq{"id": req.Id}}
+		where     = squirrel.And{squirrel.Eq{"id": req.Id}, notDeleted}
 	)
 
 	updateMap["first_name"] = req.FirstName
 	updateMap["last_name"] = req.LastName
 	updateMap["age"] = req.Age
+	if req.Locale != "" {
+		updateMap["locale"] = req.Locale
+	}
 	updateMap["updated_at"] = time.Now()
 
 	query := u.db.Builder.Update("users").SetMap(updateMap).
@@ -92,9 +105,22 @@ func (u *userRepo) UpdateUser(ctx context.Context, req *pb.User) (*pb.User, erro
 	return req, nil
 }
 
+// DeleteUser only marks the user deleted, RestoreUser brings them back
+// until PurgeDeletedUsers removes the row
 func (u *userRepo) DeleteUser(ctx context.Context, req *pb.GetUserId) (*pb.Status, error) {
-	query := u.db.Builder.Delete("users").Where(squirrel.Eq{"id": req.UserId})
-	_, err := query.RunWith(u.db.DB).Exec()
+	query := u.db.Builder.Update("users").
+		Set("deleted_at", time.Now()).
+		Where(squirrel.Eq{"id": req.UserId}).
+		Where(notDeleted)
+
+	result, err := query.RunWith(u.db.DB).Exec()
+	if err != nil {
+		return &pb.Status{
+			Success: false,
+		}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return &pb.Status{
 			Success: false,
@@ -102,7 +128,7 @@ func (u *userRepo) DeleteUser(ctx context.Context, req *pb.GetUserId) (*pb.Statu
 	}
 
 	return &pb.Status{
-		Success: true,
+		Success: rowsAffected == 1,
 	}, nil
 }
 
@@ -112,8 +138,11 @@ func (u *userRepo) ListUsers(ctx context.Context, req *pb.GetListRequest) (*pb.G
 	)
 
 	query := u.db.Builder.Select(
-		`id, first_name, last_name, age, email, password, refresh_token
+		`id, first_name, last_name, age, email, password, refresh_token, locale, deleted_at
 	`).From("users")
+	if !req.IncludeDeleted {
+		query = query.Where(notDeleted)
+	}
 
 	query = query.Offset(uint64((req.Page - 1) * req.Limit)).Limit(uint64(req.Limit))
 
@@ -124,7 +153,10 @@ func (u *userRepo) ListUsers(ctx context.Context, req *pb.GetListRequest) (*pb.G
 	defer rows.Close()
 
 	for rows.Next() {
-		respUser := &pb.User{}
+		var (
+			respUser  = &pb.User{}
+			deletedAt sql.NullString
+		)
 		err = rows.Scan(
 			&respUser.Id,
 			&respUser.FirstName,
@@ -133,10 +165,13 @@ func (u *userRepo) ListUsers(ctx context.Context, req *pb.GetListRequest) (*pb.G
 			&respUser.Email,
 			&respUser.Password,
 			&respUser.RefreshToken,
+			&respUser.Locale,
+			&deletedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		respUser.DeletedAt = deletedAt.String
 		respUsers.Users = append(respUsers.Users, respUser)
 		respUsers.Count++
 	}
@@ -149,7 +184,8 @@ func (u *userRepo) CheckField(ctx context.Context, req *pb.CheckFieldRequest) (*
 		response = &pb.CheckFieldResponse{}
 	)
 	var resp int
-	num := u.db.Builder.Select("count(1)").From("users").Where(squirrel.Eq{req.Field: req.Data})
+	// the email of a deleted user can be registered again
+	num := u.db.Builder.Select("count(1)").From("users").Where(squirrel.Eq{req.Field: req.Data}).Where(notDeleted)
 
 	err := num.RunWith(u.db.DB).Scan(&resp)
 	if err != nil {
@@ -169,8 +205,8 @@ func (u *userRepo) Check(ctx context.Context, req *pb.IfExists) (*pb.User, error
 	respUser := &pb.User{}
 
 	query := u.db.Builder.Select(`
-		id, first_name, last_name, age, email, password, refresh_token, created_at
-	`).From("users").Where(squirrel.Eq{"email": req.Email})
+		id, first_name, last_name, age, email, password, refresh_token, locale, created_at
+	`).From("users").Where(squirrel.Eq{"email": req.Email}).Where(notDeleted)
 
 	err := query.RunWith(u.db.DB).QueryRow().Scan(
 		&respUser.Id,
@@ -180,6 +216,7 @@ func (u *userRepo) Check(ctx context.Context, req *pb.IfExists) (*pb.User, error
 		&respUser.Email,
 		&respUser.Password,
 		&respUser.RefreshToken,
+		&respUser.Locale,
 		&respUser.CreatedAt,
 	)
 	if err != nil {
@@ -192,7 +229,7 @@ func (u *userRepo) Check(ctx context.Context, req *pb.IfExists) (*pb.User, error
 func (u *userRepo) UpdateRefreshToken(ctx context.Context, req *pb.UpdateRefreshTokenReq) (*pb.Status, error) {
 	var (
 		updateMap = make(map[string]interface{})
-		where     = squirrel.And{squirrel.Eq{"id": req.UserId}}
+		where     = squirrel.And{squirrel.Eq{"id": req.UserId}, notDeleted}
 	)
 
 	updateMap["refresh_token"] = req.RefreshToken
@@ -212,3 +249,94 @@ func (u *userRepo) UpdateRefreshToken(ctx context.Context, req *pb.UpdateRefresh
 		Success: true,
 	}, nil
 }
+
+// UpdatePassword replaces only the password hash of the user
+func (u *userRepo) UpdatePassword(ctx context.Context, req *pb.UpdatePasswordReq) (*pb.Status, error) {
+	query := u.db.Builder.Update("users").
+		Set("password", req.Password).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": req.UserId}).
+		Where(notDeleted)
+
+	result, err := query.RunWith(u.db.DB).Exec()
+	if err != nil {
+		return &pb.Status{
+			Success: false,
+		}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &pb.Status{
+			Success: false,
+		}, err
+	}
+
+	return &pb.Status{
+		Success: rowsAffected == 1,
+	}, nil
+}
+
+func (u *userRepo) UpdateEmail(ctx context.Context, req *pb.UpdateEmailReq) (*pb.Status, error) {
+	query := u.db.Builder.Update("users").
+		Set("email", req.Email).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": req.UserId}).
+		Where(notDeleted)
+
+	result, err := query.RunWith(u.db.DB).Exec()
+	if err != nil {
+		return &pb.Status{
+			Success: false,
+		}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &pb.Status{
+			Success: false,
+		}, err
+	}
+
+	return &pb.Status{
+		Success: rowsAffected == 1,
+	}, nil
+}
+
+// RestoreUser clears deleted_at in one statement, the unique index on the
+// emails of users that are not deleted refuses it when the email was taken
+func (u *userRepo) RestoreUser(ctx context.Context, req *pb.GetUserId) (*pb.User, error) {
+	query := u.db.Builder.Update("users").
+		Set("deleted_at", nil).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": req.UserId}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
+		Suffix("RETURNING id")
+
+	var id string
+	err := query.RunWith(u.db.DB).QueryRow().Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotDeleted
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return nil, repo.ErrEmailTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return u.GetUserById(ctx, req)
+}
+
+// PurgeDeletedUsers removes the users deleted before deletedBefore for good
+func (u *userRepo) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := u.db.Builder.Delete("users").Where(squirrel.Lt{"deleted_at": deletedBefore})
+
+	result, err := query.RunWith(u.db.DB).Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -2,7 +2,16 @@ package repo
 
 import (
 	"context"
+	"errors"
 	pb "exam/user-service/genproto/user-service"
+	"time"
+)
+
+var (
+	// ErrNotDeleted is returned by RestoreUser when no deleted user has the id
+	ErrNotDeleted = errors.New("no deleted user with this id")
+	// ErrEmailTaken is returned by RestoreUser when another user took the email meanwhile
+	ErrEmailTaken = errors.New("email is used by another user")
 )
 
 // UserService interface
@@ -15,4 +24,9 @@ type UserServiceI interface {
 	CheckField(ctx context.Context, req *pb.CheckFieldRequest) (*pb.CheckFieldResponse, error)
 	Check(ctx context.Context, req *pb.IfExists) (*pb.User, error)
 	UpdateRefreshToken(ctx context.Context, req *pb.UpdateRefreshTokenReq) (*pb.Status, error)
+	UpdatePassword(ctx context.Context, req *pb.UpdatePasswordReq) (*pb.Status, error)
+	UpdateEmail(ctx context.Context, req *pb.UpdateEmailReq) (*pb.Status, error)
+	// RestoreUser undoes DeleteUser, deleted users are kept until PurgeDeletedUsers
+	RestoreUser(ctx context.Context, req *pb.GetUserId) (*pb.User, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
 }